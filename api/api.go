@@ -1,17 +1,22 @@
 package api
 
 import (
+	"bytes"
 	"fmt"
 	"neo_zk_starter/circuits"
 	"neo_zk_starter/internal/build"
+	"neo_zk_starter/store"
+	"reflect"
 
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
 	"github.com/nspcc-dev/neo-go/pkg/smartcontract/zkpbinding"
 )
 
 // ProofResult contains all outputs from proof generation
 type ProofResult struct {
+	Circuit        string
 	VerifyArgs     *zkpbinding.VerifyProofArgs
 	Proof          groth16.Proof
 	VerifyingKey   groth16.VerifyingKey
@@ -19,25 +24,57 @@ type ProofResult struct {
 	AdditionalData []string
 }
 
-// GenerateProof generates a proof for the specified circuit with the given input.
+// GenerateProof generates a proof for the specified circuit with the given input,
+// using the keys kept in the artifact store.
 // The input type must match what the circuit's PrepareInput method expects.
 // For example:
 // - hash_commit: expects uint64
 // - merkle_verify: expects struct{LeafHash, ProofElements, Root}
-// - p256_verify: expects struct{PublicKey, MessageHash, Signature}
-func GenerateProof(circuitName string, input interface{}) (*ProofResult, error) {
+// - p256_verify: expects p256_verify.Input
+//
+// A ready circuit assignment, like the one returned by ValidInput, is used as is.
+//
+// GenerateProof loads the circuit keys on every call, use a Prover to keep them
+// in memory across proofs.
+func GenerateProof(s store.ArtifactStore, circuitName string, input interface{}) (*ProofResult, error) {
 	circ, exists := circuits.Get(circuitName)
 	if !exists {
 		return nil, fmt.Errorf("circuit not found: %s", circuitName)
 	}
+	// Invalid input is reported before the keys are loaded.
+	assignment, additionalOutput, err := prepareInput(circuitName, circ, input)
+	if err != nil {
+		return nil, err
+	}
+
+	_, ccs, pk, vk, err := build.Init(s, circuitName, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return prove(circuitName, ccs, pk, vk, assignment, additionalOutput)
+}
 
-	assignment, additionalOutput := circ.PrepareInput(input)
-	if assignment == nil {
-		return nil, fmt.Errorf("failed to prepare input for circuit %s", circuitName)
+// prepareInput turns the input into a circuit assignment
+func prepareInput(circuitName string, circ circuits.Circuit, input interface{}) (circuits.Circuit, []string, error) {
+	if assignment, ok := input.(circuits.Circuit); ok {
+		if reflect.TypeOf(assignment) != reflect.TypeOf(circ) {
+			return nil, nil, fmt.Errorf("assignment of type %T does not belong to circuit %s of type %T", assignment, circuitName, circ)
+		}
+		return assignment, nil, nil
 	}
+	assignment, additionalOutput, err := circ.PrepareInput(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to prepare input for circuit %s: %w", circuitName, err)
+	}
+	return assignment, additionalOutput, nil
+}
 
-	_, ccs, pk, vk := build.Init(circuitName, false)
-	witness, publicWitness := circuits.PrepareWitness(assignment)
+// prove generates a proof with already loaded circuit keys
+func prove(circuitName string, ccs constraint.ConstraintSystem, pk groth16.ProvingKey, vk groth16.VerifyingKey, assignment circuits.Circuit, additionalOutput []string) (*ProofResult, error) {
+	witness, publicWitness, err := circuits.PrepareWitness(assignment)
+	if err != nil {
+		return nil, err
+	}
 
 	proof, err := groth16.Prove(ccs, pk, witness)
 	if err != nil {
@@ -50,6 +87,7 @@ func GenerateProof(circuitName string, input interface{}) (*ProofResult, error)
 	}
 
 	return &ProofResult{
+		Circuit:        circuitName,
 		VerifyArgs:     args,
 		Proof:          proof,
 		VerifyingKey:   vk,
@@ -58,8 +96,39 @@ func GenerateProof(circuitName string, input interface{}) (*ProofResult, error)
 	}, nil
 }
 
-// VerifyProof verifies a proof against a verifying key and public witness
-func VerifyProof(proof groth16.Proof, vk groth16.VerifyingKey, publicWitness witness.Witness) (bool, error) {
+// VerifyProof verifies the proof of an envelope against a verifying key. The
+// envelope must have been created for the same verifying key and its
+// VerifyArgs, the arguments submitted to the verifier contract, must be the
+// ones of its proof and public witness.
+func VerifyProof(e *Envelope, vk groth16.VerifyingKey) (bool, error) {
+	vkHash, err := HashVerifyingKey(vk)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(vkHash, e.VerifyingKeyHash) {
+		return false, fmt.Errorf("proof of circuit %s was generated for a different verifying key", e.Circuit)
+	}
+
+	proof, err := e.DecodeProof()
+	if err != nil {
+		return false, err
+	}
+	publicWitness, err := e.DecodePublicWitness()
+	if err != nil {
+		return false, err
+	}
+	args, err := newVerifyArgs(proof, publicWitness)
+	if err != nil {
+		return false, err
+	}
+	if !args.Equal(e.VerifyArgs) {
+		return false, fmt.Errorf("verify args of circuit %s do not match its proof and public witness", e.Circuit)
+	}
+	return VerifyGroth16(proof, vk, publicWitness)
+}
+
+// VerifyGroth16 verifies a proof against a verifying key and public witness
+func VerifyGroth16(proof groth16.Proof, vk groth16.VerifyingKey, publicWitness witness.Witness) (bool, error) {
 	err := groth16.Verify(proof, vk, publicWitness)
 	if err != nil {
 		return false, fmt.Errorf("proof verification failed: %w", err)
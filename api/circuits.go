@@ -3,11 +3,24 @@ package api
 import (
 	"fmt"
 	"math/big"
+
+	"neo_zk_starter/circuits/eddsa_verify"
+	"neo_zk_starter/circuits/merkle_membership"
+	"neo_zk_starter/circuits/merkle_verify"
+	"neo_zk_starter/circuits/nullifier"
+	"neo_zk_starter/circuits/p256_verify"
+	"neo_zk_starter/circuits/private_vote"
+	"neo_zk_starter/circuits/rollup_transfer"
+	"neo_zk_starter/circuits/sealed_bid"
+	"neo_zk_starter/circuits/secp256k1_verify"
+	"neo_zk_starter/circuits/semaphore"
+	"neo_zk_starter/circuits/smt_verify"
+	"neo_zk_starter/store"
 )
 
 // HashCommitProof generates a proof that you know a preimage for a hash
-func HashCommitProof(preimage uint64) (*ProofResult, error) {
-	return GenerateProof("hash_commit", preimage)
+func HashCommitProof(s store.ArtifactStore, preimage uint64) (*ProofResult, error) {
+	return GenerateProof(s, "hash_commit", preimage)
 }
 
 // MerkleProofInput represents the input for merkle_verify circuit
@@ -18,10 +31,13 @@ type MerkleProofInput struct {
 }
 
 // MerkleProof generates a proof of membership in a Merkle tree
-func MerkleProof(input MerkleProofInput) (*ProofResult, error) {
+//
+// Deprecated: merkle_verify cannot prove right hand leaves, use
+// MerkleMembershipProof.
+func MerkleProof(s store.ArtifactStore, input MerkleProofInput) (*ProofResult, error) {
 	// Validate input
-	if len(input.ProofElements) > 4 {
-		return nil, fmt.Errorf("too many proof elements (max 4)")
+	if len(input.ProofElements) > merkle_verify.MaxProofElements {
+		return nil, fmt.Errorf("too many proof elements (max %d)", merkle_verify.MaxProofElements)
 	}
 	if len(input.ProofElements) == 0 {
 		return nil, fmt.Errorf("proof elements cannot be empty")
@@ -33,73 +49,120 @@ func MerkleProof(input MerkleProofInput) (*ProofResult, error) {
 	// Convert to circuit-compatible format
 	circuitInput := struct {
 		LeafHash      *big.Int
-		ProofElements [4]*big.Int
+		ProofElements []*big.Int
 		Root          *big.Int
 	}{
-		LeafHash: new(big.Int).SetBytes(input.LeafHash),
-		Root:     new(big.Int).SetBytes(input.Root),
+		LeafHash:      new(big.Int).SetBytes(input.LeafHash),
+		ProofElements: make([]*big.Int, len(input.ProofElements)),
+		Root:          new(big.Int).SetBytes(input.Root),
 	}
-
-	// Convert proof elements
-	for i := 0; i < 4; i++ {
-		if i < len(input.ProofElements) {
-			circuitInput.ProofElements[i] = new(big.Int).SetBytes(input.ProofElements[i])
-		} else {
-			circuitInput.ProofElements[i] = new(big.Int) // zero for unused elements
-		}
+	for i, pe := range input.ProofElements {
+		circuitInput.ProofElements[i] = new(big.Int).SetBytes(pe)
 	}
 
-	return GenerateProof("merkle_verify", circuitInput)
+	return GenerateProof(s, "merkle_verify", circuitInput)
 }
 
-// P256ProofInput represents the input for p256_verify circuit
-type P256ProofInput struct {
-	PublicKey struct {
-		X, Y []byte
-	}
-	MessageHash []byte
-	Signature   struct {
-		R, S []byte
+// MerkleMembershipInput represents the input for the merkle_membership
+// circuits, it is the input type of the circuit itself.
+type MerkleMembershipInput = merkle_membership.Input
+
+// MerkleMembershipProof generates a proof of membership in a Merkle tree of
+// depth 20 (merkle_membership) or 32 (merkle_membership_32), the depth is the
+// number of siblings.
+func MerkleMembershipProof(s store.ArtifactStore, input MerkleMembershipInput) (*ProofResult, error) {
+	switch len(input.Siblings) {
+	case merkle_membership.DefaultDepth:
+		return GenerateProof(s, "merkle_membership", input)
+	case merkle_membership.MaxDepth:
+		return GenerateProof(s, "merkle_membership_32", input)
+	default:
+		return nil, fmt.Errorf("no merkle_membership circuit registered for depth %d", len(input.Siblings))
 	}
 }
 
+// P256ProofInput represents the input for p256_verify circuit, it is the
+// input type of the circuit itself.
+type P256ProofInput = p256_verify.Input
+
 // P256Proof generates a proof of a valid ECDSA signature
-func P256Proof(input P256ProofInput) (*ProofResult, error) {
-	// Validate input
-	if len(input.PublicKey.X) == 0 || len(input.PublicKey.Y) == 0 {
-		return nil, fmt.Errorf("invalid public key")
-	}
-	if len(input.MessageHash) == 0 {
-		return nil, fmt.Errorf("message hash cannot be empty")
-	}
-	if len(input.Signature.R) == 0 || len(input.Signature.S) == 0 {
-		return nil, fmt.Errorf("invalid signature")
-	}
+func P256Proof(s store.ArtifactStore, input P256ProofInput) (*ProofResult, error) {
+	return GenerateProof(s, "p256_verify", input)
+}
 
-	// Convert to circuit-compatible format
-	circuitInput := struct {
-		PublicKey struct {
-			X, Y *big.Int
-		}
-		MessageHash *big.Int
-		Signature   struct {
-			R, S *big.Int
-		}
-	}{
-		PublicKey: struct {
-			X, Y *big.Int
-		}{
-			X: new(big.Int).SetBytes(input.PublicKey.X),
-			Y: new(big.Int).SetBytes(input.PublicKey.Y),
-		},
-		MessageHash: new(big.Int).SetBytes(input.MessageHash),
-		Signature: struct {
-			R, S *big.Int
-		}{
-			R: new(big.Int).SetBytes(input.Signature.R),
-			S: new(big.Int).SetBytes(input.Signature.S),
-		},
-	}
+// Secp256k1ProofInput represents the input for secp256k1_verify circuit, it
+// is the input type of the circuit itself. secp256k1_verify.NewInput builds
+// it from a recoverable wallet signature.
+type Secp256k1ProofInput = secp256k1_verify.Input
+
+// Secp256k1Proof generates a proof of a valid secp256k1 ECDSA signature
+func Secp256k1Proof(s store.ArtifactStore, input Secp256k1ProofInput) (*ProofResult, error) {
+	return GenerateProof(s, "secp256k1_verify", input)
+}
+
+// EdDSAProofInput represents the input for eddsa_verify circuit, it is the
+// input type of the circuit itself, eddsa_verify.Sign makes the signature.
+type EdDSAProofInput = eddsa_verify.Input
+
+// EdDSAProof generates a proof of a valid EdDSA signature on Jubjub
+func EdDSAProof(s store.ArtifactStore, input EdDSAProofInput) (*ProofResult, error) {
+	return GenerateProof(s, "eddsa_verify", input)
+}
+
+// SMTProofInput represents the input for smt_verify circuit, it is produced
+// by smt.Tree.Proof for a full depth tree.
+type SMTProofInput = smt_verify.Input
+
+// SMTProof generates a proof that a key is or is not in a sparse Merkle tree
+func SMTProof(s store.ArtifactStore, input SMTProofInput) (*ProofResult, error) {
+	return GenerateProof(s, "smt_verify", input)
+}
+
+// RollupBatchInput represents the input for rollup_transfer circuit, it is
+// produced by rollup.State.Apply.
+type RollupBatchInput = rollup_transfer.Input
+
+// RollupProof generates a proof of a batch of transfers moving the rollup state
+// from the old to the new root
+func RollupProof(s store.ArtifactStore, input *RollupBatchInput) (*ProofResult, error) {
+	return GenerateProof(s, "rollup_transfer", input)
+}
+
+// NullifierProofInput represents the input for nullifier circuit, it is the
+// input type of the circuit itself.
+type NullifierProofInput = nullifier.Input
+
+// NullifierProof generates a proof of the secret of a commitment revealing
+// its nullifier for the external nullifier.
+func NullifierProof(s store.ArtifactStore, input NullifierProofInput) (*ProofResult, error) {
+	return GenerateProof(s, "nullifier", input)
+}
+
+// SemaphoreProofInput represents the input for semaphore circuit, it is
+// produced by identity.Group.Proof for a group of depth 20.
+type SemaphoreProofInput = semaphore.Input
+
+// SemaphoreProof generates a proof that a group member signals the signal hash
+// for the external nullifier
+func SemaphoreProof(s store.ArtifactStore, input SemaphoreProofInput) (*ProofResult, error) {
+	return GenerateProof(s, "semaphore", input)
+}
+
+// PrivateVoteProofInput represents the input for private_vote circuit, its
+// member is produced by identity.Group.Proof for the election id.
+type PrivateVoteProofInput = private_vote.Input
+
+// PrivateVoteProof generates a proof of a ballot of an eligible voter for the
+// election, committing to the choice with the salt
+func PrivateVoteProof(s store.ArtifactStore, input PrivateVoteProofInput) (*ProofResult, error) {
+	return GenerateProof(s, "private_vote", input)
+}
+
+// SealedBidProofInput represents the input for sealed_bid circuit
+type SealedBidProofInput = sealed_bid.Input
 
-	return GenerateProof("p256_verify", circuitInput)
+// SealedBidProof generates a proof that a sealed bid is within the bounds of
+// its mode, without opening it
+func SealedBidProof(s store.ArtifactStore, input SealedBidProofInput) (*ProofResult, error) {
+	return GenerateProof(s, "sealed_bid", input)
 }
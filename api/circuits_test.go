@@ -0,0 +1,106 @@
+package api
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"neo_zk_starter/circuits/merkle_membership"
+	"neo_zk_starter/circuits/secp256k1_verify"
+	"neo_zk_starter/merkle"
+	"neo_zk_starter/store"
+
+	"github.com/consensys/gnark-crypto/ecc/secp256k1/ecdsa"
+	"github.com/nspcc-dev/neo-go/pkg/crypto/hash"
+	"github.com/nspcc-dev/neo-go/pkg/wallet"
+)
+
+func TestP256Proof(t *testing.T) {
+	if testing.Short() {
+		t.Skip("p256_verify setup is slow")
+	}
+
+	w, err := wallet.NewAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := hash.Sha256([]byte("hello neo"))
+
+	s := store.NewMemoryStore()
+
+	// Invalid input is reported instead of panicking.
+	if _, err := P256Proof(s, P256ProofInput{PublicKey: w.PublicKey(), MessageHash: hashed.BytesBE()}); err == nil {
+		t.Fatal("missing signature must be rejected")
+	}
+
+	result, err := P256Proof(s, P256ProofInput{
+		PublicKey:   w.PublicKey(),
+		MessageHash: hashed.BytesBE(),
+		Signature:   w.PrivateKey().SignHash(hashed),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyGroth16(result.Proof, result.VerifyingKey, result.PublicWitness); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSecp256k1Proof(t *testing.T) {
+	if testing.Short() {
+		t.Skip("secp256k1_verify setup is slow")
+	}
+
+	key, err := ecdsa.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := hash.Sha256([]byte("hello neo"))
+	v, r, sig, err := key.SignForRecover(hashed.BytesBE(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := make([]byte, 65)
+	r.FillBytes(signature[:32])
+	sig.FillBytes(signature[32:64])
+	signature[64] = byte(v)
+
+	input, err := secp256k1_verify.NewInput(hashed.BytesBE(), signature, secp256k1_verify.Recoverable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Secp256k1Proof(store.NewMemoryStore(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyGroth16(result.Proof, result.VerifyingKey, result.PublicWitness); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMerkleMembershipProof(t *testing.T) {
+	leaf := big.NewInt(42)
+	siblings := make([]*big.Int, merkle_membership.DefaultDepth)
+	for i := range siblings {
+		siblings[i] = big.NewInt(int64(i + 1))
+	}
+	index := uint64(0b101)
+
+	s := store.NewMemoryStore()
+	result, err := MerkleMembershipProof(s, MerkleMembershipInput{
+		LeafHash: leaf,
+		Index:    index,
+		Siblings: siblings,
+		Root:     merkle.ComputeRoot(leaf, index, siblings),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyGroth16(result.Proof, result.VerifyingKey, result.PublicWitness); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MerkleMembershipProof(s, MerkleMembershipInput{LeafHash: leaf, Siblings: siblings[:8], Root: leaf}); err == nil {
+		t.Fatal("unsupported depth must be rejected")
+	}
+}
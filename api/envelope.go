@@ -0,0 +1,384 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/nspcc-dev/neo-go/pkg/smartcontract/zkpbinding"
+)
+
+// EnvelopeVersion is the version of the proof envelope format written by this
+// package.
+const EnvelopeVersion = 1
+
+// Format selects the encoding of a proof envelope.
+type Format int
+
+const (
+	// FormatJSON encodes the envelope as JSON with hex encoded byte fields.
+	FormatJSON Format = iota
+	// FormatBinary encodes the envelope as length prefixed fields.
+	FormatBinary
+)
+
+// envelopeMagic starts every binary encoded envelope.
+var envelopeMagic = []byte("NZKP")
+
+// HexBytes is a byte slice encoded as a hex string in JSON.
+type HexBytes []byte
+
+// MarshalText implements encoding.TextMarshaler
+func (b HexBytes) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(b)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (b *HexBytes) UnmarshalText(text []byte) error {
+	data, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	*b = data
+	return nil
+}
+
+// VerifyArgs holds the arguments of the verifyProof method of the generated
+// verifier contract, see zkpbinding.VerifyProofArgs.
+type VerifyArgs struct {
+	A               HexBytes   `json:"a"`
+	B               HexBytes   `json:"b"`
+	C               HexBytes   `json:"c"`
+	PublicWitnesses []HexBytes `json:"publicWitnesses"`
+}
+
+// Envelope is the serializable form of a proof. It carries everything needed to
+// verify the proof off-chain or to submit it to the verifier contract, so it can
+// be moved from the prover to the component that talks to the chain.
+type Envelope struct {
+	Version int    `json:"version"`
+	Circuit string `json:"circuit"`
+	// VerifyingKeyHash is the sha256 of the binary verifying key the proof
+	// was generated for.
+	VerifyingKeyHash HexBytes `json:"verifyingKeyHash"`
+	// Proof is the binary groth16 proof.
+	Proof HexBytes `json:"proof"`
+	// PublicWitness is the binary public witness.
+	PublicWitness HexBytes   `json:"publicWitness"`
+	VerifyArgs    VerifyArgs `json:"verifyArgs"`
+}
+
+// HashVerifyingKey returns the sha256 of the binary verifying key.
+func HashVerifyingKey(vk groth16.VerifyingKey) ([]byte, error) {
+	h := sha256.New()
+	if _, err := vk.WriteTo(h); err != nil {
+		return nil, fmt.Errorf("failed to hash verifying key: %w", err)
+	}
+	return h.Sum(nil), nil
+}
+
+// NewEnvelope packs a proof of the circuit into a serializable envelope.
+func NewEnvelope(circuitName string, proof groth16.Proof, vk groth16.VerifyingKey, publicWitness witness.Witness) (*Envelope, error) {
+	vkHash, err := HashVerifyingKey(vk)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if _, err := proof.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("failed to encode proof: %w", err)
+	}
+
+	e := &Envelope{
+		Version:          EnvelopeVersion,
+		Circuit:          circuitName,
+		VerifyingKeyHash: vkHash,
+		Proof:            buf.Bytes(),
+	}
+	if err := e.setPublicWitness(proof, publicWitness); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Envelope packs the proof result into a serializable envelope.
+func (r *ProofResult) Envelope() (*Envelope, error) {
+	return NewEnvelope(r.Circuit, r.Proof, r.VerifyingKey, r.PublicWitness)
+}
+
+// SetPublicWitness replaces the public witness of the envelope, the verifier
+// contract arguments are updated to match.
+func (e *Envelope) SetPublicWitness(publicWitness witness.Witness) error {
+	proof, err := e.DecodeProof()
+	if err != nil {
+		return err
+	}
+	return e.setPublicWitness(proof, publicWitness)
+}
+
+func (e *Envelope) setPublicWitness(proof groth16.Proof, publicWitness witness.Witness) error {
+	data, err := publicWitness.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to encode public witness: %w", err)
+	}
+	args, err := newVerifyArgs(proof, publicWitness)
+	if err != nil {
+		return err
+	}
+
+	e.PublicWitness = data
+	e.VerifyArgs = args
+	return nil
+}
+
+// newVerifyArgs returns the verifyProof arguments of the proof and public
+// witness.
+func newVerifyArgs(proof groth16.Proof, publicWitness witness.Witness) (VerifyArgs, error) {
+	verifyArgs, err := zkpbinding.GetVerifyProofArgs(proof, publicWitness)
+	if err != nil {
+		return VerifyArgs{}, fmt.Errorf("failed to get verify proof args: %w", err)
+	}
+
+	args := VerifyArgs{A: verifyArgs.A, B: verifyArgs.B, C: verifyArgs.C}
+	for i, w := range verifyArgs.PublicWitnesses {
+		b, ok := w.([]byte)
+		if !ok {
+			return VerifyArgs{}, fmt.Errorf("unexpected public witness %d type %T", i, w)
+		}
+		args.PublicWitnesses = append(args.PublicWitnesses, b)
+	}
+	return args, nil
+}
+
+// Equal reports whether the arguments are byte for byte the same.
+func (a VerifyArgs) Equal(other VerifyArgs) bool {
+	return bytes.Equal(a.A, other.A) && bytes.Equal(a.B, other.B) && bytes.Equal(a.C, other.C) &&
+		slices.EqualFunc(a.PublicWitnesses, other.PublicWitnesses, func(x, y HexBytes) bool { return bytes.Equal(x, y) })
+}
+
+// DecodeProof returns the groth16 proof of the envelope.
+func (e *Envelope) DecodeProof() (groth16.Proof, error) {
+	proof := groth16.NewProof(ecc.BLS12_381)
+	if _, err := proof.ReadFrom(bytes.NewReader(e.Proof)); err != nil {
+		return nil, fmt.Errorf("failed to decode proof: %w", err)
+	}
+	return proof, nil
+}
+
+// DecodeRawProof reads a proof in the raw gnark encoding, as written by
+// groth16.Proof.WriteTo, like ProofResult.Proof.
+func DecodeRawProof(data []byte) (groth16.Proof, error) {
+	proof := groth16.NewProof(ecc.BLS12_381)
+	n, err := proof.ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode proof: %w", err)
+	}
+	if n != int64(len(data)) {
+		return nil, fmt.Errorf("failed to decode proof: %d trailing bytes", int64(len(data))-n)
+	}
+	return proof, nil
+}
+
+// DecodePublicWitness returns the public witness of the envelope.
+func (e *Envelope) DecodePublicWitness() (witness.Witness, error) {
+	w, err := witness.New(ecc.BLS12_381.ScalarField())
+	if err != nil {
+		return nil, err
+	}
+	if err := w.UnmarshalBinary(e.PublicWitness); err != nil {
+		return nil, fmt.Errorf("failed to decode public witness: %w", err)
+	}
+	return w, nil
+}
+
+// VerifyProofArgs returns the arguments of the verifier contract call.
+func (e *Envelope) VerifyProofArgs() *zkpbinding.VerifyProofArgs {
+	args := &zkpbinding.VerifyProofArgs{A: e.VerifyArgs.A, B: e.VerifyArgs.B, C: e.VerifyArgs.C}
+	for _, w := range e.VerifyArgs.PublicWitnesses {
+		args.PublicWitnesses = append(args.PublicWitnesses, []byte(w))
+	}
+	return args
+}
+
+// Marshal encodes the envelope in the given format.
+func Marshal(e *Envelope, format Format) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(e, "", "  ")
+	case FormatBinary:
+		return e.MarshalBinary()
+	default:
+		return nil, fmt.Errorf("unknown envelope format %d", format)
+	}
+}
+
+// Unmarshal decodes an envelope in either format, the format is detected from
+// the data.
+func Unmarshal(data []byte) (*Envelope, error) {
+	e := &Envelope{}
+	if bytes.HasPrefix(data, envelopeMagic) {
+		if err := e.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("failed to decode envelope: %w", err)
+	}
+	if e.Version != EnvelopeVersion {
+		return nil, fmt.Errorf("unsupported envelope version %d", e.Version)
+	}
+	return e, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The encoding is the magic,
+// a version byte and every field prefixed with its uint32 big endian length.
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(envelopeMagic)
+	buf.WriteByte(byte(e.Version))
+
+	write := func(b []byte) {
+		binary.Write(&buf, binary.BigEndian, uint32(len(b)))
+		buf.Write(b)
+	}
+	write([]byte(e.Circuit))
+	write(e.VerifyingKeyHash)
+	write(e.Proof)
+	write(e.PublicWitness)
+	write(e.VerifyArgs.A)
+	write(e.VerifyArgs.B)
+	write(e.VerifyArgs.C)
+	binary.Write(&buf, binary.BigEndian, uint32(len(e.VerifyArgs.PublicWitnesses)))
+	for _, w := range e.VerifyArgs.PublicWitnesses {
+		write(w)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (e *Envelope) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	magic := make([]byte, len(envelopeMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, envelopeMagic) {
+		return errors.New("not a binary proof envelope")
+	}
+	version, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("failed to decode envelope: %w", err)
+	}
+	if version != EnvelopeVersion {
+		return fmt.Errorf("unsupported envelope version %d", version)
+	}
+
+	readLen := func() (uint32, error) {
+		var n uint32
+		err := binary.Read(r, binary.BigEndian, &n)
+		return n, err
+	}
+	read := func() ([]byte, error) {
+		n, err := readLen()
+		if err != nil {
+			return nil, err
+		}
+		if int64(n) > int64(r.Len()) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(r, b)
+		return b, err
+	}
+
+	d := Envelope{Version: int(version)}
+	circuit, err := read()
+	if err != nil {
+		return fmt.Errorf("failed to decode envelope: %w", err)
+	}
+	d.Circuit = string(circuit)
+	for _, f := range []*HexBytes{&d.VerifyingKeyHash, &d.Proof, &d.PublicWitness, &d.VerifyArgs.A, &d.VerifyArgs.B, &d.VerifyArgs.C} {
+		if *f, err = read(); err != nil {
+			return fmt.Errorf("failed to decode envelope: %w", err)
+		}
+	}
+
+	n, err := readLen()
+	if err != nil {
+		return fmt.Errorf("failed to decode envelope: %w", err)
+	}
+	for i := uint32(0); i < n; i++ {
+		b, err := read()
+		if err != nil {
+			return fmt.Errorf("failed to decode envelope: %w", err)
+		}
+		d.VerifyArgs.PublicWitnesses = append(d.VerifyArgs.PublicWitnesses, b)
+	}
+	if r.Len() != 0 {
+		return errors.New("failed to decode envelope: trailing data")
+	}
+
+	*e = d
+	return nil
+}
+
+// ParsePublicWitness reads a public witness, either in the binary witness
+// encoding or as a JSON list of public inputs. Inputs are numbers or decimal
+// and 0x prefixed hex strings, in the order of the public circuit variables.
+func ParsePublicWitness(data []byte) (witness.Witness, error) {
+	w, err := witness.New(ecc.BLS12_381.ScalarField())
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := w.UnmarshalBinary(data); err != nil {
+			return nil, fmt.Errorf("failed to decode public witness: %w", err)
+		}
+		return w, nil
+	}
+
+	var inputs []any
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	dec.UseNumber()
+	if err := dec.Decode(&inputs); err != nil {
+		return nil, fmt.Errorf("public inputs must be a JSON list of numbers or strings: %w", err)
+	}
+
+	values := make(chan any, len(inputs))
+	for i, in := range inputs {
+		var s string
+		switch in := in.(type) {
+		case json.Number:
+			s = in.String()
+		case string:
+			s = in
+		default:
+			return nil, fmt.Errorf("public input %d must be a number or a string, got %v", i, in)
+		}
+		v, ok := new(big.Int).SetString(s, 0)
+		if !ok || v.Sign() < 0 {
+			return nil, fmt.Errorf("public input %d is not a valid field element: %q", i, s)
+		}
+		if v.Cmp(ecc.BLS12_381.ScalarField()) >= 0 {
+			return nil, fmt.Errorf("public input %d exceeds the scalar field: %s", i, s)
+		}
+		values <- v
+	}
+	close(values)
+
+	if err := w.Fill(len(inputs), 0, values); err != nil {
+		return nil, fmt.Errorf("failed to fill public witness: %w", err)
+	}
+	return w, nil
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"bytes"
+	"testing"
+
+	"neo_zk_starter/circuits"
+	_ "neo_zk_starter/circuits/all"
+	"neo_zk_starter/internal/build"
+	"neo_zk_starter/store"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+func TestEnvelope(t *testing.T) {
+	s := store.NewMemoryStore()
+	result, err := HashCommitProof(s, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := result.Envelope()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Circuit != "hash_commit" {
+		t.Fatalf("unexpected circuit %q", envelope.Circuit)
+	}
+
+	for name, format := range map[string]Format{"json": FormatJSON, "binary": FormatBinary} {
+		t.Run(name, func(t *testing.T) {
+			data, err := Marshal(envelope, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := Unmarshal(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := VerifyProof(decoded, result.VerifyingKey); !ok || err != nil {
+				t.Fatalf("decoded envelope does not verify: %v", err)
+			}
+
+			args := decoded.VerifyProofArgs()
+			if !bytes.Equal(args.A, result.VerifyArgs.A) || !bytes.Equal(args.B, result.VerifyArgs.B) || !bytes.Equal(args.C, result.VerifyArgs.C) {
+				t.Fatal("verify proof args changed")
+			}
+			if len(args.PublicWitnesses) != len(result.VerifyArgs.PublicWitnesses) {
+				t.Fatal("public witnesses changed")
+			}
+			for i := range args.PublicWitnesses {
+				if !bytes.Equal(args.PublicWitnesses[i].([]byte), result.VerifyArgs.PublicWitnesses[i].([]byte)) {
+					t.Fatalf("public witness %d changed", i)
+				}
+			}
+		})
+	}
+
+	// Keys from another setup of the same circuit must be refused.
+	circ, _ := circuits.Get("hash_commit")
+	ccs, err := build.Compile(circ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyProof(envelope, vk); err == nil {
+		t.Fatal("envelope must not verify with a different verifying key")
+	}
+
+	// Verify args are what the contract receives, they must match the proof.
+	for name, mutate := range map[string]func(*VerifyArgs){
+		"a":             func(args *VerifyArgs) { args.A[0] ^= 1 },
+		"publicWitness": func(args *VerifyArgs) { args.PublicWitnesses[0][0] ^= 1 },
+		"missing":       func(args *VerifyArgs) { args.PublicWitnesses = nil },
+	} {
+		mutated := *envelope
+		mutated.VerifyArgs = VerifyArgs{
+			A:               bytes.Clone(envelope.VerifyArgs.A),
+			B:               envelope.VerifyArgs.B,
+			C:               envelope.VerifyArgs.C,
+			PublicWitnesses: []HexBytes{bytes.Clone(envelope.VerifyArgs.PublicWitnesses[0])},
+		}
+		mutate(&mutated.VerifyArgs)
+		if _, err := VerifyProof(&mutated, result.VerifyingKey); err == nil {
+			t.Fatalf("envelope with mutated verify args (%s) must not verify", name)
+		}
+	}
+
+	data, _ := Marshal(envelope, FormatBinary)
+	if _, err := Unmarshal(data[:len(data)-1]); err == nil {
+		t.Fatal("truncated envelope must not decode")
+	}
+}
+
+func TestParsePublicWitness(t *testing.T) {
+	s := store.NewMemoryStore()
+	result, err := HashCommitProof(s, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := result.Envelope()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	binaryWitness, err := ParsePublicWitness(envelope.PublicWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitment := binaryWitness.Vector().(fr.Vector)[0].String()
+
+	if _, err := ParsePublicWitness([]byte(`["0x10", -1]`)); err == nil {
+		t.Fatal("negative public input must be rejected")
+	}
+
+	w, err := ParsePublicWitness([]byte(`["` + commitment + `"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := envelope.SetPublicWitness(w); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyProof(envelope, result.VerifyingKey); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err = ParsePublicWitness([]byte(`[1]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := envelope.SetPublicWitness(w); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyProof(envelope, result.VerifyingKey); err == nil {
+		t.Fatal("proof must not verify with a different public input")
+	}
+}
+
+func TestDecodeRawProof(t *testing.T) {
+	result, err := HashCommitProof(store.NewMemoryStore(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw bytes.Buffer
+	if _, err := result.Proof.WriteTo(&raw); err != nil {
+		t.Fatal(err)
+	}
+	proof, err := DecodeRawProof(raw.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := NewEnvelope("hash_commit", proof, result.VerifyingKey, result.PublicWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyProof(envelope, result.VerifyingKey); !ok || err != nil {
+		t.Fatalf("raw proof does not verify: %v", err)
+	}
+
+	if _, err := DecodeRawProof(raw.Bytes()[:raw.Len()-1]); err == nil {
+		t.Fatal("truncated proof must not decode")
+	}
+	if _, err := DecodeRawProof(append(raw.Bytes(), 0)); err == nil {
+		t.Fatal("proof with trailing bytes must not decode")
+	}
+}
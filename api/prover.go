@@ -0,0 +1,149 @@
+package api
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/internal/build"
+	"neo_zk_starter/store"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+)
+
+// Prover keeps the compiled circuits and keys in memory once they are loaded
+// from the artifact store and generates proofs on a bounded pool of workers.
+// It is safe for concurrent use.
+type Prover struct {
+	store   store.ArtifactStore
+	workers chan struct{}
+
+	mu       sync.Mutex
+	circuits map[string]*loadedCircuit
+}
+
+// loadedCircuit holds the circuit keys, they are loaded once on first use.
+// A failed load is dropped from the prover so that the next use retries.
+type loadedCircuit struct {
+	once sync.Once
+	err  error
+
+	circ circuits.Circuit
+	ccs  constraint.ConstraintSystem
+	pk   groth16.ProvingKey
+	vk   groth16.VerifyingKey
+}
+
+// ProofRequest is a single statement to prove in a batch.
+type ProofRequest struct {
+	Circuit string
+	Input   interface{}
+}
+
+// BatchResult is the outcome of a ProofRequest, in the same position of the batch.
+type BatchResult struct {
+	Result *ProofResult
+	Err    error
+}
+
+// NewProver creates a Prover that reads keys from the artifact store and runs
+// at most workers proofs at once. Zero or less workers means one per CPU.
+func NewProver(s store.ArtifactStore, workers int) *Prover {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &Prover{
+		store:    s,
+		workers:  make(chan struct{}, workers),
+		circuits: make(map[string]*loadedCircuit),
+	}
+}
+
+// Load loads the keys of the circuit if they are not in memory yet. It can be
+// used to warm the prover up before the first proof.
+func (p *Prover) Load(circuitName string) error {
+	_, err := p.load(circuitName)
+	return err
+}
+
+func (p *Prover) load(circuitName string) (*loadedCircuit, error) {
+	if _, exists := circuits.Get(circuitName); !exists {
+		return nil, fmt.Errorf("circuit not found: %s", circuitName)
+	}
+
+	p.mu.Lock()
+	lc, ok := p.circuits[circuitName]
+	if !ok {
+		lc = &loadedCircuit{}
+		p.circuits[circuitName] = lc
+	}
+	p.mu.Unlock()
+
+	lc.once.Do(func() {
+		lc.circ, lc.ccs, lc.pk, lc.vk, lc.err = build.Init(p.store, circuitName, false, nil)
+	})
+	if lc.err != nil {
+		p.mu.Lock()
+		if p.circuits[circuitName] == lc {
+			delete(p.circuits, circuitName)
+		}
+		p.mu.Unlock()
+		return nil, lc.err
+	}
+	return lc, nil
+}
+
+// VerifyingKey returns the verifying key of the circuit.
+func (p *Prover) VerifyingKey(circuitName string) (groth16.VerifyingKey, error) {
+	lc, err := p.load(circuitName)
+	if err != nil {
+		return nil, err
+	}
+	return lc.vk, nil
+}
+
+// Prove generates a proof for the circuit with the given input, see
+// GenerateProof for the expected input types.
+func (p *Prover) Prove(circuitName string, input interface{}) (*ProofResult, error) {
+	lc, err := p.load(circuitName)
+	if err != nil {
+		return nil, err
+	}
+	assignment, additionalOutput, err := prepareInput(circuitName, lc.circ, input)
+	if err != nil {
+		return nil, err
+	}
+
+	p.workers <- struct{}{}
+	defer func() { <-p.workers }()
+
+	return prove(circuitName, lc.ccs, lc.pk, lc.vk, assignment, additionalOutput)
+}
+
+// ProveBatch proves the requests on as many goroutines as the prover has
+// workers and returns their results in request order. A failing request does
+// not stop the others.
+func (p *Prover) ProveBatch(requests []ProofRequest) []BatchResult {
+	results := make([]BatchResult, len(requests))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(cap(p.workers), len(requests)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i].Result, results[i].Err = p.Prove(requests[i].Circuit, requests[i].Input)
+			}
+		}()
+	}
+	for i := range requests {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return results
+}
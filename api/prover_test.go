@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"neo_zk_starter/circuits"
+	_ "neo_zk_starter/circuits/all"
+	"neo_zk_starter/store"
+)
+
+// countingStore counts the artifacts opened from the underlying store.
+type countingStore struct {
+	store.ArtifactStore
+
+	mu    sync.Mutex
+	opens map[string]int
+}
+
+func (s *countingStore) Open(ns store.Namespace, circuitName, name string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	s.opens[store.FileName(ns, circuitName, name)]++
+	s.mu.Unlock()
+	return s.ArtifactStore.Open(ns, circuitName, name)
+}
+
+func TestProverBatch(t *testing.T) {
+	s := &countingStore{ArtifactStore: store.NewMemoryStore(), opens: make(map[string]int)}
+
+	// Create the keys once, the prover must then only load them a single time.
+	if _, err := HashCommitProof(s, 1); err != nil {
+		t.Fatal(err)
+	}
+	s.opens = make(map[string]int)
+
+	p := NewProver(s, 4)
+	requests := make([]ProofRequest, 16)
+	for i := range requests {
+		requests[i] = ProofRequest{Circuit: "hash_commit", Input: uint64(i)}
+	}
+	requests = append(requests, ProofRequest{Circuit: "missing", Input: uint64(0)})
+
+	results := p.ProveBatch(requests)
+	if len(results) != len(requests) {
+		t.Fatalf("expected %d results, got %d", len(requests), len(results))
+	}
+	for i, r := range results[:len(results)-1] {
+		if r.Err != nil {
+			t.Fatalf("request %d failed: %v", i, r.Err)
+		}
+		if _, err := VerifyGroth16(r.Result.Proof, r.Result.VerifyingKey, r.Result.PublicWitness); err != nil {
+			t.Fatalf("request %d does not verify: %v", i, err)
+		}
+	}
+	if results[len(results)-1].Err == nil {
+		t.Fatal("unknown circuit must fail")
+	}
+
+	if n := s.opens["hash_commit_prover_key"]; n != 1 {
+		t.Fatalf("prover key read %d times, expected once", n)
+	}
+}
+
+// failingStore fails to open the artifacts until it is healed.
+type failingStore struct {
+	store.ArtifactStore
+
+	mu      sync.Mutex
+	failing bool
+}
+
+func (s *failingStore) Open(ns store.Namespace, circuitName, name string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failing {
+		return nil, errors.New("store is unavailable")
+	}
+	return s.ArtifactStore.Open(ns, circuitName, name)
+}
+
+// TestProverRetry checks that a failed load is not kept, the prover loads the
+// keys once the store recovers.
+func TestProverRetry(t *testing.T) {
+	s := &failingStore{ArtifactStore: store.NewMemoryStore()}
+	if _, err := HashCommitProof(s, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewProver(s, 1)
+	s.failing = true
+	if err := p.Load("hash_commit"); err == nil {
+		t.Fatal("load from a failing store succeeded")
+	}
+	s.failing = false
+	if err := p.Load("hash_commit"); err != nil {
+		t.Fatalf("load after the store recovered: %v", err)
+	}
+	if _, err := p.Prove("hash_commit", uint64(1)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAssignmentType checks that a ready assignment of another circuit is
+// refused.
+func TestAssignmentType(t *testing.T) {
+	circ, _ := circuits.Get("hash_commit")
+	other, _ := circuits.Get("nullifier")
+	if _, _, err := prepareInput("hash_commit", circ, other.ValidInput()); err == nil {
+		t.Fatal("assignment of another circuit must be refused")
+	}
+	if _, _, err := prepareInput("hash_commit", circ, circ.ValidInput()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,8 +2,17 @@
 package all
 
 import (
+	_ "neo_zk_starter/circuits/eddsa_verify"
 	_ "neo_zk_starter/circuits/hash_commit"
+	_ "neo_zk_starter/circuits/merkle_membership"
 	_ "neo_zk_starter/circuits/merkle_verify"
+	_ "neo_zk_starter/circuits/nullifier"
 	_ "neo_zk_starter/circuits/p256_verify"
+	_ "neo_zk_starter/circuits/private_vote"
+	_ "neo_zk_starter/circuits/rollup_transfer"
+	_ "neo_zk_starter/circuits/sealed_bid"
+	_ "neo_zk_starter/circuits/secp256k1_verify"
+	_ "neo_zk_starter/circuits/semaphore"
+	_ "neo_zk_starter/circuits/smt_verify"
 	// Add new circuits here
 )
@@ -0,0 +1,137 @@
+// Package eddsa_verify verifies EdDSA signatures on Jubjub, the twisted
+// Edwards curve over the BLS12-381 scalar field. Its arithmetic is native to
+// the proofs, so a signature costs a few thousand constraints where
+// p256_verify emulates the P256 fields with hundreds of thousands. Messages
+// are field elements, signed with MiMC as the hash.
+package eddsa_verify
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/internal/util"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards/eddsa"
+	tedwards "github.com/consensys/gnark-crypto/ecc/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+	"github.com/consensys/gnark/std/hash/mimc"
+	stdeddsa "github.com/consensys/gnark/std/signature/eddsa"
+)
+
+// Circuit proves a signature of the public key over the message. The
+// signature itself stays private.
+type Circuit struct {
+	PublicKey stdeddsa.PublicKey `gnark:",public"`
+	Message   frontend.Variable  `gnark:",public"`
+	Signature stdeddsa.Signature
+}
+
+// Input is the input of the circuit, Sign produces the signature.
+type Input struct {
+	PublicKey eddsa.PublicKey
+	Message   *big.Int // element of the scalar field
+	Signature []byte   // 64 bytes, compressed R || S
+}
+
+// VerifyEdDSASig verifies the signature of the message, the hash is MiMC.
+func VerifyEdDSASig(api frontend.API, publicKey stdeddsa.PublicKey, message frontend.Variable, signature stdeddsa.Signature) error {
+	curve, err := twistededwards.NewEdCurve(api, tedwards.BLS12_381)
+	if err != nil {
+		return err
+	}
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	return stdeddsa.Verify(curve, signature, message, publicKey, &h)
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	return VerifyEdDSASig(api, c.PublicKey, c.Message, c.Signature)
+}
+
+func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
+	var inputData Input
+	switch in := input.(type) {
+	case Input:
+		inputData = in
+	case *Input:
+		if in == nil {
+			return nil, nil, fmt.Errorf("input is nil")
+		}
+		inputData = *in
+	default:
+		return nil, nil, fmt.Errorf("input must be eddsa_verify.Input for EdDSAVerifyCircuit, got %T", input)
+	}
+	if _, err := messageBytes(inputData.Message); err != nil {
+		return nil, nil, err
+	}
+	var sig eddsa.Signature
+	if _, err := sig.SetBytes(inputData.Signature); err != nil {
+		return nil, nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	assignment := &Circuit{Message: inputData.Message}
+	assignment.PublicKey.Assign(tedwards.BLS12_381, inputData.PublicKey.Bytes())
+	assignment.Signature.Assign(tedwards.BLS12_381, inputData.Signature)
+	return assignment, []string{}, nil
+}
+
+// ParseInput implements circuits.JSONInput, the input is
+// {"publicKey": "<32 byte compressed key>", "message": "0x..",
+// "signature": "<R || S>"}.
+func (c *Circuit) ParseInput(data []byte) (interface{}, error) {
+	var in struct {
+		PublicKey string      `json:"publicKey"`
+		Message   util.Number `json:"message"`
+		Signature string      `json:"signature"`
+	}
+	if err := util.DecodeInput(data, &in); err != nil {
+		return nil, err
+	}
+
+	var out Input
+	keyBytes, err := util.ParseHex("publicKey", in.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := out.PublicKey.SetBytes(keyBytes); err != nil {
+		return nil, fmt.Errorf("publicKey: %w", err)
+	}
+	if out.Message, err = util.ParseFieldElement("message", in.Message); err != nil {
+		return nil, err
+	}
+	if out.Signature, err = util.ParseHex("signature", in.Signature); err != nil {
+		return nil, err
+	}
+	if len(out.Signature) != 64 {
+		return nil, fmt.Errorf("signature: must be 64 bytes, got %d", len(out.Signature))
+	}
+	return out, nil
+}
+
+// ValidInput signs a fixed message with a key derived from a fixed seed.
+func (c *Circuit) ValidInput() circuits.Circuit {
+	key, err := GenerateKey(bytes.NewReader(bytes.Repeat([]byte{1}, 32)))
+	if err != nil {
+		panic(err)
+	}
+	message := util.HashInputs(util.MiMC, []interface{}{uint64(42)})
+	signature, err := Sign(key, message)
+	if err != nil {
+		panic(err)
+	}
+
+	return circuits.MustPrepareInput(c, Input{
+		PublicKey: key.PublicKey,
+		Message:   message,
+		Signature: signature,
+	})
+}
+
+func init() {
+	circuits.Register("eddsa_verify", func() circuits.Circuit { return &Circuit{} })
+}
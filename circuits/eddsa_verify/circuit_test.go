@@ -0,0 +1,131 @@
+package eddsa_verify
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"neo_zk_starter/circuits/p256_verify"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/test"
+)
+
+func TestCircuit(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := &Circuit{}
+	validAssignment := circuit.ValidInput().(*Circuit)
+
+	// Test with valid inputs
+	assert.ProverSucceeded(circuit, validAssignment,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+
+	// Test with another message
+	message := *validAssignment
+	message.Message = 43
+	assert.ProverFailed(circuit, &message,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+
+	// Test with the key of another signer
+	other, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := *validAssignment
+	key.PublicKey.A.X, key.PublicKey.A.Y = other.PublicKey.A.X.BigInt(new(big.Int)), other.PublicKey.A.Y.BigInt(new(big.Int))
+	assert.ProverFailed(circuit, &key,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+}
+
+func TestSign(t *testing.T) {
+	key, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := big.NewInt(1337)
+	sig, err := Sign(key, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Verify(&key.PublicKey, message, sig); err != nil || !ok {
+		t.Fatalf("signature does not verify: %v", err)
+	}
+	if ok, _ := Verify(&key.PublicKey, big.NewInt(1338), sig); ok {
+		t.Fatal("signature verifies for another message")
+	}
+
+	for _, m := range []*big.Int{nil, big.NewInt(-1), ecc.BLS12_381.ScalarField()} {
+		if _, err := Sign(key, m); err == nil {
+			t.Fatalf("message %v accepted", m)
+		}
+	}
+
+	if _, _, err := (&Circuit{}).PrepareInput(Input{PublicKey: key.PublicKey, Message: message, Signature: sig[:63]}); err == nil {
+		t.Fatal("short signature accepted")
+	}
+}
+
+func TestParseInput(t *testing.T) {
+	key, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := big.NewInt(1337)
+	sig, err := Sign(key, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := key.PublicKey.Bytes()
+
+	c := &Circuit{}
+	in, err := c.ParseInput([]byte(fmt.Sprintf(`{"publicKey": "%x", "message": 1337, "signature": "%x"}`, pub, sig)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := in.(Input)
+	if !input.PublicKey.Equal(&key.PublicKey) || input.Message.Cmp(message) != 0 {
+		t.Fatalf("wrong input %+v", input)
+	}
+	if _, _, err := c.PrepareInput(input); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range []string{
+		fmt.Sprintf(`{"publicKey": "%x", "message": 1337, "signature": "%x"}`, pub[:31], sig),
+		fmt.Sprintf(`{"publicKey": "%x", "message": 1337, "signature": "%x"}`, pub, sig[:63]),
+		fmt.Sprintf(`{"publicKey": "%x", "signature": "%x"}`, pub, sig),
+	} {
+		if _, err := c.ParseInput([]byte(data)); err == nil {
+			t.Fatalf("%s accepted", data)
+		}
+	}
+}
+
+// TestConstraints compares the cost of a signature with p256_verify, run
+// with -v to see the numbers.
+func TestConstraints(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiling p256_verify is slow")
+	}
+
+	constraints := func(circuit frontend.Circuit) int {
+		ccs, err := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ccs.GetNbConstraints()
+	}
+	eddsa := constraints(&Circuit{})
+	p256 := constraints(&p256_verify.Circuit{})
+	t.Logf("eddsa_verify: %d constraints, p256_verify: %d constraints, %.0fx fewer", eddsa, p256, float64(p256)/float64(eddsa))
+	if eddsa >= p256 {
+		t.Fatalf("eddsa_verify is not cheaper than p256_verify")
+	}
+}
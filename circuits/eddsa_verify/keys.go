@@ -0,0 +1,53 @@
+package eddsa_verify
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/mimc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards/eddsa"
+)
+
+// GenerateKey returns a new Jubjub key pair, the seed is read from r or from
+// crypto/rand when r is nil. The same seed gives the same key.
+func GenerateKey(r io.Reader) (*eddsa.PrivateKey, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	return eddsa.GenerateKey(r)
+}
+
+// Sign signs the message, an element of the BLS12-381 scalar field, with MiMC
+// as the hash, the way the circuit verifies it. The signature is 64 bytes,
+// the compressed R and S.
+func Sign(priv *eddsa.PrivateKey, message *big.Int) ([]byte, error) {
+	msg, err := messageBytes(message)
+	if err != nil {
+		return nil, err
+	}
+	return priv.Sign(msg, mimc.NewMiMC())
+}
+
+// Verify checks a signature made with Sign natively.
+func Verify(pub *eddsa.PublicKey, message *big.Int, sig []byte) (bool, error) {
+	msg, err := messageBytes(message)
+	if err != nil {
+		return false, err
+	}
+	return pub.Verify(sig, msg, mimc.NewMiMC())
+}
+
+// messageBytes returns the big endian bytes of the message, which must be
+// an element of the scalar field.
+func messageBytes(message *big.Int) ([]byte, error) {
+	if message == nil || message.Sign() < 0 || message.Cmp(fr.Modulus()) >= 0 {
+		return nil, fmt.Errorf("message must be an element of the scalar field, got %v", message)
+	}
+	var e fr.Element
+	e.SetBigInt(message)
+	b := e.Bytes()
+	return b[:], nil
+}
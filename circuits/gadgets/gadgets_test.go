@@ -0,0 +1,220 @@
+package gadgets
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+type bitsCircuit struct {
+	V frontend.Variable
+
+	n      int
+	lookup bool
+}
+
+func (c *bitsCircuit) Define(api frontend.API) error {
+	if c.lookup {
+		AssertBitsLookup(api, c.V, c.n)
+	} else {
+		AssertBits(api, c.V, c.n)
+	}
+	return nil
+}
+
+// compareCircuit checks the comparisons of A and B against the expected
+// results, and the assertions that hold for them.
+type compareCircuit struct {
+	A, B         frontend.Variable
+	Less, LessEq frontend.Variable
+	assertLess   bool
+	assertLessEq bool
+	n            int
+}
+
+func (c *compareCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(IsLess(api, c.A, c.B, c.n), c.Less)
+	api.AssertIsEqual(IsLessOrEqual(api, c.A, c.B, c.n), c.LessEq)
+	if c.assertLess {
+		AssertIsLess(api, c.A, c.B, c.n)
+	}
+	if c.assertLessEq {
+		AssertIsLessOrEqual(api, c.A, c.B, c.n)
+	}
+	return nil
+}
+
+func solved(circuit, assignment frontend.Circuit) bool {
+	return test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()) == nil
+}
+
+func TestAssertBits(t *testing.T) {
+	field := ecc.BLS12_381.ScalarField()
+	minusOne := new(big.Int).Sub(field, big.NewInt(1))
+	for _, n := range []int{1, 8, 64, 128, MaxBits} {
+		max := new(big.Int).Sub(pow2(n), big.NewInt(1))
+		for _, lookup := range []bool{false, true} {
+			circuit := &bitsCircuit{n: n, lookup: lookup}
+			for _, tc := range []struct {
+				v  *big.Int
+				ok bool
+			}{
+				{big.NewInt(0), true},
+				{max, true},
+				{pow2(n), false},
+				{minusOne, false},
+			} {
+				if solved(circuit, &bitsCircuit{V: tc.v}) != tc.ok {
+					t.Fatalf("%d bits, lookup %v: %s accepted %v", n, lookup, tc.v, !tc.ok)
+				}
+			}
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	one := big.NewInt(1)
+	for _, n := range []int{1, 8, 64, MaxBits} {
+		max := new(big.Int).Sub(pow2(n), one)
+		for _, tc := range [][2]*big.Int{
+			{big.NewInt(0), big.NewInt(0)},
+			{big.NewInt(0), one},
+			{one, big.NewInt(0)},
+			{max, max},
+			{new(big.Int).Sub(max, one), max},
+			{max, new(big.Int).Sub(max, one)},
+			{big.NewInt(0), max},
+			{max, big.NewInt(0)},
+		} {
+			a, b := tc[0], tc[1]
+			less, lessEq := a.Cmp(b) < 0, a.Cmp(b) <= 0
+			for _, assert := range []struct{ less, lessEq bool }{{false, false}, {true, false}, {false, true}} {
+				circuit := &compareCircuit{n: n, assertLess: assert.less, assertLessEq: assert.lessEq}
+				assignment := &compareCircuit{A: a, B: b, Less: bit(less), LessEq: bit(lessEq)}
+				ok := (!assert.less || less) && (!assert.lessEq || lessEq)
+				if solved(circuit, assignment) != ok {
+					t.Fatalf("%d bits, %s and %s, asserting %+v: solved %v", n, a, b, assert, !ok)
+				}
+			}
+
+			// The wrong result is refused
+			circuit := &compareCircuit{n: n}
+			if solved(circuit, &compareCircuit{A: a, B: b, Less: bit(!less), LessEq: bit(lessEq)}) ||
+				solved(circuit, &compareCircuit{A: a, B: b, Less: bit(less), LessEq: bit(!lessEq)}) {
+				t.Fatalf("%d bits, %s and %s: wrong result accepted", n, a, b)
+			}
+		}
+	}
+}
+
+type rangeCircuit struct {
+	V, Low, High frontend.Variable
+}
+
+func (c *rangeCircuit) Define(api frontend.API) error {
+	AssertInRange(api, c.V, c.Low, c.High, 64)
+	return nil
+}
+
+func TestAssertInRange(t *testing.T) {
+	for v, ok := range map[int64]bool{99: false, 100: true, 500: true, 1000: true, 1001: false} {
+		if solved(&rangeCircuit{}, &rangeCircuit{V: v, Low: 100, High: 1000}) != ok {
+			t.Fatalf("%d accepted %v", v, !ok)
+		}
+	}
+}
+
+func TestOperandSize(t *testing.T) {
+	for _, n := range []int{0, MaxBits + 1} {
+		err := test.IsSolved(&compareCircuit{n: n}, &compareCircuit{A: 0, B: 0, Less: 0, LessEq: 1}, ecc.BLS12_381.ScalarField())
+		if err == nil || !strings.Contains(err.Error(), "operand size") {
+			t.Fatalf("%d bit operands: expected an operand size error, got %v", n, err)
+		}
+	}
+}
+
+type uint256Circuit struct {
+	A, B        Uint256
+	Less, Equal frontend.Variable
+
+	assertLess, assertLessEq bool
+}
+
+func (c *uint256Circuit) Define(api frontend.API) error {
+	c.A.AssertValid(api)
+	c.B.AssertValid(api)
+	api.AssertIsEqual(IsLessUint256(api, c.A, c.B), c.Less)
+	api.AssertIsEqual(IsEqualUint256(api, c.A, c.B), c.Equal)
+	if c.assertLess {
+		AssertIsLessUint256(api, c.A, c.B)
+	}
+	if c.assertLessEq {
+		AssertIsLessOrEqualUint256(api, c.A, c.B)
+	}
+	return nil
+}
+
+func TestUint256(t *testing.T) {
+	limb := pow2(LimbBits)
+	max := new(big.Int).Sub(pow2(2*LimbBits), big.NewInt(1))
+	values := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		new(big.Int).Sub(limb, big.NewInt(1)),
+		limb,
+		new(big.Int).Add(limb, big.NewInt(5)),
+		new(big.Int).Add(limb, big.NewInt(4)),
+		new(big.Int).Lsh(big.NewInt(3), LimbBits), // larger high limb, smaller low limb than the next
+		new(big.Int).Add(new(big.Int).Lsh(big.NewInt(2), LimbBits), new(big.Int).Sub(limb, big.NewInt(1))),
+		ecc.BLS12_381.ScalarField(), // does not fit in a field element
+		max,
+	}
+	for _, a := range values {
+		for _, b := range values {
+			av, err := ValueOf(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bv, err := ValueOf(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			less, equal := a.Cmp(b) < 0, a.Cmp(b) == 0
+			if !solved(&uint256Circuit{}, &uint256Circuit{A: av, B: bv, Less: bit(less), Equal: bit(equal)}) {
+				t.Fatalf("%s and %s: not solved", a, b)
+			}
+			if solved(&uint256Circuit{}, &uint256Circuit{A: av, B: bv, Less: bit(!less), Equal: bit(equal)}) {
+				t.Fatalf("%s and %s: wrong result accepted", a, b)
+			}
+			assignment := &uint256Circuit{A: av, B: bv, Less: bit(less), Equal: bit(equal)}
+			if solved(&uint256Circuit{assertLess: true}, assignment) != less ||
+				solved(&uint256Circuit{assertLessEq: true}, assignment) != (less || equal) {
+				t.Fatalf("%s and %s: wrong assertion", a, b)
+			}
+		}
+	}
+
+	// Limbs beyond 128 bits are refused
+	if solved(&uint256Circuit{}, &uint256Circuit{
+		A: Uint256{Lo: limb, Hi: 0}, B: Uint256{Lo: 0, Hi: 1}, Less: 0, Equal: 0,
+	}) {
+		t.Fatal("invalid limb accepted")
+	}
+
+	for _, x := range []*big.Int{nil, big.NewInt(-1), new(big.Int).Add(max, big.NewInt(1))} {
+		if _, err := ValueOf(x); err == nil {
+			t.Fatalf("%v accepted", x)
+		}
+	}
+}
+
+func bit(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
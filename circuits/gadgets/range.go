@@ -0,0 +1,78 @@
+// Package gadgets holds range checks and comparisons for circuits. The
+// comparisons are bounded: they take the bit size n of their operands, which
+// must fit in n bits. Check the operands with AssertBits unless they are known
+// to be small, e.g. bits of a hash or public inputs the contract checks.
+package gadgets
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+// MaxBits is the largest operand size of the comparisons. Differences of
+// MaxBits operands do not wrap around the BLS12-381 scalar field, whose
+// modulus is just below 2^255.
+const MaxBits = 253
+
+// AssertBits checks that v fits in n bits by decomposing it into n boolean
+// constraints. n may be up to the field size.
+func AssertBits(api frontend.API, v frontend.Variable, n int) {
+	api.ToBinary(v, n)
+}
+
+// AssertBitsLookup checks that v fits in n bits with gnark's lookup based
+// range checker. It has a fixed setup cost and then costs less per check than
+// AssertBits, so it pays off in circuits making many checks. With Groth16 the
+// proof carries a commitment to the checked values, like with the SHA-256
+// hasher.
+func AssertBitsLookup(api frontend.API, v frontend.Variable, n int) {
+	rangecheck.New(api).Check(v, n)
+}
+
+// AssertIsLess checks a < b for n bit operands.
+func AssertIsLess(api frontend.API, a, b frontend.Variable, n int) {
+	checkSize(n)
+	// b - a - 1 wraps around to a value of at least 2^n for a >= b
+	api.ToBinary(api.Sub(b, a, 1), n)
+}
+
+// AssertIsLessOrEqual checks a <= b for n bit operands.
+func AssertIsLessOrEqual(api frontend.API, a, b frontend.Variable, n int) {
+	checkSize(n)
+	api.ToBinary(api.Sub(b, a), n)
+}
+
+// AssertInRange checks low <= v <= high for n bit operands.
+func AssertInRange(api frontend.API, v, low, high frontend.Variable, n int) {
+	AssertIsLessOrEqual(api, low, v, n)
+	AssertIsLessOrEqual(api, v, high, n)
+}
+
+// IsLess returns 1 when a < b and 0 otherwise, for n bit operands.
+func IsLess(api frontend.API, a, b frontend.Variable, n int) frontend.Variable {
+	checkSize(n)
+	// 2^n + a - b is in [1, 2^(n+1)) and has bit n set iff a >= b
+	bits := api.ToBinary(api.Add(api.Sub(a, b), pow2(n)), n+1)
+	return api.Sub(1, bits[n])
+}
+
+// IsLessOrEqual returns 1 when a <= b and 0 otherwise, for n bit operands.
+func IsLessOrEqual(api frontend.API, a, b frontend.Variable, n int) frontend.Variable {
+	return api.Sub(1, IsLess(api, b, a, n))
+}
+
+// checkSize panics for operand sizes the comparisons do not support, a
+// mistake of the circuit and not of its input.
+func checkSize(n int) {
+	if n < 1 || n > MaxBits {
+		panic(fmt.Sprintf("gadgets: operand size must be between 1 and %d bits, got %d", MaxBits, n))
+	}
+}
+
+// pow2 returns 2^n.
+func pow2(n int) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(n))
+}
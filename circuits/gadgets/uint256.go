@@ -0,0 +1,63 @@
+package gadgets
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// LimbBits is the size of the limbs of a Uint256.
+const LimbBits = 128
+
+// Uint256 is a 256 bit unsigned integer in a circuit, like a NEP-17 amount,
+// as two 128 bit limbs. It is wider than the BLS12-381 scalar field, so it
+// cannot be a single variable. The comparisons expect valid limbs, see
+// AssertValid.
+type Uint256 struct {
+	Lo frontend.Variable // low 128 bits
+	Hi frontend.Variable // high 128 bits
+}
+
+// ValueOf returns the assignment of x, which must be in [0, 2^256).
+func ValueOf(x *big.Int) (Uint256, error) {
+	if x == nil || x.Sign() < 0 || x.BitLen() > 2*LimbBits {
+		return Uint256{}, fmt.Errorf("value must be a 256 bit unsigned integer, got %v", x)
+	}
+	mask := new(big.Int).Sub(pow2(LimbBits), big.NewInt(1))
+	return Uint256{
+		Lo: new(big.Int).And(x, mask),
+		Hi: new(big.Int).Rsh(x, LimbBits),
+	}, nil
+}
+
+// AssertValid checks that both limbs fit in 128 bits.
+func (u Uint256) AssertValid(api frontend.API) {
+	AssertBits(api, u.Lo, LimbBits)
+	AssertBits(api, u.Hi, LimbBits)
+}
+
+// IsEqualUint256 returns 1 when a == b and 0 otherwise.
+func IsEqualUint256(api frontend.API, a, b Uint256) frontend.Variable {
+	return api.Mul(api.IsZero(api.Sub(a.Lo, b.Lo)), api.IsZero(api.Sub(a.Hi, b.Hi)))
+}
+
+// IsLessUint256 returns 1 when a < b and 0 otherwise, comparing the high
+// limbs first.
+func IsLessUint256(api frontend.API, a, b Uint256) frontend.Variable {
+	hiLess := IsLess(api, a.Hi, b.Hi, LimbBits)
+	hiEqual := api.IsZero(api.Sub(a.Hi, b.Hi))
+	loLess := IsLess(api, a.Lo, b.Lo, LimbBits)
+	// hiLess and hiEqual exclude each other, the sum is a bit
+	return api.Add(hiLess, api.Mul(hiEqual, loLess))
+}
+
+// AssertIsLessUint256 checks a < b.
+func AssertIsLessUint256(api frontend.API, a, b Uint256) {
+	api.AssertIsEqual(IsLessUint256(api, a, b), 1)
+}
+
+// AssertIsLessOrEqualUint256 checks a <= b.
+func AssertIsLessOrEqualUint256(api frontend.API, a, b Uint256) {
+	api.AssertIsEqual(IsLessUint256(api, b, a), 0)
+}
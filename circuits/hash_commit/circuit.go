@@ -1,61 +1,85 @@
 package hash_commit
 
 import (
+	"fmt"
 	"neo_zk_starter/circuits"
+	"neo_zk_starter/circuits/hasher"
 	"neo_zk_starter/internal/util"
 
 	"github.com/consensys/gnark/frontend"
-	"github.com/consensys/gnark/std/hash/mimc"
 )
 
 type Circuit struct {
 	HiddenInput     frontend.Variable
 	InputCommitment frontend.Variable `gnark:",public"`
+
+	hash util.Hash // hash of the commitment, MiMC when empty
 }
 
 func (c *Circuit) Define(api frontend.API) error {
-	// Prepare our MiMC hasher
-	// It is a SNARK-friendly alternative to SHA2
+	// Prepare our hasher, MiMC unless the registered variant picks another
+	// MiMC is a SNARK-friendly alternative to SHA2
 	// Provides reduction in constraint count
 	// at a cost of a longer verification time
-	mimc, _ := mimc.NewMiMC(api)
+	h, err := hasher.New(api, c.hash)
+	if err != nil {
+		return err
+	}
 
 	// Write the hidden input to the hasher
-	mimc.Write(c.HiddenInput)
+	h.Write(c.HiddenInput)
 
 	// Check that the input commitment matches the hidden input hash
-	api.AssertIsEqual(c.InputCommitment, mimc.Sum())
+	api.AssertIsEqual(c.InputCommitment, h.Sum())
 	return nil
 }
 
-func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string) {
-	// The HashInputsToString function emulates the MiMC hash used in the circuit.
+func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
+	// The HashInputs function emulates the hash used in the circuit.
 	// It accepts any number of inputs as uint64 or *big.Int.
 	// Data is written to the hash in sequential writes, one for each input.
-	// The function returns a string representation of the hash.
-	// The helper function StringToBigInt can convert the string to a big.Int for use as a circuit input.
+	// The function returns the hash as a big.Int for use as a circuit input.
 	uint64Input, ok := input.(uint64)
 	if !ok {
-		panic("Input must be uint64 for HashCommitCircuit")
+		return nil, nil, fmt.Errorf("input must be uint64 for HashCommitCircuit, got %T", input)
 	}
 
-	inputCommit := util.HashInputsToString([]interface{}{uint64Input})
+	inputCommit := util.HashInputs(c.hash, []interface{}{uint64Input})
 
 	return &Circuit{
 		HiddenInput:     uint64Input,
-		InputCommitment: util.StringToBigInt(inputCommit, 10),
-	}, []string{inputCommit}
+		InputCommitment: inputCommit,
+	}, []string{inputCommit.String()}, nil
+}
+
+// ParseInput implements circuits.JSONInput, the input is {"preimage": 42}.
+func (c *Circuit) ParseInput(data []byte) (interface{}, error) {
+	var in struct {
+		Preimage util.Number `json:"preimage"`
+	}
+	if err := util.DecodeInput(data, &in); err != nil {
+		return nil, err
+	}
+	preimage, err := util.ParseFieldElement("preimage", in.Preimage)
+	if err != nil {
+		return nil, err
+	}
+	if !preimage.IsUint64() {
+		return nil, fmt.Errorf("preimage: must fit in 64 bits")
+	}
+	return preimage.Uint64(), nil
 }
 
 func (c *Circuit) ValidInput() circuits.Circuit {
 	var hiddenInput uint64 = 42.0
-	preparedInput, _ := c.PrepareInput(hiddenInput)
-
-	return preparedInput
+	return circuits.MustPrepareInput(c, hiddenInput)
 }
 
 func init() {
 	circuits.Register("hash_commit", func() circuits.Circuit {
 		return &Circuit{}
 	})
+	circuits.Register("hash_commit_sha256", func() circuits.Circuit {
+		return &Circuit{hash: util.SHA256}
+	})
 }
@@ -1,8 +1,14 @@
 package hash_commit
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
 	"testing"
 
+	"neo_zk_starter/internal/util"
+
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/test"
@@ -31,3 +37,113 @@ func TestHashCommitCircuit(t *testing.T) {
 		test.WithCurves(ecc.BLS12_381),
 		test.WithBackends(backend.GROTH16))
 }
+
+func TestHashVariants(t *testing.T) {
+	for _, h := range util.Hashes {
+		circuit := &Circuit{hash: h}
+		assignment := circuit.ValidInput().(*Circuit)
+		if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err != nil {
+			t.Fatalf("%s: %v", h, err)
+		}
+
+		// The commitment of another hash does not match
+		if h != util.MiMC {
+			mimc := (&Circuit{}).ValidInput().(*Circuit)
+			if err := test.IsSolved(circuit, mimc, ecc.BLS12_381.ScalarField()); err == nil {
+				t.Fatalf("%s: MiMC commitment accepted", h)
+			}
+		}
+	}
+}
+
+func TestParseInput(t *testing.T) {
+	circuit := &Circuit{}
+
+	input, err := circuit.ParseInput([]byte(`{"preimage": "0x2a"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.(uint64) != 42 {
+		t.Fatalf("unexpected preimage %v", input)
+	}
+
+	for data, field := range map[string]string{
+		`{"preimage": "x"}`:                   "preimage",
+		`{"preimage": -1}`:                    "preimage",
+		`{"preimage": true}`:                  "preimage",
+		`{"preimage": "0x10000000000000000"}`: "preimage",
+		`{"preimag": 42}`:                     "preimag",
+	} {
+		if _, err := circuit.ParseInput([]byte(data)); err == nil || !strings.Contains(err.Error(), field) {
+			t.Fatalf("input %s: expected an error naming %s, got %v", data, field, err)
+		}
+	}
+}
+
+func TestDigestCircuit(t *testing.T) {
+	circuit := &DigestCircuit{}
+	assignment := circuit.ValidInput().(*DigestCircuit)
+	if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The public input is the digest CryptoLib.sha256 returns for the preimage
+	// followed by the salt
+	salt := big.NewInt(7)
+	_, public, err := circuit.PrepareInput(DigestInput{Preimage: 42, Salt: salt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	preimage := make([]byte, 40)
+	preimage[7], preimage[39] = 42, 7
+	digest := sha256.Sum256(preimage)
+	if public[0] != hex.EncodeToString(digest[:]) {
+		t.Fatalf("unexpected digest %s", public[0])
+	}
+	if _, _, err := circuit.PrepareInput(DigestInput{Preimage: 42}); err == nil {
+		t.Fatal("missing salt accepted")
+	}
+
+	// Another preimage or salt does not match the digest
+	if err := test.IsSolved(circuit, &DigestCircuit{
+		HiddenInput: 43,
+		Salt:        assignment.Salt,
+		DigestHigh:  assignment.DigestHigh,
+		DigestLow:   assignment.DigestLow,
+	}, ecc.BLS12_381.ScalarField()); err == nil {
+		t.Fatal("wrong preimage accepted")
+	}
+	if err := test.IsSolved(circuit, &DigestCircuit{
+		HiddenInput: assignment.HiddenInput,
+		Salt:        salt,
+		DigestHigh:  assignment.DigestHigh,
+		DigestLow:   assignment.DigestLow,
+	}, ecc.BLS12_381.ScalarField()); err == nil {
+		t.Fatal("wrong salt accepted")
+	}
+
+	// Neither do swapped halves
+	if err := test.IsSolved(circuit, &DigestCircuit{
+		HiddenInput: assignment.HiddenInput,
+		Salt:        assignment.Salt,
+		DigestHigh:  assignment.DigestLow,
+		DigestLow:   assignment.DigestHigh,
+	}, ecc.BLS12_381.ScalarField()); err == nil {
+		t.Fatal("swapped digest accepted")
+	}
+}
+
+func TestDigestParseInput(t *testing.T) {
+	circuit := &DigestCircuit{}
+
+	input, err := circuit.ParseInput([]byte(`{"preimage": 42, "salt": "0x7"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in := input.(DigestInput); in.Preimage != 42 || in.Salt.Int64() != 7 {
+		t.Fatalf("unexpected input %v", input)
+	}
+	if _, err := circuit.ParseInput([]byte(`{"preimage": 42}`)); err == nil || !strings.Contains(err.Error(), "salt") {
+		t.Fatalf("expected an error naming salt, got %v", err)
+	}
+}
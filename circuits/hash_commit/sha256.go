@@ -0,0 +1,189 @@
+package hash_commit
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/circuits/hasher"
+	"neo_zk_starter/internal/util"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/sha2"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/nspcc-dev/neo-go/pkg/core/native/nativehashes"
+)
+
+// DigestCircuit commits to the preimage with a SHA-256 digest that Neo
+// contracts compute with CryptoLib.sha256. The preimage is hashed as 8 bytes
+// big endian followed by the salt as 32 bytes big endian, the 32 byte digest
+// is split into two 128 bit public inputs. Without the salt a 64 bit preimage
+// could be found from the digest by brute force.
+type DigestCircuit struct {
+	HiddenInput frontend.Variable
+	Salt        frontend.Variable
+	DigestHigh  frontend.Variable `gnark:",public"` // first 16 bytes of the digest
+	DigestLow   frontend.Variable `gnark:",public"` // last 16 bytes of the digest
+}
+
+// DigestInput is the input of DigestCircuit.
+type DigestInput struct {
+	Preimage uint64
+	Salt     *big.Int // random field element, keep it with the preimage to reveal it
+}
+
+// SHA256Preimage returns the bytes of the preimage and salt the digest is
+// taken of, a reveal passes them to the contract.
+func SHA256Preimage(preimage uint64, salt *big.Int) []byte {
+	var s fr.Element
+	s.SetBigInt(salt)
+	return append(binary.BigEndian.AppendUint64(nil, preimage), s.Marshal()...)
+}
+
+func (c *DigestCircuit) Define(api frontend.API) error {
+	h, err := sha2.New(api)
+	if err != nil {
+		return err
+	}
+
+	// The preimage fits in 8 bytes, written most significant byte first
+	bits := api.ToBinary(c.HiddenInput, 64)
+	preimage := make([]uints.U8, 8)
+	for i := range preimage {
+		lsb := 8 * (len(preimage) - 1 - i)
+		preimage[i] = uints.U8{Val: api.FromBinary(bits[lsb : lsb+8]...)}
+	}
+	h.Write(preimage)
+	h.Write(hasher.ElementBytes(api, c.Salt))
+	digest := h.Sum()
+
+	api.AssertIsEqual(c.DigestHigh, hasher.BytesToElement(api, digest[:16]))
+	api.AssertIsEqual(c.DigestLow, hasher.BytesToElement(api, digest[16:]))
+	return nil
+}
+
+func (c *DigestCircuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
+	in, ok := input.(DigestInput)
+	if !ok {
+		return nil, nil, fmt.Errorf("input must be DigestInput for DigestCircuit, got %T", input)
+	}
+	if in.Salt == nil {
+		return nil, nil, fmt.Errorf("salt is required")
+	}
+
+	digest := sha256.Sum256(SHA256Preimage(in.Preimage, in.Salt))
+	high := new(big.Int).SetBytes(digest[:16])
+	low := new(big.Int).SetBytes(digest[16:])
+
+	return &DigestCircuit{
+		HiddenInput: in.Preimage,
+		Salt:        in.Salt,
+		DigestHigh:  high,
+		DigestLow:   low,
+	}, []string{fmt.Sprintf("%x", digest)}, nil
+}
+
+// ParseInput implements circuits.JSONInput, the input is {"preimage": 42,
+// "salt": "0x.."}.
+func (c *DigestCircuit) ParseInput(data []byte) (interface{}, error) {
+	var in struct {
+		Preimage util.Number `json:"preimage"`
+		Salt     util.Number `json:"salt"`
+	}
+	if err := util.DecodeInput(data, &in); err != nil {
+		return nil, err
+	}
+	preimage, err := util.ParseFieldElement("preimage", in.Preimage)
+	if err != nil {
+		return nil, err
+	}
+	if !preimage.IsUint64() {
+		return nil, fmt.Errorf("preimage: must fit in 64 bits")
+	}
+	salt, err := util.ParseFieldElement("salt", in.Salt)
+	if err != nil {
+		return nil, err
+	}
+	return DigestInput{Preimage: preimage.Uint64(), Salt: salt}, nil
+}
+
+func (c *DigestCircuit) ValidInput() circuits.Circuit {
+	return circuits.MustPrepareInput(c, DigestInput{Preimage: 42, Salt: big.NewInt(123456789)})
+}
+
+// ContractMethods implements circuits.ContractExtension. CommitWithProof
+// stores the digest of a verified proof for the committer, Reveal looks up the
+// CryptoLib.sha256 digest of a preimage for the same committer.
+func (c *DigestCircuit) ContractMethods() circuits.ContractMethods {
+	return circuits.ContractMethods{
+		Imports: []string{
+			"github.com/nspcc-dev/neo-go/pkg/interop",
+			"github.com/nspcc-dev/neo-go/pkg/interop/native/crypto",
+			"github.com/nspcc-dev/neo-go/pkg/interop/runtime",
+			"github.com/nspcc-dev/neo-go/pkg/interop/storage",
+		},
+		Source: digestContractMethods,
+		Permissions: []circuits.ContractPermission{
+			{Hash: nativehashes.CryptoLib.StringLE(), Methods: []string{"sha256"}},
+		},
+	}
+}
+
+const digestContractMethods = `// commitmentPrefix prefixes the storage keys of the commitments.
+const commitmentPrefix = "c"
+
+// CommitWithProof verifies a proof of knowledge of the preimage and stores the
+// SHA-256 commitment from its public inputs for the committer, who must sign.
+// Commitments are keyed by the digest, so a copied proof only commits to the
+// same digest again. It fails when the digest is already committed.
+func CommitWithProof(committer interop.Hash160, a []byte, b []byte, c []byte, publicInput [][]byte) bool {
+	if !runtime.CheckWitness(committer) {
+		return false
+	}
+	if len(publicInput) != 2 || !VerifyProof(a, b, c, publicInput) {
+		return false
+	}
+	ctx := storage.GetContext()
+	key := commitmentPrefix + string(digestFromInputs(publicInput[0], publicInput[1]))
+	if storage.Get(ctx, key) != nil {
+		return false
+	}
+	storage.Put(ctx, key, committer)
+	return true
+}
+
+// Reveal checks that the digest of the preimage and salt is committed by the
+// committer, who must sign. The commitment is removed once revealed.
+func Reveal(committer interop.Hash160, preimage []byte) bool {
+	if !runtime.CheckWitness(committer) {
+		return false
+	}
+	ctx := storage.GetContext()
+	key := commitmentPrefix + string(crypto.Sha256(preimage))
+	stored := storage.Get(ctx, key)
+	if stored == nil || !committer.Equals(stored.(interop.Hash160)) {
+		return false
+	}
+	storage.Delete(ctx, key)
+	return true
+}
+
+// digestFromInputs joins the two digest halves, public inputs are 32 byte
+// little endian field elements.
+func digestFromInputs(high []byte, low []byte) []byte {
+	digest := make([]byte, 32)
+	for i := 0; i < 16; i++ {
+		digest[i] = high[15-i]
+		digest[16+i] = low[15-i]
+	}
+	return digest
+}
+`
+
+func init() {
+	circuits.Register("hash_commit_cryptolib", func() circuits.Circuit {
+		return &DigestCircuit{}
+	})
+}
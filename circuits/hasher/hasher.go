@@ -0,0 +1,97 @@
+// Package hasher lets circuits choose their hash function. Every hasher hashes
+// field elements to a field element exactly like util.HashInputs does natively.
+package hasher
+
+import (
+	"fmt"
+
+	"neo_zk_starter/internal/util"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/hash/sha2"
+	"github.com/consensys/gnark/std/math/uints"
+)
+
+// New returns an in-circuit hasher of h, the empty hash stands for MiMC. Sum
+// hashes everything written since the last Reset.
+func New(api frontend.API, h util.Hash) (hash.FieldHasher, error) {
+	switch h {
+	case util.MiMC, "":
+		m, err := mimc.NewMiMC(api)
+		if err != nil {
+			return nil, err
+		}
+		return &m, nil
+	case util.SHA256:
+		h, err := sha2.New(api)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := h.(resetHasher)
+		if !ok {
+			return nil, fmt.Errorf("SHA-256 hasher %T cannot be reset", h)
+		}
+		return &sha256{api: api, h: r}, nil
+	default:
+		return nil, fmt.Errorf("unsupported hash %q", h)
+	}
+}
+
+// sha256 hashes the 32 byte big endian encodings of the elements and reduces
+// the digest into the field.
+type sha256 struct {
+	api  frontend.API
+	h    resetHasher
+	data []frontend.Variable
+}
+
+// resetHasher is the SHA-256 hasher of gnark, Reset is missing from its
+// interface.
+type resetHasher interface {
+	hash.BinaryHasher
+	Reset()
+}
+
+func (s *sha256) Write(data ...frontend.Variable) {
+	s.data = append(s.data, data...)
+}
+
+func (s *sha256) Reset() {
+	s.data = nil
+}
+
+func (s *sha256) Sum() frontend.Variable {
+	s.h.Reset()
+	for _, e := range s.data {
+		s.h.Write(ElementBytes(s.api, e))
+	}
+	return BytesToElement(s.api, s.h.Sum())
+}
+
+// ElementBytes returns the 32 byte big endian encoding of the canonical value
+// of the element.
+func ElementBytes(api frontend.API, e frontend.Variable) []uints.U8 {
+	// The full length decomposition is unique, the top bit of 256 is zero
+	bits := api.ToBinary(e, fr.Bits)
+	bits = append(bits, 0)
+
+	out := make([]uints.U8, fr.Bytes)
+	for i := range out {
+		lsb := 8 * (fr.Bytes - 1 - i)
+		out[i] = uints.U8{Val: api.FromBinary(bits[lsb : lsb+8]...)}
+	}
+	return out
+}
+
+// BytesToElement returns the big endian bytes as a field element, reduced
+// modulo the field order.
+func BytesToElement(api frontend.API, b []uints.U8) frontend.Variable {
+	var out frontend.Variable = 0
+	for i := range b {
+		out = api.Add(api.Mul(out, 256), b[i].Val)
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package hasher
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"neo_zk_starter/internal/util"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/test"
+)
+
+type hashCircuit struct {
+	Inputs []frontend.Variable
+	Hash   frontend.Variable `gnark:",public"`
+
+	hash util.Hash
+}
+
+func (c *hashCircuit) Define(api frontend.API) error {
+	h, err := New(api, c.hash)
+	if err != nil {
+		return err
+	}
+	h.Write(c.Inputs...)
+	api.AssertIsEqual(h.Sum(), c.Hash)
+	return nil
+}
+
+// TestNative cross-checks every hasher with util.HashInputs.
+func TestNative(t *testing.T) {
+	largest := new(big.Int).Sub(ecc.BLS12_381.ScalarField(), big.NewInt(1))
+	inputSets := [][]interface{}{
+		{uint64(42)},
+		{uint64(1), uint64(2), uint64(3)},
+		{largest, uint64(0)},
+	}
+	for _, h := range util.Hashes {
+		for _, inputs := range inputSets {
+			circuit := &hashCircuit{Inputs: make([]frontend.Variable, len(inputs)), hash: h}
+			assignment := &hashCircuit{Inputs: make([]frontend.Variable, len(inputs)), Hash: util.HashInputs(h, inputs)}
+			for i, in := range inputs {
+				assignment.Inputs[i] = in
+			}
+			if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err != nil {
+				t.Fatalf("%s %v: %v", h, inputs, err)
+			}
+
+			assignment.Hash = new(big.Int).Add(assignment.Hash.(*big.Int), big.NewInt(1))
+			if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err == nil {
+				t.Fatalf("%s %v: wrong hash accepted", h, inputs)
+			}
+		}
+	}
+}
+
+func TestUnknownHash(t *testing.T) {
+	circuit := &hashCircuit{Inputs: make([]frontend.Variable, 1), hash: "md5"}
+	if _, err := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, circuit); err == nil {
+		t.Fatal("unknown hash must be rejected")
+	}
+}
+
+// BenchmarkConstraints reports the constraints of hashing 1, 2 and 8 field
+// elements with every hash, run with go test -bench Constraints -run ^$.
+func BenchmarkConstraints(b *testing.B) {
+	for _, h := range util.Hashes {
+		for _, n := range []int{1, 2, 8} {
+			b.Run(fmt.Sprintf("%s/%d", h, n), func(b *testing.B) {
+				var constraints int
+				for i := 0; i < b.N; i++ {
+					ccs, err := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder,
+						&hashCircuit{Inputs: make([]frontend.Variable, n), hash: h})
+					if err != nil {
+						b.Fatal(err)
+					}
+					constraints = ccs.GetNbConstraints()
+				}
+				b.ReportMetric(float64(constraints), "constraints")
+			})
+		}
+	}
+}
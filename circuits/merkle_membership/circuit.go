@@ -0,0 +1,204 @@
+package merkle_membership
+
+import (
+	"fmt"
+	"math/big"
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/internal/util"
+	"neo_zk_starter/merkle"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// Supported tree depths, a depth d tree holds 2^d leaves.
+const (
+	MinDepth     = 20
+	MaxDepth     = 32
+	DefaultDepth = MinDepth
+)
+
+// Circuit proves that a leaf is in a MiMC Merkle tree of a fixed depth. The
+// position of the leaf stays private, the path bits tell for every level
+// whether the current node is the left (0) or the right (1) child.
+type Circuit struct {
+	LeafHash    frontend.Variable   `gnark:",public"` // Hash of the leaf data
+	Root        frontend.Variable   `gnark:",public"` // Expected Merkle root
+	Siblings    []frontend.Variable // Sibling hashes from the leaf level up
+	PathIndices []frontend.Variable // Path bits from the leaf level up
+}
+
+// Input is the input of the circuit, the path of the leaf as merkle.Tree.Proof
+// returns it.
+type Input = merkle.Proof
+
+// New returns a circuit for trees of the given depth.
+func New(depth int) (*Circuit, error) {
+	if depth < MinDepth || depth > MaxDepth {
+		return nil, fmt.Errorf("depth must be between %d and %d, got %d", MinDepth, MaxDepth, depth)
+	}
+	return newCircuit(depth), nil
+}
+
+func newCircuit(depth int) *Circuit {
+	return &Circuit{
+		Siblings:    make([]frontend.Variable, depth),
+		PathIndices: make([]frontend.Variable, depth),
+	}
+}
+
+// Depth returns the depth of the tree the circuit is compiled for.
+func (c *Circuit) Depth() int {
+	return len(c.Siblings)
+}
+
+// VerifyMerklePath asserts that the leaf hashes up to the root along the path.
+// Every path bit must be boolean and chooses the hash order of its level, so
+// both left and right children are supported and no level can be skipped.
+func VerifyMerklePath(api frontend.API, leafHash, root frontend.Variable, siblings, pathIndices []frontend.Variable) error {
+	computed, err := ComputeMerkleRoot(api, leafHash, siblings, pathIndices)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(computed, root)
+	return nil
+}
+
+// ComputeMerkleRoot hashes the leaf up along the path and returns the root, it
+// lets circuits update a leaf by hashing the new leaf along the same path.
+func ComputeMerkleRoot(api frontend.API, leafHash frontend.Variable, siblings, pathIndices []frontend.Variable) (frontend.Variable, error) {
+	if len(siblings) != len(pathIndices) {
+		return nil, fmt.Errorf("got %d siblings and %d path bits", len(siblings), len(pathIndices))
+	}
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return nil, err
+	}
+
+	currentHash := leafHash
+	for i := range siblings {
+		api.AssertIsBoolean(pathIndices[i])
+
+		// The current node is the right child when the bit is set
+		left := api.Select(pathIndices[i], siblings[i], currentHash)
+		right := api.Select(pathIndices[i], currentHash, siblings[i])
+
+		h.Reset()
+		h.Write(left, right)
+		currentHash = h.Sum()
+	}
+	return currentHash, nil
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	return VerifyMerklePath(api, c.LeafHash, c.Root, c.Siblings, c.PathIndices)
+}
+
+func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
+	var inputData Input
+	switch in := input.(type) {
+	case Input:
+		inputData = in
+	case *Input:
+		if in == nil {
+			return nil, nil, fmt.Errorf("input is nil")
+		}
+		inputData = *in
+	default:
+		return nil, nil, fmt.Errorf("input must be merkle_membership.Input for MerkleMembershipCircuit, got %T", input)
+	}
+
+	depth := c.Depth()
+	if inputData.LeafHash == nil || inputData.Root == nil {
+		return nil, nil, fmt.Errorf("leaf hash and root are required")
+	}
+	if len(inputData.Siblings) != depth {
+		return nil, nil, fmt.Errorf("expected %d siblings for a depth %d tree, got %d", depth, depth, len(inputData.Siblings))
+	}
+	if depth < 64 && inputData.Index >= 1<<depth {
+		return nil, nil, fmt.Errorf("index %d is out of range for a depth %d tree", inputData.Index, depth)
+	}
+
+	assignment := newCircuit(depth)
+	assignment.LeafHash = inputData.LeafHash
+	assignment.Root = inputData.Root
+	for i, sibling := range inputData.Siblings {
+		if sibling == nil {
+			return nil, nil, fmt.Errorf("sibling %d is nil", i)
+		}
+		assignment.Siblings[i] = sibling
+		assignment.PathIndices[i] = inputData.Index >> i & 1
+	}
+
+	return assignment, []string{inputData.LeafHash.String(), inputData.Root.String()}, nil
+}
+
+// ParseInput implements circuits.JSONInput, the input is
+// {"leaf": "<hash>", "index": 5, "siblings": ["<hash>", ...], "root": "<hash>"}.
+func (c *Circuit) ParseInput(data []byte) (interface{}, error) {
+	var in struct {
+		Leaf     util.Number   `json:"leaf"`
+		Index    util.Number   `json:"index"`
+		Siblings []util.Number `json:"siblings"`
+		Root     util.Number   `json:"root"`
+	}
+	if err := util.DecodeInput(data, &in); err != nil {
+		return nil, err
+	}
+
+	leaf, err := util.ParseFieldElement("leaf", in.Leaf)
+	if err != nil {
+		return nil, err
+	}
+	root, err := util.ParseFieldElement("root", in.Root)
+	if err != nil {
+		return nil, err
+	}
+	index, err := util.ParseFieldElement("index", in.Index)
+	if err != nil {
+		return nil, err
+	}
+	if depth := c.Depth(); index.BitLen() > depth {
+		return nil, fmt.Errorf("index: out of range for a depth %d tree", depth)
+	}
+	if len(in.Siblings) != c.Depth() {
+		return nil, fmt.Errorf("siblings: expected %d, got %d", c.Depth(), len(in.Siblings))
+	}
+	siblings := make([]*big.Int, len(in.Siblings))
+	for i, s := range in.Siblings {
+		if siblings[i], err = util.ParseFieldElement(fmt.Sprintf("siblings[%d]", i), s); err != nil {
+			return nil, err
+		}
+	}
+
+	return Input{
+		LeafHash: leaf,
+		Index:    index.Uint64(),
+		Siblings: siblings,
+		Root:     root,
+	}, nil
+}
+
+func (c *Circuit) ValidInput() circuits.Circuit {
+	// A right hand leaf with arbitrary sibling hashes
+	leafHash := util.StringToBigInt(util.HashInputsToString([]interface{}{big.NewInt(1337), big.NewInt(9001), big.NewInt(5)}), 10)
+	index := uint64(0b1011)
+
+	siblings := make([]*big.Int, c.Depth())
+	for i := range siblings {
+		siblings[i] = util.StringToBigInt(util.HashInputsToString([]interface{}{uint64(i)}), 10)
+	}
+
+	return circuits.MustPrepareInput(c, Input{
+		LeafHash: leafHash,
+		Index:    index,
+		Siblings: siblings,
+		Root:     merkle.ComputeRoot(leafHash, index, siblings),
+	})
+}
+
+func init() {
+	circuits.Register("merkle_membership", func() circuits.Circuit { return newCircuit(DefaultDepth) })
+	circuits.Register("merkle_membership_32", func() circuits.Circuit { return newCircuit(MaxDepth) })
+	circuits.Register("merkle_membership_tree", func() circuits.Circuit { return &TreeCircuit{Circuit: *newCircuit(DefaultDepth)} })
+}
@@ -0,0 +1,138 @@
+package merkle_membership
+
+import (
+	"math/big"
+	"testing"
+
+	"neo_zk_starter/merkle"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+func TestCircuit(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := newCircuit(DefaultDepth)
+	validAssignment := circuit.ValidInput().(*Circuit)
+
+	// Test with valid inputs
+	assert.ProverSucceeded(circuit, validAssignment,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+
+	// Test with a flipped path bit, the sibling is hashed on the wrong side
+	flipped := *validAssignment
+	flipped.PathIndices = append([]frontend.Variable{}, validAssignment.PathIndices...)
+	flipped.PathIndices[0] = 0
+	assert.ProverFailed(circuit, &flipped,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+
+	// Test with a path bit that is not boolean
+	nonBoolean := *validAssignment
+	nonBoolean.PathIndices = append([]frontend.Variable{}, validAssignment.PathIndices...)
+	nonBoolean.PathIndices[0] = 2
+	assert.ProverFailed(circuit, &nonBoolean,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+}
+
+func TestPaths(t *testing.T) {
+	circuit := newCircuit(MinDepth)
+	leaf := big.NewInt(42)
+	siblings := make([]*big.Int, MinDepth)
+	for i := range siblings {
+		siblings[i] = big.NewInt(int64(i + 1))
+	}
+
+	// Left and right hand leaves both prove, at any position.
+	for _, index := range []uint64{0, 1, 2, 1<<MinDepth - 1} {
+		assignment, _, err := circuit.PrepareInput(Input{LeafHash: leaf, Index: index, Siblings: siblings, Root: merkle.ComputeRoot(leaf, index, siblings)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err != nil {
+			t.Fatalf("index %d: %v", index, err)
+		}
+	}
+
+	// A zero sibling is hashed like any other, it does not skip the level.
+	zeros := make([]*big.Int, MinDepth)
+	for i := range zeros {
+		zeros[i] = new(big.Int)
+	}
+	assignment, _, err := circuit.PrepareInput(Input{LeafHash: leaf, Index: 0, Siblings: zeros, Root: leaf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err == nil {
+		t.Fatal("zero siblings must not skip levels")
+	}
+
+	// Invalid input
+	if _, _, err := circuit.PrepareInput(Input{LeafHash: leaf, Siblings: siblings[:4], Root: leaf}); err == nil {
+		t.Fatal("too few siblings must be rejected")
+	}
+	if _, _, err := circuit.PrepareInput(Input{LeafHash: leaf, Index: 1 << MinDepth, Siblings: siblings, Root: leaf}); err == nil {
+		t.Fatal("out of range index must be rejected")
+	}
+}
+
+func TestTreeCircuit(t *testing.T) {
+	circuit := &TreeCircuit{Circuit: *newCircuit(MinDepth)}
+	if err := test.IsSolved(circuit, circuit.ValidInput(), ecc.BLS12_381.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := circuit.ValidInput().(*TreeCircuit); !ok {
+		t.Fatal("assignments must be tree circuits")
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, depth := range []int{MinDepth, 24, MaxDepth} {
+		c, err := New(depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Depth() != depth {
+			t.Fatalf("expected depth %d, got %d", depth, c.Depth())
+		}
+	}
+	for _, depth := range []int{4, MinDepth - 1, MaxDepth + 1} {
+		if _, err := New(depth); err == nil {
+			t.Fatalf("depth %d must be rejected", depth)
+		}
+	}
+}
+
+// TestTreeRoots cross-checks the hashing in the circuit with merkle.Tree.
+func TestTreeRoots(t *testing.T) {
+	leaves := make([]*big.Int, 6)
+	for i := range leaves {
+		leaves[i] = big.NewInt(int64(i + 1))
+	}
+	for _, depth := range []int{MinDepth, MaxDepth} {
+		tree, err := merkle.FromLeaves(depth, leaves)
+		if err != nil {
+			t.Fatal(err)
+		}
+		circuit := newCircuit(depth)
+
+		for _, index := range []uint64{0, 3, 5} {
+			input, err := tree.Proof(index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assignment, _, err := circuit.PrepareInput(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err != nil {
+				t.Fatalf("depth %d leaf %d: %v", depth, index, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package merkle_membership
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/contracts"
+	"neo_zk_starter/internal/util"
+)
+
+// RootHistory is the number of recent roots the tree contract accepts proofs
+// against, deposits made while a proof is in flight do not invalidate it.
+const RootHistory = 30
+
+// TreeCircuit is the merkle_membership circuit with an incremental Merkle
+// tree as verifier contract, registered as merkle_membership_tree. The plain
+// merkle_membership verifier only verifies proofs.
+type TreeCircuit struct {
+	Circuit
+}
+
+func (c *TreeCircuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
+	assignment, publicInputs, err := c.Circuit.PrepareInput(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &TreeCircuit{Circuit: *assignment.(*Circuit)}, publicInputs, nil
+}
+
+func (c *TreeCircuit) ValidInput() circuits.Circuit {
+	return &TreeCircuit{Circuit: *c.Circuit.ValidInput().(*Circuit)}
+}
+
+// ContractMethods implements circuits.ContractExtension. The verifier becomes
+// an incremental Merkle tree: deposit appends a leaf hash, checkMembership
+// checks a proof against one of the last RootHistory roots.
+func (c *TreeCircuit) ContractMethods() circuits.ContractMethods {
+	membership := circuits.ContractMethods{
+		Imports:     []string{"github.com/nspcc-dev/neo-go/pkg/interop/convert"},
+		Source:      membershipContractMethods,
+		SafeMethods: []string{"checkMembership"},
+	}
+	return TreeContractMethods(c.Depth()).Merge(membership)
+}
+
+// TreeContractMethods returns the incremental Merkle tree of the given depth
+// with the MiMC and items libraries it calls, for verifiers that check the
+// root of their proofs with isKnownRoot. The deploy data is the admin, the
+// only account that deposits.
+func TreeContractMethods(depth int) circuits.ContractMethods {
+	// zeros[i] is the root of an empty subtree of height i
+	var zeros strings.Builder
+	zero := big.NewInt(0)
+	for i := 0; i <= depth; i++ {
+		fmt.Fprintf(&zeros, "\t%s\n", byteLiteral(zero))
+		zero = util.StringToBigInt(util.HashInputsToString([]interface{}{zero, zero}), 10)
+	}
+
+	tree := circuits.ContractMethods{
+		Imports: []string{
+			"github.com/nspcc-dev/neo-go/pkg/interop",
+			"github.com/nspcc-dev/neo-go/pkg/interop/convert",
+			"github.com/nspcc-dev/neo-go/pkg/interop/runtime",
+			"github.com/nspcc-dev/neo-go/pkg/interop/storage",
+		},
+		Source:      fmt.Sprintf(treeContractMethods, depth, RootHistory, zeros.String()),
+		SafeMethods: []string{"getAdmin", "getLastRoot", "getNextIndex", "isKnownRoot"},
+		Events: []circuits.ContractEvent{{
+			Name: "Deposit",
+			Parameters: []circuits.ContractEventParameter{
+				{Name: "leaf", Type: "Integer"},
+				{Name: "index", Type: "Integer"},
+				{Name: "root", Type: "Integer"},
+			},
+		}},
+	}
+	return tree.Merge(contracts.MiMC()).Merge(contracts.Items())
+}
+
+// byteLiteral returns x as the Go literal of its 32 byte little endian
+// encoding, contracts convert it back with convert.ToInteger.
+func byteLiteral(x *big.Int) string {
+	b := x.FillBytes(make([]byte, 32))
+	parts := make([]string, len(b))
+	for i := range b {
+		parts[i] = fmt.Sprintf("0x%02x", b[len(b)-1-i])
+	}
+	return strings.Join(parts, ", ") + ","
+}
+
+const treeContractMethods = `// Incremental Merkle tree of MiMC hashes, zero leaves are empty slots.
+const (
+	treeDepth   = %d
+	rootHistory = %d
+)
+
+// Storage keys of the tree.
+const (
+	adminKey      = "a"
+	nextIndexKey  = "n"
+	rootIndexKey  = "i"
+	rootPrefix    = "r"
+	subtreePrefix = "f"
+)
+
+// zeros holds the roots of empty subtrees by height, 32 bytes little endian
+// each.
+var zeros = []byte{
+%s}
+
+// _deploy stores the admin, the deploy data, and the root of the empty tree.
+func _deploy(data any, isUpdate bool) {
+	if isUpdate {
+		return
+	}
+	admin := data.(interop.Hash160)
+	if len(admin) != interop.Hash160Len {
+		panic("invalid admin")
+	}
+	ctx := storage.GetContext()
+	storage.Put(ctx, adminKey, admin)
+	storage.Put(ctx, itemKey(rootPrefix, 0), zero(treeDepth))
+	storage.Put(ctx, rootIndexKey, 0)
+	storage.Put(ctx, nextIndexKey, 0)
+}
+
+// Deposit appends the leaf hash to the tree and returns its index, only the
+// admin deposits. Only the last filled subtree of every level is kept, the new
+// root is added to the ring of recent roots. Zero is the hash of the empty
+// slots, so it is refused.
+func Deposit(leaf int) int {
+	ctx := storage.GetContext()
+	if !runtime.CheckWitness(storage.Get(ctx, adminKey).(interop.Hash160)) {
+		panic("only the admin can deposit")
+	}
+	if leaf <= 0 || leaf >= Modulus() {
+		panic("leaf is not a non-zero field element")
+	}
+	index := getInt(ctx, nextIndexKey)
+	if index >= 1<<treeDepth {
+		panic("tree is full")
+	}
+
+	current := leaf
+	position := index
+	for level := 0; level < treeDepth; level++ {
+		if position%%2 == 0 {
+			storage.Put(ctx, itemKey(subtreePrefix, level), current)
+			current = HashPair(current, zero(level))
+		} else {
+			current = HashPair(getInt(ctx, itemKey(subtreePrefix, level)), current)
+		}
+		position /= 2
+	}
+
+	rootIndex := (getInt(ctx, rootIndexKey) + 1) %% rootHistory
+	storage.Put(ctx, itemKey(rootPrefix, rootIndex), current)
+	storage.Put(ctx, rootIndexKey, rootIndex)
+	storage.Put(ctx, nextIndexKey, index+1)
+	runtime.Notify("Deposit", leaf, index, current)
+	return index
+}
+
+// GetAdmin returns the account that deposits.
+func GetAdmin() interop.Hash160 {
+	return storage.Get(storage.GetReadOnlyContext(), adminKey).(interop.Hash160)
+}
+
+// GetLastRoot returns the root of the tree.
+func GetLastRoot() int {
+	ctx := storage.GetReadOnlyContext()
+	return getInt(ctx, itemKey(rootPrefix, getInt(ctx, rootIndexKey)))
+}
+
+// GetNextIndex returns the number of leaves in the tree.
+func GetNextIndex() int {
+	return getInt(storage.GetReadOnlyContext(), nextIndexKey)
+}
+
+// IsKnownRoot reports whether the root is one of the recent roots of the tree.
+func IsKnownRoot(root int) bool {
+	ctx := storage.GetReadOnlyContext()
+	rootIndex := getInt(ctx, rootIndexKey)
+	for i := 0; i < rootHistory; i++ {
+		stored := storage.Get(ctx, itemKey(rootPrefix, (rootIndex-i+rootHistory)%%rootHistory))
+		if stored != nil && convert.ToInteger(stored) == root {
+			return true
+		}
+	}
+	return false
+}
+
+// zero returns the root of an empty subtree of the height.
+func zero(height int) int {
+	return convert.ToInteger(zeros[32*height : 32*height+32])
+}
+`
+
+const membershipContractMethods = `// CheckMembership verifies a merkle_membership proof whose root, the second
+// public input, is a recent root of the tree. Proofs for a zero leaf, an empty
+// slot of the tree, are refused. It is a read-only membership check of a public
+// leaf and records nothing, so it is no withdrawal: the same proof passes any
+// number of times.
+func CheckMembership(a []byte, b []byte, c []byte, publicInput [][]byte) bool {
+	if len(publicInput) != 2 || convert.ToInteger(publicInput[0]) == 0 ||
+		!IsKnownRoot(convert.ToInteger(publicInput[1])) {
+		return false
+	}
+	return VerifyProof(a, b, c, publicInput)
+}
+`
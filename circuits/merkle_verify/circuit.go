@@ -4,17 +4,17 @@ import (
 	"fmt"
 	"math/big"
 	"neo_zk_starter/circuits"
+	"neo_zk_starter/circuits/hasher"
 	"neo_zk_starter/internal/util"
+	"neo_zk_starter/merkle"
 
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
 	"github.com/consensys/gnark/std/hash/mimc"
 )
 
-type Leaf struct {
-	SenderKey *big.Int
-	Balance   *big.Int
-	Nonce     *big.Int
-}
+// Leaf is an account leaf of the tree, see merkle.Leaf.
+type Leaf = merkle.Leaf
 
 const MaxProofElements = 4
 
@@ -22,14 +22,24 @@ type Circuit struct {
 	LeafHash      frontend.Variable                   `gnark:",public"` // Hash of the leaf data
 	ProofElements [MaxProofElements]frontend.Variable `gnark:",public"` // Array of sibling hashes along the Merkle proof path
 	Root          frontend.Variable                   `gnark:",public"` // Expected Merkle root
+
+	hash util.Hash // hash of the tree, MiMC when empty
 }
 
+// VerifyMerkleProof hashes the leaf with every non-zero proof element, always
+// with the current hash on the left.
+//
+// Deprecated: it cannot prove right hand leaves and zero elements skip levels,
+// use merkle_membership.VerifyMerklePath.
 func VerifyMerkleProof(api frontend.API, leafHash, root frontend.Variable, proofElements []frontend.Variable) error {
 	h, err := mimc.NewMiMC(api)
 	if err != nil {
 		return err
 	}
+	return verifyMerkleProof(api, &h, leafHash, root, proofElements)
+}
 
+func verifyMerkleProof(api frontend.API, h hash.FieldHasher, leafHash, root frontend.Variable, proofElements []frontend.Variable) error {
 	currentHash := leafHash
 
 	for i, proofElement := range proofElements {
@@ -61,8 +71,12 @@ func (c *Circuit) Define(api frontend.API) error {
 		api.Println(fmt.Sprintf("ProofElement[%d]:", i), proofElementsSlice[i])
 	}
 
-	// Use the VerifyMerkleProof function
-	err := VerifyMerkleProof(api, c.LeafHash, c.Root, proofElementsSlice)
+	// Verify the proof with the hash of the registered variant
+	h, err := hasher.New(api, c.hash)
+	if err != nil {
+		return err
+	}
+	err = verifyMerkleProof(api, h, c.LeafHash, c.Root, proofElementsSlice)
 	if err != nil {
 		return err
 	}
@@ -70,7 +84,7 @@ func (c *Circuit) Define(api frontend.API) error {
 	return nil
 }
 
-func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string) {
+func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
 	// Expecting the input to be a struct with the necessary fields
 	inputData, ok := input.(struct {
 		LeafHash      *big.Int
@@ -78,7 +92,18 @@ func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string) {
 		Root          *big.Int
 	})
 	if !ok {
-		panic("Input must be of the correct type for MerkleVerifyCircuit")
+		return nil, nil, fmt.Errorf("input must be struct{LeafHash, ProofElements, Root} for MerkleVerifyCircuit, got %T", input)
+	}
+	if inputData.LeafHash == nil || inputData.Root == nil {
+		return nil, nil, fmt.Errorf("leaf hash and root are required")
+	}
+	if len(inputData.ProofElements) > MaxProofElements {
+		return nil, nil, fmt.Errorf("too many proof elements: %d, max %d", len(inputData.ProofElements), MaxProofElements)
+	}
+	for i, pe := range inputData.ProofElements {
+		if pe == nil {
+			return nil, nil, fmt.Errorf("proof element %d is nil", i)
+		}
 	}
 
 	var proofElements [MaxProofElements]frontend.Variable
@@ -94,33 +119,66 @@ func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string) {
 		LeafHash:      util.StringToBigInt(inputData.LeafHash.String(), 10),
 		ProofElements: proofElements,
 		Root:          util.StringToBigInt(inputData.Root.String(), 10),
-	}, []string{inputData.LeafHash.String(), inputData.Root.String()}
+	}, []string{inputData.LeafHash.String(), inputData.Root.String()}, nil
+}
+
+// ParseInput implements circuits.JSONInput, the input is
+// {"leaf": "<hash>", "siblings": ["<hash>", ...], "root": "<hash>"}.
+func (c *Circuit) ParseInput(data []byte) (interface{}, error) {
+	var in struct {
+		Leaf     util.Number   `json:"leaf"`
+		Siblings []util.Number `json:"siblings"`
+		Root     util.Number   `json:"root"`
+	}
+	if err := util.DecodeInput(data, &in); err != nil {
+		return nil, err
+	}
+
+	leaf, err := util.ParseFieldElement("leaf", in.Leaf)
+	if err != nil {
+		return nil, err
+	}
+	root, err := util.ParseFieldElement("root", in.Root)
+	if err != nil {
+		return nil, err
+	}
+	if len(in.Siblings) > MaxProofElements {
+		return nil, fmt.Errorf("siblings: too many proof elements: %d, max %d", len(in.Siblings), MaxProofElements)
+	}
+	siblings := make([]*big.Int, len(in.Siblings))
+	for i, s := range in.Siblings {
+		if siblings[i], err = util.ParseFieldElement(fmt.Sprintf("siblings[%d]", i), s); err != nil {
+			return nil, err
+		}
+	}
+
+	return struct {
+		LeafHash      *big.Int
+		ProofElements []*big.Int
+		Root          *big.Int
+	}{
+		LeafHash:      leaf,
+		ProofElements: siblings,
+		Root:          root,
+	}, nil
 }
 
 func (c *Circuit) ValidInput() circuits.Circuit {
 	// Example Merkle tree stores account information for a ZK-Rollup
-	// Create two account leaves
+	// Create a tree with two account leaves
 	leaves := []Leaf{
 		{SenderKey: big.NewInt(1337), Balance: big.NewInt(9001), Nonce: big.NewInt(5)},
 		{SenderKey: big.NewInt(420), Balance: big.NewInt(20), Nonce: big.NewInt(13)},
 	}
-
-	// Hash leaves
 	leafHashes := make([]*big.Int, len(leaves))
 	for i, leaf := range leaves {
-		leafInputs := []interface{}{leaf.SenderKey, leaf.Balance, leaf.Nonce}
-		leafHashed := util.HashInputsToString(leafInputs)
-		leafHashes[i] = util.StringToBigInt(leafHashed, 10)
+		leafHashes[i] = util.HashInputs(c.hash, []interface{}{leaf.SenderKey, leaf.Balance, leaf.Nonce})
 	}
 
-	// Calculate root hash
-	rootHashed := util.HashInputsToString([]interface{}{leafHashes[0], leafHashes[1]})
-	rootHash := util.StringToBigInt(rootHashed, 10)
-
-	// Create proof for the first leaf
-	proofElements := []*big.Int{
-		leafHashes[1], // Sibling of the first leaf
-	}
+	// Create proof for the first leaf, its sibling is the second leaf
+	leafHash := leafHashes[0]
+	proofElements := []*big.Int{leafHashes[1]}
+	rootHash := util.HashInputs(c.hash, []interface{}{leafHashes[0], leafHashes[1]})
 
 	// Prepare the input
 	input := struct {
@@ -128,15 +186,15 @@ func (c *Circuit) ValidInput() circuits.Circuit {
 		ProofElements []*big.Int
 		Root          *big.Int
 	}{
-		LeafHash:      leafHashes[0],
+		LeafHash:      leafHash,
 		ProofElements: proofElements,
 		Root:          rootHash,
 	}
 
-	preparedInput, _ := c.PrepareInput(input)
-	return preparedInput
+	return circuits.MustPrepareInput(c, input)
 }
 
 func init() {
 	circuits.Register("merkle_verify", func() circuits.Circuit { return &Circuit{} })
+	circuits.Register("merkle_verify_sha256", func() circuits.Circuit { return &Circuit{hash: util.SHA256} })
 }
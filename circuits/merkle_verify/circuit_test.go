@@ -1,8 +1,12 @@
 package merkle_verify
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
+	"neo_zk_starter/internal/util"
+
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
@@ -68,3 +72,48 @@ func TestCircuit(t *testing.T) {
 		test.WithCurves(ecc.BLS12_381),
 		test.WithBackends(backend.GROTH16))
 }
+
+func TestHashVariants(t *testing.T) {
+	mimc := (&Circuit{}).ValidInput()
+	for _, h := range util.Hashes {
+		circuit := &Circuit{hash: h}
+		if err := test.IsSolved(circuit, circuit.ValidInput(), ecc.BLS12_381.ScalarField()); err != nil {
+			t.Fatalf("%s: %v", h, err)
+		}
+		if h != util.MiMC {
+			if err := test.IsSolved(circuit, mimc, ecc.BLS12_381.ScalarField()); err == nil {
+				t.Fatalf("%s: MiMC tree accepted", h)
+			}
+		}
+	}
+}
+
+func TestParseInput(t *testing.T) {
+	circuit := &Circuit{}
+	valid := circuit.ValidInput().(*Circuit)
+
+	data := fmt.Sprintf(`{"leaf": "%s", "siblings": ["%s"], "root": "%s"}`,
+		valid.LeafHash, valid.ProofElements[0], valid.Root)
+	input, err := circuit.ParseInput([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment, _, err := circuit.PrepareInput(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	for data, field := range map[string]string{
+		`{"leaf": "1", "siblings": ["2", "z"], "root": "3"}`:                "siblings[1]",
+		`{"leaf": "1", "siblings": ["1", "2", "3", "4", "5"], "root": "3"}`: "siblings",
+		`{"siblings": [], "root": "3"}`:                                     "leaf",
+		`{"leaf": "1", "siblings": "2", "root": "3"}`:                       "siblings",
+	} {
+		if _, err := circuit.ParseInput([]byte(data)); err == nil || !strings.Contains(err.Error(), field) {
+			t.Fatalf("input %s: expected an error naming %s, got %v", data, field, err)
+		}
+	}
+}
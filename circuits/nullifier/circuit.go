@@ -0,0 +1,136 @@
+// Package nullifier provides the nullifier gadget, the MiMC hash of a secret
+// and an external nullifier. Proofs reveal the nullifier as a public input, a
+// contract that records it accepts one proof per secret and external
+// nullifier, e.g. one vote per voter and election.
+package nullifier
+
+import (
+	"fmt"
+	"math/big"
+
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/internal/util"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// Hash returns the nullifier of the secret for the external nullifier, the
+// MiMC hash of both.
+func Hash(api frontend.API, secret, externalNullifier frontend.Variable) (frontend.Variable, error) {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(secret, externalNullifier)
+	return h.Sum(), nil
+}
+
+// Compute returns the nullifier of the secret for the external nullifier, the
+// way Hash does in a circuit.
+func Compute(secret, externalNullifier *big.Int) *big.Int {
+	return util.HashInputs(util.MiMC, []interface{}{secret, externalNullifier})
+}
+
+// Circuit proves knowledge of the secret of a commitment, the MiMC hash of the
+// secret, and reveals its nullifier for the external nullifier. The verifier
+// contract records the nullifiers, see circuits.NullifierCircuit.
+type Circuit struct {
+	Secret            frontend.Variable
+	Commitment        frontend.Variable `gnark:",public"`
+	ExternalNullifier frontend.Variable `gnark:",public"`
+	Nullifier         frontend.Variable `gnark:",public"`
+}
+
+// Input is the input of the circuit.
+type Input struct {
+	Secret            *big.Int
+	ExternalNullifier *big.Int
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	h.Write(c.Secret)
+	api.AssertIsEqual(c.Commitment, h.Sum())
+
+	nullifier, err := Hash(api, c.Secret, c.ExternalNullifier)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(c.Nullifier, nullifier)
+	return nil
+}
+
+// NullifierInput implements circuits.NullifierCircuit.
+func (c *Circuit) NullifierInput() int {
+	return 2
+}
+
+// ExternalNullifierInput implements circuits.NullifierCircuit.
+func (c *Circuit) ExternalNullifierInput() int {
+	return 1
+}
+
+func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
+	var in Input
+	switch v := input.(type) {
+	case Input:
+		in = v
+	case *Input:
+		if v == nil {
+			return nil, nil, fmt.Errorf("input is nil")
+		}
+		in = *v
+	default:
+		return nil, nil, fmt.Errorf("input must be nullifier.Input for NullifierCircuit, got %T", input)
+	}
+	if in.Secret == nil || in.ExternalNullifier == nil {
+		return nil, nil, fmt.Errorf("secret and external nullifier are required")
+	}
+
+	commitment := util.HashInputs(util.MiMC, []interface{}{in.Secret})
+	nullifier := Compute(in.Secret, in.ExternalNullifier)
+	return &Circuit{
+		Secret:            in.Secret,
+		Commitment:        commitment,
+		ExternalNullifier: in.ExternalNullifier,
+		Nullifier:         nullifier,
+	}, []string{commitment.String(), nullifier.String()}, nil
+}
+
+// ParseInput implements circuits.JSONInput, the input is
+// {"secret": "0x..", "externalNullifier": 1}.
+func (c *Circuit) ParseInput(data []byte) (interface{}, error) {
+	var in struct {
+		Secret            util.Number `json:"secret"`
+		ExternalNullifier util.Number `json:"externalNullifier"`
+	}
+	if err := util.DecodeInput(data, &in); err != nil {
+		return nil, err
+	}
+	secret, err := util.ParseFieldElement("secret", in.Secret)
+	if err != nil {
+		return nil, err
+	}
+	external, err := util.ParseFieldElement("externalNullifier", in.ExternalNullifier)
+	if err != nil {
+		return nil, err
+	}
+	return Input{Secret: secret, ExternalNullifier: external}, nil
+}
+
+func (c *Circuit) ValidInput() circuits.Circuit {
+	return circuits.MustPrepareInput(c, Input{
+		Secret:            big.NewInt(1337),
+		ExternalNullifier: big.NewInt(1),
+	})
+}
+
+func init() {
+	circuits.Register("nullifier", func() circuits.Circuit {
+		return &Circuit{}
+	})
+}
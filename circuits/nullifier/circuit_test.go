@@ -0,0 +1,76 @@
+package nullifier
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/test"
+)
+
+func TestCircuit(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := &Circuit{}
+	assignment := circuit.ValidInput().(*Circuit)
+	assert.ProverSucceeded(circuit, assignment,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+
+	// The nullifier is bound to the external nullifier
+	assert.ProverFailed(circuit, &Circuit{
+		Secret:            assignment.Secret,
+		Commitment:        assignment.Commitment,
+		ExternalNullifier: 2,
+		Nullifier:         assignment.Nullifier,
+	}, test.WithCurves(ecc.BLS12_381), test.WithBackends(backend.GROTH16))
+
+	// and to the secret of the commitment
+	other, _, err := circuit.PrepareInput(Input{Secret: big.NewInt(1338), ExternalNullifier: big.NewInt(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.ProverFailed(circuit, &Circuit{
+		Secret:            assignment.Secret,
+		Commitment:        assignment.Commitment,
+		ExternalNullifier: assignment.ExternalNullifier,
+		Nullifier:         other.(*Circuit).Nullifier,
+	}, test.WithCurves(ecc.BLS12_381), test.WithBackends(backend.GROTH16))
+}
+
+func TestCompute(t *testing.T) {
+	secret := big.NewInt(1337)
+	a := Compute(secret, big.NewInt(1))
+	if a.Cmp(Compute(secret, big.NewInt(1))) != 0 {
+		t.Fatal("nullifier is not deterministic")
+	}
+	if a.Cmp(Compute(secret, big.NewInt(2))) == 0 {
+		t.Fatal("external nullifiers share the nullifier")
+	}
+	if a.Cmp(Compute(big.NewInt(1338), big.NewInt(1))) == 0 {
+		t.Fatal("secrets share the nullifier")
+	}
+}
+
+func TestParseInput(t *testing.T) {
+	circuit := &Circuit{}
+	input, err := circuit.ParseInput([]byte(`{"secret": "0x539", "externalNullifier": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in := input.(Input); in.Secret.Int64() != 1337 || in.ExternalNullifier.Int64() != 1 {
+		t.Fatalf("unexpected input %+v", in)
+	}
+
+	for data, field := range map[string]string{
+		`{"externalNullifier": 1}`:                "secret",
+		`{"secret": 1}`:                           "externalNullifier",
+		`{"secret": 1, "externalNullifier": "x"}`: "externalNullifier",
+	} {
+		if _, err := circuit.ParseInput([]byte(data)); err == nil || !strings.Contains(err.Error(), field) {
+			t.Fatalf("input %s: expected an error naming %s, got %v", data, field, err)
+		}
+	}
+}
@@ -1,17 +1,27 @@
 package p256_verify
 
 import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
 	"neo_zk_starter/circuits"
+	"neo_zk_starter/internal/util"
 
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/algebra/emulated/sw_emulated"
 	"github.com/consensys/gnark/std/math/emulated"
 	"github.com/consensys/gnark/std/signature/ecdsa"
-	"github.com/nspcc-dev/neo-go/pkg/crypto/hash"
 	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
-	"github.com/nspcc-dev/neo-go/pkg/wallet"
 )
 
+// Input is the input of the circuit, it is shared with api.P256Proof.
+type Input struct {
+	PublicKey   *keys.PublicKey
+	MessageHash []byte // 32 bytes, big endian
+	Signature   []byte // 64 bytes, r || s
+}
+
 type Circuit struct {
 	PublicKey   ecdsa.PublicKey[emulated.P256Fp, emulated.P256Fr] `gnark:",public"`
 	Signature   ecdsa.Signature[emulated.P256Fr]                  `gnark:",public"`
@@ -27,14 +37,27 @@ func (c *Circuit) Define(api frontend.API) error {
 	return nil
 }
 
-func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string) {
-	inputData, ok := input.(struct {
-		PublicKey   *keys.PublicKey
-		MessageHash []byte
-		Signature   []byte
-	})
-	if !ok {
-		panic("Input must be of the correct type for P256SigVerifyCircuit")
+func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
+	var inputData Input
+	switch in := input.(type) {
+	case Input:
+		inputData = in
+	case *Input:
+		if in == nil {
+			return nil, nil, fmt.Errorf("input is nil")
+		}
+		inputData = *in
+	default:
+		return nil, nil, fmt.Errorf("input must be p256_verify.Input for P256SigVerifyCircuit, got %T", input)
+	}
+	if inputData.PublicKey == nil {
+		return nil, nil, fmt.Errorf("public key is required")
+	}
+	if len(inputData.Signature) != 64 {
+		return nil, nil, fmt.Errorf("signature must be 64 bytes, got %d", len(inputData.Signature))
+	}
+	if len(inputData.MessageHash) != 32 {
+		return nil, nil, fmt.Errorf("message hash must be 32 bytes, got %d", len(inputData.MessageHash))
 	}
 
 	keyX := emulated.ValueOf[emulated.P256Fp](inputData.PublicKey.X.Bytes())
@@ -53,26 +76,81 @@ func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string) {
 			S: sigS,
 		},
 		MessageHash: msg,
-	}, []string{}
+	}, []string{}, nil
+}
+
+// ParseInput implements circuits.JSONInput, the input holds hex strings:
+// {"publicKey": "<compressed or uncompressed key>", "messageHash": "<hash>",
+// "signature": "<r || s>"}.
+func (c *Circuit) ParseInput(data []byte) (interface{}, error) {
+	var in struct {
+		PublicKey   string `json:"publicKey"`
+		MessageHash string `json:"messageHash"`
+		Signature   string `json:"signature"`
+	}
+	if err := util.DecodeInput(data, &in); err != nil {
+		return nil, err
+	}
+
+	keyBytes, err := util.ParseHex("publicKey", in.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := keys.NewPublicKeyFromBytes(keyBytes, elliptic.P256())
+	if err != nil {
+		return nil, fmt.Errorf("publicKey: %w", err)
+	}
+	messageHash, err := util.ParseHex("messageHash", in.MessageHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(messageHash) != 32 {
+		return nil, fmt.Errorf("messageHash: must be 32 bytes, got %d", len(messageHash))
+	}
+	signature, err := util.ParseHex("signature", in.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if len(signature) != 64 {
+		return nil, fmt.Errorf("signature: must be 64 bytes, got %d", len(signature))
+	}
+
+	return Input{
+		PublicKey:   publicKey,
+		MessageHash: messageHash,
+		Signature:   signature,
+	}, nil
 }
 
+// validKey and validNonce are the private key and the nonce of the ValidInput
+// signature, fixed so that every call returns the same input.
+var (
+	validKey   = big.NewInt(0x5eed)
+	validNonce = big.NewInt(0x1337)
+)
+
 func (c *Circuit) ValidInput() circuits.Circuit {
-	w, _ := wallet.NewAccount()
-	messageHash := []byte("hello world")
-	hashed := hash.Sha256(messageHash)
-	signature := w.PrivateKey().SignHash(hashed)
-
-	preparedInputs, _ := c.PrepareInput(struct {
-		PublicKey   *keys.PublicKey
-		MessageHash []byte
-		Signature   []byte
-	}{
-		PublicKey:   w.PublicKey(),
-		MessageHash: hashed.BytesBE(),
+	hashed := sha256.Sum256([]byte("hello world"))
+
+	// r = x(kG) mod n, s = (h + r*d) / k mod n, the hash is as wide as n
+	curve := elliptic.P256()
+	n := curve.Params().N
+	keyX, keyY := curve.ScalarBaseMult(validKey.Bytes())
+	r, _ := curve.ScalarBaseMult(validNonce.Bytes())
+	r.Mod(r, n)
+	s := new(big.Int).Mul(r, validKey)
+	s.Add(s, new(big.Int).SetBytes(hashed[:]))
+	s.Mul(s, new(big.Int).ModInverse(validNonce, n))
+	s.Mod(s, n)
+
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return circuits.MustPrepareInput(c, Input{
+		PublicKey:   &keys.PublicKey{Curve: curve, X: keyX, Y: keyY},
+		MessageHash: hashed[:],
 		Signature:   signature,
 	})
-
-	return preparedInputs
 }
 
 func init() {
@@ -1,6 +1,8 @@
 package p256_verify
 
 import (
+	"crypto/elliptic"
+	"reflect"
 	"testing"
 
 	"github.com/consensys/gnark-crypto/ecc"
@@ -10,6 +12,7 @@ import (
 	"github.com/consensys/gnark/std/signature/ecdsa"
 	"github.com/consensys/gnark/test"
 	"github.com/nspcc-dev/neo-go/pkg/crypto/hash"
+	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
 )
 
 func TestP256SigVerifyCircuit(t *testing.T) {
@@ -47,3 +50,23 @@ func TestP256SigVerifyCircuit(t *testing.T) {
 		test.WithCurves(ecc.BLS12_381),
 		test.WithBackends(backend.GROTH16))
 }
+
+func TestValidInput(t *testing.T) {
+	c := &Circuit{}
+	if !reflect.DeepEqual(c.ValidInput(), c.ValidInput()) {
+		t.Fatal("ValidInput returned another input")
+	}
+}
+
+// TestMessageHashLength checks that only 32 byte hashes are proven, other
+// lengths would be read as other numbers.
+func TestMessageHashLength(t *testing.T) {
+	curve := elliptic.P256()
+	x, y := curve.ScalarBaseMult(validKey.Bytes())
+	for _, n := range []int{0, 31, 33} {
+		in := Input{PublicKey: &keys.PublicKey{Curve: curve, X: x, Y: y}, MessageHash: make([]byte, n), Signature: make([]byte, 64)}
+		if _, _, err := (&Circuit{}).PrepareInput(in); err == nil {
+			t.Fatalf("%d byte message hash accepted", n)
+		}
+	}
+}
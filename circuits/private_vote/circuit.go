@@ -0,0 +1,284 @@
+// Package private_vote proves an anonymous ballot: the voter's identity
+// commitment is in the eligibility tree, the nullifier allows one ballot per
+// identity and election, and the ballot commits to a choice of the election
+// with a random salt. The ballot contract generated with the verifier tallies
+// the choices once their commitments are revealed.
+package private_vote
+
+import (
+	"fmt"
+	"math/big"
+
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/circuits/gadgets"
+	"neo_zk_starter/circuits/merkle_membership"
+	"neo_zk_starter/circuits/nullifier"
+	"neo_zk_starter/circuits/semaphore"
+	"neo_zk_starter/internal/util"
+	"neo_zk_starter/merkle"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+const (
+	// DefaultDepth is the depth of the eligibility tree.
+	DefaultDepth = semaphore.DefaultDepth
+	// MaxChoices is the largest number of choices of an election.
+	MaxChoices = 1 << choiceBits
+
+	choiceBits = 8
+)
+
+// Circuit proves a ballot of an eligible voter. The identity is a semaphore
+// identity, the election id is the external nullifier of its nullifier.
+type Circuit struct {
+	IdentityNullifier frontend.Variable
+	IdentityTrapdoor  frontend.Variable
+	Siblings          []frontend.Variable // Sibling hashes from the leaf level up
+	PathIndices       []frontend.Variable // Path bits from the leaf level up
+	Choice            frontend.Variable
+	Salt              frontend.Variable
+
+	Root           frontend.Variable `gnark:",public"` // Eligibility tree root
+	NullifierHash  frontend.Variable `gnark:",public"`
+	ElectionID     frontend.Variable `gnark:",public"`
+	Choices        frontend.Variable `gnark:",public"` // Number of choices, the choice is below
+	VoteCommitment frontend.Variable `gnark:",public"` // MiMC(choice, salt)
+}
+
+// Input is the input of the circuit.
+type Input struct {
+	// Member is the membership of the voter in the eligibility tree as
+	// identity.Group.Proof returns it for the election id as external
+	// nullifier. Its signal hash is not used.
+	Member  semaphore.Input
+	Choices uint64
+	Choice  uint64
+	Salt    *big.Int // random, keep it with the choice to reveal the ballot
+}
+
+// New returns a circuit for eligibility trees of the given depth.
+func New(depth int) (*Circuit, error) {
+	if depth < semaphore.MinDepth || depth > semaphore.MaxDepth {
+		return nil, fmt.Errorf("depth must be between %d and %d, got %d", semaphore.MinDepth, semaphore.MaxDepth, depth)
+	}
+	return newCircuit(depth), nil
+}
+
+func newCircuit(depth int) *Circuit {
+	return &Circuit{
+		Siblings:    make([]frontend.Variable, depth),
+		PathIndices: make([]frontend.Variable, depth),
+	}
+}
+
+// Depth returns the depth of the eligibility tree the circuit is compiled for.
+func (c *Circuit) Depth() int {
+	return len(c.Siblings)
+}
+
+// VoteCommitment returns the commitment of a ballot to the choice, the way the
+// circuit and the ballot contract compute it.
+func VoteCommitment(choice uint64, salt *big.Int) *big.Int {
+	return util.HashInputs(util.MiMC, []interface{}{choice, salt})
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	commitment, err := semaphore.IdentityCommitment(api, c.IdentityNullifier, c.IdentityTrapdoor)
+	if err != nil {
+		return err
+	}
+	if err := merkle_membership.VerifyMerklePath(api, commitment, c.Root, c.Siblings, c.PathIndices); err != nil {
+		return err
+	}
+
+	nullifierHash, err := nullifier.Hash(api, c.IdentityNullifier, c.ElectionID)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(c.NullifierHash, nullifierHash)
+
+	// 0 <= choice < choices <= MaxChoices
+	gadgets.AssertBits(api, c.Choice, choiceBits)
+	gadgets.AssertBits(api, api.Sub(c.Choices, 1), choiceBits)
+	gadgets.AssertIsLess(api, c.Choice, c.Choices, choiceBits+1)
+
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	h.Write(c.Choice, c.Salt)
+	api.AssertIsEqual(c.VoteCommitment, h.Sum())
+	return nil
+}
+
+// NullifierInput implements circuits.NullifierCircuit.
+func (c *Circuit) NullifierInput() int {
+	return 1
+}
+
+// ExternalNullifierInput implements circuits.NullifierCircuit.
+func (c *Circuit) ExternalNullifierInput() int {
+	return 2
+}
+
+func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
+	var in Input
+	switch v := input.(type) {
+	case Input:
+		in = v
+	case *Input:
+		if v == nil {
+			return nil, nil, fmt.Errorf("input is nil")
+		}
+		in = *v
+	default:
+		return nil, nil, fmt.Errorf("input must be private_vote.Input for PrivateVoteCircuit, got %T", input)
+	}
+
+	m := in.Member
+	depth := c.Depth()
+	if m.IdentityNullifier == nil || m.IdentityTrapdoor == nil || m.Root == nil || m.ExternalNullifier == nil {
+		return nil, nil, fmt.Errorf("identity, root and election id are required")
+	}
+	if len(m.Siblings) != depth {
+		return nil, nil, fmt.Errorf("expected %d siblings for a depth %d tree, got %d", depth, depth, len(m.Siblings))
+	}
+	if m.Index >= 1<<depth {
+		return nil, nil, fmt.Errorf("index %d is out of range for a depth %d tree", m.Index, depth)
+	}
+	if in.Choices == 0 || in.Choices > MaxChoices {
+		return nil, nil, fmt.Errorf("choices must be between 1 and %d, got %d", MaxChoices, in.Choices)
+	}
+	if in.Choice >= in.Choices {
+		return nil, nil, fmt.Errorf("choice %d is out of range for %d choices", in.Choice, in.Choices)
+	}
+	if in.Salt == nil {
+		return nil, nil, fmt.Errorf("salt is required")
+	}
+
+	nullifierHash := nullifier.Compute(m.IdentityNullifier, m.ExternalNullifier)
+	voteCommitment := VoteCommitment(in.Choice, in.Salt)
+	assignment := newCircuit(depth)
+	assignment.IdentityNullifier = m.IdentityNullifier
+	assignment.IdentityTrapdoor = m.IdentityTrapdoor
+	assignment.Choice = in.Choice
+	assignment.Salt = in.Salt
+	assignment.Root = m.Root
+	assignment.NullifierHash = nullifierHash
+	assignment.ElectionID = m.ExternalNullifier
+	assignment.Choices = in.Choices
+	assignment.VoteCommitment = voteCommitment
+	for i, sibling := range m.Siblings {
+		if sibling == nil {
+			return nil, nil, fmt.Errorf("sibling %d is nil", i)
+		}
+		assignment.Siblings[i] = sibling
+		assignment.PathIndices[i] = m.Index >> i & 1
+	}
+
+	return assignment, []string{nullifierHash.String(), voteCommitment.String()}, nil
+}
+
+// ParseInput implements circuits.JSONInput, the input is
+// {"identityNullifier": "0x..", "identityTrapdoor": "0x..", "index": 5,
+// "siblings": ["0x..", ...], "root": "0x..", "electionId": "0x..",
+// "choices": 3, "choice": 1, "salt": "0x.."}.
+func (c *Circuit) ParseInput(data []byte) (interface{}, error) {
+	var in struct {
+		IdentityNullifier util.Number   `json:"identityNullifier"`
+		IdentityTrapdoor  util.Number   `json:"identityTrapdoor"`
+		Index             util.Number   `json:"index"`
+		Siblings          []util.Number `json:"siblings"`
+		Root              util.Number   `json:"root"`
+		ElectionID        util.Number   `json:"electionId"`
+		Choices           util.Number   `json:"choices"`
+		Choice            util.Number   `json:"choice"`
+		Salt              util.Number   `json:"salt"`
+	}
+	if err := util.DecodeInput(data, &in); err != nil {
+		return nil, err
+	}
+
+	var out Input
+	for _, f := range []struct {
+		name string
+		n    util.Number
+		v    **big.Int
+	}{
+		{"identityNullifier", in.IdentityNullifier, &out.Member.IdentityNullifier},
+		{"identityTrapdoor", in.IdentityTrapdoor, &out.Member.IdentityTrapdoor},
+		{"root", in.Root, &out.Member.Root},
+		{"electionId", in.ElectionID, &out.Member.ExternalNullifier},
+		{"salt", in.Salt, &out.Salt},
+	} {
+		v, err := util.ParseFieldElement(f.name, f.n)
+		if err != nil {
+			return nil, err
+		}
+		*f.v = v
+	}
+
+	for _, f := range []struct {
+		name string
+		n    util.Number
+		v    *uint64
+		bits int
+	}{
+		{"index", in.Index, &out.Member.Index, c.Depth()},
+		{"choices", in.Choices, &out.Choices, choiceBits + 1},
+		{"choice", in.Choice, &out.Choice, choiceBits},
+	} {
+		v, err := util.ParseFieldElement(f.name, f.n)
+		if err != nil {
+			return nil, err
+		}
+		if v.BitLen() > f.bits {
+			return nil, fmt.Errorf("%s: out of range", f.name)
+		}
+		*f.v = v.Uint64()
+	}
+
+	if len(in.Siblings) != c.Depth() {
+		return nil, fmt.Errorf("siblings: expected %d, got %d", c.Depth(), len(in.Siblings))
+	}
+	out.Member.Siblings = make([]*big.Int, len(in.Siblings))
+	for i, s := range in.Siblings {
+		var err error
+		if out.Member.Siblings[i], err = util.ParseFieldElement(fmt.Sprintf("siblings[%d]", i), s); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (c *Circuit) ValidInput() circuits.Circuit {
+	identityNullifier, identityTrapdoor := big.NewInt(1337), big.NewInt(9001)
+	commitment := semaphore.ComputeIdentityCommitment(identityNullifier, identityTrapdoor)
+	index := uint64(2)
+
+	// Arbitrary sibling hashes
+	siblings := make([]*big.Int, c.Depth())
+	for i := range siblings {
+		siblings[i] = util.HashInputs(util.MiMC, []interface{}{uint64(i)})
+	}
+
+	return circuits.MustPrepareInput(c, Input{
+		Member: semaphore.Input{
+			IdentityNullifier: identityNullifier,
+			IdentityTrapdoor:  identityTrapdoor,
+			Index:             index,
+			Siblings:          siblings,
+			Root:              merkle.ComputeRoot(commitment, index, siblings),
+			ExternalNullifier: big.NewInt(1),
+		},
+		Choices: 3,
+		Choice:  1,
+		Salt:    big.NewInt(123456789),
+	})
+}
+
+func init() {
+	circuits.Register("private_vote", func() circuits.Circuit { return newCircuit(DefaultDepth) })
+}
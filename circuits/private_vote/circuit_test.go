@@ -0,0 +1,176 @@
+package private_vote
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"neo_zk_starter/circuits/nullifier"
+	"neo_zk_starter/circuits/semaphore"
+	"neo_zk_starter/internal/util"
+	"neo_zk_starter/merkle"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/test"
+)
+
+func TestCircuit(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := newCircuit(DefaultDepth)
+	assignment := circuit.ValidInput().(*Circuit)
+	assert.ProverSucceeded(circuit, assignment,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+}
+
+// validInput returns a ballot for the second of three choices in a tree of
+// the depth.
+func validInput(depth int) Input {
+	m := semaphore.Input{
+		IdentityNullifier: big.NewInt(1337),
+		IdentityTrapdoor:  big.NewInt(9001),
+		Index:             1,
+		Siblings:          make([]*big.Int, depth),
+		ExternalNullifier: big.NewInt(7),
+	}
+	for i := range m.Siblings {
+		m.Siblings[i] = big.NewInt(int64(i + 1))
+	}
+	commitment := semaphore.ComputeIdentityCommitment(m.IdentityNullifier, m.IdentityTrapdoor)
+	m.Root = merkle.ComputeRoot(commitment, m.Index, m.Siblings)
+	return Input{Member: m, Choices: 3, Choice: 1, Salt: big.NewInt(42)}
+}
+
+func TestInvalid(t *testing.T) {
+	const depth = 4
+	circuit := newCircuit(depth)
+	prepare := func(in Input) *Circuit {
+		assignment, _, err := circuit.PrepareInput(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return assignment.(*Circuit)
+	}
+
+	valid := prepare(validInput(depth))
+	if err := test.IsSolved(circuit, valid, ecc.BLS12_381.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, change := range map[string]func(in *Input, a *Circuit){
+		"wrong trapdoor": func(in *Input, a *Circuit) { a.IdentityTrapdoor = 9002 },
+		"wrong root":     func(in *Input, a *Circuit) { a.Root = 1 },
+		"nullifier hash of another election": func(in *Input, a *Circuit) {
+			a.ElectionID = 8
+		},
+		"nullifier hash of another identity": func(in *Input, a *Circuit) {
+			a.NullifierHash = nullifier.Compute(big.NewInt(1338), in.Member.ExternalNullifier)
+		},
+		"choice out of range": func(in *Input, a *Circuit) {
+			a.Choice = 3
+			a.VoteCommitment = VoteCommitment(3, in.Salt)
+		},
+		"choice beyond the field": func(in *Input, a *Circuit) {
+			// -1 is below every number of choices when the comparison wraps
+			minusOne := new(big.Int).Sub(ecc.BLS12_381.ScalarField(), big.NewInt(1))
+			a.Choice = minusOne
+			a.VoteCommitment = util.HashInputs(util.MiMC, []interface{}{minusOne, in.Salt})
+		},
+		"no choices": func(in *Input, a *Circuit) {
+			a.Choices = 0
+			a.Choice = 0
+			a.VoteCommitment = VoteCommitment(0, in.Salt)
+		},
+		"too many choices": func(in *Input, a *Circuit) {
+			a.Choices = MaxChoices + 1
+		},
+		"commitment to another choice": func(in *Input, a *Circuit) {
+			a.VoteCommitment = VoteCommitment(2, in.Salt)
+		},
+		"commitment with another salt": func(in *Input, a *Circuit) {
+			a.Salt = 43
+		},
+	} {
+		in := validInput(depth)
+		assignment := prepare(in)
+		change(&in, assignment)
+		if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err == nil {
+			t.Fatalf("%s: accepted", name)
+		}
+	}
+}
+
+func TestPrepareInput(t *testing.T) {
+	circuit := newCircuit(4)
+	in := validInput(4)
+	_, public, err := circuit.PrepareInput(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public[0] != nullifier.Compute(in.Member.IdentityNullifier, in.Member.ExternalNullifier).String() {
+		t.Fatalf("unexpected nullifier hash %s", public[0])
+	}
+	if public[1] != VoteCommitment(in.Choice, in.Salt).String() {
+		t.Fatalf("unexpected vote commitment %s", public[1])
+	}
+
+	for name, change := range map[string]func(in *Input){
+		"choice out of range": func(in *Input) { in.Choice = 3 },
+		"no choices":          func(in *Input) { in.Choices, in.Choice = 0, 0 },
+		"too many choices":    func(in *Input) { in.Choices = MaxChoices + 1 },
+		"missing salt":        func(in *Input) { in.Salt = nil },
+		"missing election":    func(in *Input) { in.Member.ExternalNullifier = nil },
+		"index out of range":  func(in *Input) { in.Member.Index = 16 },
+		"wrong siblings":      func(in *Input) { in.Member.Siblings = in.Member.Siblings[1:] },
+	} {
+		in := validInput(4)
+		change(&in)
+		if _, _, err := circuit.PrepareInput(in); err == nil {
+			t.Fatalf("%s: accepted", name)
+		}
+	}
+	if _, _, err := circuit.PrepareInput(in.Member); err == nil {
+		t.Fatal("wrong input type accepted")
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, depth := range []int{semaphore.MinDepth, DefaultDepth, semaphore.MaxDepth} {
+		c, err := New(depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Depth() != depth {
+			t.Fatalf("expected depth %d, got %d", depth, c.Depth())
+		}
+	}
+	if _, err := New(semaphore.MaxDepth + 1); err == nil {
+		t.Fatal("depth accepted")
+	}
+}
+
+func TestParseInput(t *testing.T) {
+	circuit := newCircuit(2)
+	input, err := circuit.ParseInput([]byte(`{"identityNullifier": 1, "identityTrapdoor": 2, "index": 3,
+		"siblings": [4, 5], "root": 6, "electionId": 7, "choices": 3, "choice": 2, "salt": 8}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in := input.(Input); in.Member.Index != 3 || in.Member.ExternalNullifier.Int64() != 7 ||
+		in.Choices != 3 || in.Choice != 2 || in.Salt.Int64() != 8 {
+		t.Fatalf("unexpected input %+v", in)
+	}
+
+	for data, field := range map[string]string{
+		`{"identityNullifier": 1, "identityTrapdoor": 2, "index": 3, "siblings": [4, 5], "root": 6, "choices": 3, "choice": 2, "salt": 8}`:                    "electionId",
+		`{"identityNullifier": 1, "identityTrapdoor": 2, "index": 3, "siblings": [4, 5], "root": 6, "electionId": 7, "choices": 3, "choice": 256, "salt": 8}`: "choice",
+		`{"identityNullifier": 1, "identityTrapdoor": 2, "index": 4, "siblings": [4, 5], "root": 6, "electionId": 7, "choices": 3, "choice": 2, "salt": 8}`:   "index",
+		`{"identityNullifier": 1, "identityTrapdoor": 2, "index": 3, "siblings": [4], "root": 6, "electionId": 7, "choices": 3, "choice": 2, "salt": 8}`:      "siblings",
+	} {
+		if _, err := circuit.ParseInput([]byte(data)); err == nil || !strings.Contains(err.Error(), field) {
+			t.Fatalf("input %s: expected an error naming %s, got %v", data, field, err)
+		}
+	}
+}
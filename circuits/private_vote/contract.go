@@ -0,0 +1,185 @@
+package private_vote
+
+import (
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/contracts"
+)
+
+// Election phases of the ballot contract.
+const (
+	PhaseVoting = iota
+	PhaseReveal
+	PhaseFinished
+)
+
+// ContractMethods implements circuits.ContractExtension. The verifier becomes
+// the ballot of a single election, deployed with the owner, the election id,
+// the eligibility root and the number of choices. Voters cast ballots with
+// vote while voting, the owner ends voting, voters reveal their choice and
+// salt with reveal, which counts the choice, until the owner ends the reveal.
+func (c *Circuit) ContractMethods() circuits.ContractMethods {
+	ballot := circuits.ContractMethods{
+		Imports: []string{
+			"github.com/nspcc-dev/neo-go/pkg/interop",
+			"github.com/nspcc-dev/neo-go/pkg/interop/convert",
+			"github.com/nspcc-dev/neo-go/pkg/interop/runtime",
+			"github.com/nspcc-dev/neo-go/pkg/interop/storage",
+		},
+		Source:      ballotContractMethods,
+		SafeMethods: []string{"getElection", "getPhase", "getTally", "getPending"},
+		Events: []circuits.ContractEvent{
+			{
+				Name: "Voted",
+				Parameters: []circuits.ContractEventParameter{
+					{Name: "nullifier", Type: "ByteArray"},
+					{Name: "commitment", Type: "Integer"},
+				},
+			},
+			{
+				Name:       "Revealed",
+				Parameters: []circuits.ContractEventParameter{{Name: "choice", Type: "Integer"}},
+			},
+			{
+				Name:       "PhaseChanged",
+				Parameters: []circuits.ContractEventParameter{{Name: "phase", Type: "Integer"}},
+			},
+		},
+	}
+	return ballot.Merge(contracts.MiMC()).Merge(contracts.Items())
+}
+
+const ballotContractMethods = `// Ballot of the private_vote circuit. The public inputs are the eligibility
+// root, the nullifier hash, the election id, the number of choices and the
+// vote commitment MiMC(choice, salt).
+const (
+	phaseVoting = iota
+	phaseReveal
+	phaseFinished
+)
+
+// Storage keys of the ballot.
+const (
+	ownerKey      = "o"
+	electionKey   = "e"
+	rootKey       = "r"
+	choicesKey    = "k"
+	phaseKey      = "p"
+	pendingPrefix = "b"
+	tallyPrefix   = "t"
+)
+
+func _deploy(data any, isUpdate bool) {
+	if isUpdate {
+		return
+	}
+	args := data.([]any)
+	if len(args) != 4 {
+		panic("expected owner, election id, eligibility root and number of choices")
+	}
+	owner := args[0].(interop.Hash160)
+	if len(owner) != interop.Hash160Len {
+		panic("invalid owner")
+	}
+	ctx := storage.GetContext()
+	storage.Put(ctx, ownerKey, owner)
+	storage.Put(ctx, electionKey, args[1].(int))
+	storage.Put(ctx, rootKey, args[2].(int))
+	storage.Put(ctx, choicesKey, args[3].(int))
+	storage.Put(ctx, phaseKey, phaseVoting)
+}
+
+// Vote casts the ballot of a private_vote proof for the election. Every
+// identity votes once, the ballot stays pending until its choice is revealed.
+func Vote(a []byte, b []byte, c []byte, publicInput [][]byte) bool {
+	ctx := storage.GetContext()
+	if getInt(ctx, phaseKey) != phaseVoting {
+		panic("voting has ended")
+	}
+	if len(publicInput) != 5 ||
+		convert.ToInteger(publicInput[0]) != getInt(ctx, rootKey) ||
+		convert.ToInteger(publicInput[3]) != getInt(ctx, choicesKey) {
+		return false
+	}
+	if !verifyAndNullify(getInt(ctx, electionKey), a, b, c, publicInput) {
+		return false
+	}
+	commitment := convert.ToInteger(publicInput[4])
+	key := itemKey(pendingPrefix, commitment)
+	storage.Put(ctx, key, getInt(ctx, key)+1)
+	runtime.Notify("Voted", publicInput[1], commitment)
+	return true
+}
+
+// Reveal counts the choice of a pending ballot committing to the choice and
+// the salt. Reveals are accepted after voting has ended.
+func Reveal(choice int, salt int) bool {
+	ctx := storage.GetContext()
+	if getInt(ctx, phaseKey) != phaseReveal {
+		panic("not revealing")
+	}
+	if choice < 0 || choice >= getInt(ctx, choicesKey) || salt < 0 || salt >= Modulus() {
+		return false
+	}
+	key := itemKey(pendingPrefix, Hash([]int{choice, salt}))
+	pending := getInt(ctx, key)
+	if pending == 0 {
+		return false
+	}
+	if pending == 1 {
+		storage.Delete(ctx, key)
+	} else {
+		storage.Put(ctx, key, pending-1)
+	}
+	tally := itemKey(tallyPrefix, choice)
+	storage.Put(ctx, tally, getInt(ctx, tally)+1)
+	runtime.Notify("Revealed", choice)
+	return true
+}
+
+// EndVoting ends voting and starts the reveal, only the owner can call it.
+func EndVoting() {
+	setPhase(phaseVoting, phaseReveal)
+}
+
+// EndReveal ends the reveal, the tally is final. Only the owner can call it.
+func EndReveal() {
+	setPhase(phaseReveal, phaseFinished)
+}
+
+// GetElection returns the election id, the eligibility root and the number
+// of choices.
+func GetElection() []int {
+	ctx := storage.GetReadOnlyContext()
+	return []int{getInt(ctx, electionKey), getInt(ctx, rootKey), getInt(ctx, choicesKey)}
+}
+
+// GetPhase returns the phase of the election: 0 voting, 1 revealing,
+// 2 finished.
+func GetPhase() int {
+	return getInt(storage.GetReadOnlyContext(), phaseKey)
+}
+
+// GetTally returns the number of revealed ballots for the choice.
+func GetTally(choice int) int {
+	return getInt(storage.GetReadOnlyContext(), itemKey(tallyPrefix, choice))
+}
+
+// GetPending returns the number of cast ballots with the vote commitment that
+// are not revealed.
+func GetPending(commitment int) int {
+	return getInt(storage.GetReadOnlyContext(), itemKey(pendingPrefix, commitment))
+}
+
+// setPhase moves the election from the phase to the next one.
+func setPhase(from, to int) {
+	ctx := storage.GetContext()
+	if !runtime.CheckWitness(storage.Get(ctx, ownerKey).(interop.Hash160)) {
+		panic("only the owner can change the phase")
+	}
+	if getInt(ctx, phaseKey) != from {
+		panic("wrong phase")
+	}
+	storage.Put(ctx, phaseKey, to)
+	runtime.Notify("PhaseChanged", to)
+}
+`
@@ -1,6 +1,8 @@
 package circuits
 
 import (
+	"fmt"
+
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/frontend"
@@ -9,10 +11,108 @@ import (
 // Circuit defines the interface that all circuits must implement
 type Circuit interface {
 	Define(api frontend.API) error
-	PrepareInput(input interface{}) (Circuit, []string)
+	PrepareInput(input interface{}) (Circuit, []string, error)
 	ValidInput() Circuit
 }
 
+// MustPrepareInput prepares the example input of ValidInput. It panics when
+// PrepareInput rejects the input, the example of the circuit is broken then.
+func MustPrepareInput(c Circuit, input interface{}) Circuit {
+	assignment, _, err := c.PrepareInput(input)
+	if err != nil {
+		panic(fmt.Sprintf("invalid example input for %T: %v", c, err))
+	}
+	return assignment
+}
+
+// JSONInput is implemented by circuits that accept their input as JSON, like
+// the prove command does.
+type JSONInput interface {
+	// ParseInput decodes the JSON input into the value PrepareInput expects.
+	// Errors name the offending input field.
+	ParseInput(data []byte) (interface{}, error)
+}
+
+// ParseInput decodes the JSON input of the circuit, see JSONInput.
+func ParseInput(circuit Circuit, data []byte) (interface{}, error) {
+	p, ok := circuit.(JSONInput)
+	if !ok {
+		return nil, fmt.Errorf("circuit %T does not accept JSON input", circuit)
+	}
+	return p.ParseInput(data)
+}
+
+// ContractExtension is implemented by circuits whose verifier contract gets
+// companion methods. The build appends them to the generated contract.
+type ContractExtension interface {
+	ContractMethods() ContractMethods
+}
+
+// NullifierCircuit is implemented by circuits with a nullifier and the
+// external nullifier it is scoped to among their public inputs. Their verifier
+// contract records the nullifier of every verified proof and refuses it
+// afterwards, proofs for another external nullifier are refused as well.
+// Circuits that also implement ContractExtension do not get VerifyProofOnce,
+// their methods call verifyAndNullify after their own checks so that no
+// public method records a nullifier without them.
+type NullifierCircuit interface {
+	// NullifierInput returns the index of the nullifier in the public inputs.
+	NullifierInput() int
+	// ExternalNullifierInput returns the index of the external nullifier in
+	// the public inputs.
+	ExternalNullifierInput() int
+}
+
+// ContractMethods is Go source added to the generated verifier contract. The
+// source may call VerifyProof of the verifier.
+type ContractMethods struct {
+	// Imports are the packages the source uses, ones the verifier already
+	// imports are skipped.
+	Imports []string
+	// Source holds the declarations appended to the contract.
+	Source string
+	// SafeMethods are the methods of the source that do not change state,
+	// named as in the manifest.
+	SafeMethods []string
+	// Events are the notifications the source sends.
+	Events []ContractEvent
+	// Permissions are the contract calls the source makes besides the ones
+	// of the verifier.
+	Permissions []ContractPermission
+}
+
+// Merge returns the methods with the other ones appended, e.g. a library the
+// source calls.
+func (m ContractMethods) Merge(other ContractMethods) ContractMethods {
+	return ContractMethods{
+		Imports:     append(append([]string{}, m.Imports...), other.Imports...),
+		Source:      m.Source + "\n" + other.Source,
+		SafeMethods: append(append([]string{}, m.SafeMethods...), other.SafeMethods...),
+		Events:      append(append([]ContractEvent{}, m.Events...), other.Events...),
+		Permissions: append(append([]ContractPermission{}, m.Permissions...), other.Permissions...),
+	}
+}
+
+// ContractEvent is a notification of the contract, parameter types are the
+// ones of the contract manifest like Integer or ByteArray.
+type ContractEvent struct {
+	Name       string
+	Parameters []ContractEventParameter
+}
+
+// ContractEventParameter is a named parameter of a ContractEvent.
+type ContractEventParameter struct {
+	Name string
+	Type string
+}
+
+// ContractPermission allows the contract to call the methods of the contract
+// with the hash, a little endian hex string as in the contract configuration.
+type ContractPermission struct {
+	Hash    string
+	Methods []string
+}
+
 // Registry stores all available circuits
 type Registry struct {
 	circuits map[string]func() Circuit
@@ -47,8 +147,14 @@ func ListCircuits() []string {
 }
 
 // PrepareWitness creates witness from circuit
-func PrepareWitness(circuit Circuit) (witness.Witness, witness.Witness) {
-	witness, _ := frontend.NewWitness(circuit, ecc.BLS12_381.ScalarField())
-	publicWitness, _ := witness.Public()
-	return witness, publicWitness
+func PrepareWitness(circuit Circuit) (witness.Witness, witness.Witness, error) {
+	witness, err := frontend.NewWitness(circuit, ecc.BLS12_381.ScalarField())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create witness: %w", err)
+	}
+	publicWitness, err := witness.Public()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create public witness: %w", err)
+	}
+	return witness, publicWitness, nil
 }
@@ -0,0 +1,331 @@
+package rollup_transfer
+
+import (
+	"bytes"
+	"fmt"
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/circuits/merkle_membership"
+	"neo_zk_starter/rollup"
+
+	blsMimc "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/mimc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards/eddsa"
+	tedwards "github.com/consensys/gnark-crypto/ecc/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+	"github.com/consensys/gnark/std/hash/mimc"
+	stdeddsa "github.com/consensys/gnark/std/signature/eddsa"
+)
+
+// Default circuit parameters, a batch moves the state by BatchSize transfers
+// in a tree of Depth levels.
+const (
+	DefaultDepth     = merkle_membership.DefaultDepth
+	DefaultBatchSize = 4
+)
+
+// Input is the input of the circuit, it is produced by rollup.State.Apply.
+type Input = rollup.Batch
+
+// Transfer is a single transfer of a batch. The account leaves are
+// MiMC(key, balance, nonce) as in merkle.Leaf, the key of the sender is
+// MiMC(A.X, A.Y) of its public key. A transfer with Enabled set to 0 pads the
+// batch, it leaves the tree unchanged and its signature is not checked.
+type Transfer struct {
+	Enabled         frontend.Variable
+	SenderPublicKey stdeddsa.PublicKey
+	Signature       stdeddsa.Signature
+	ReceiverKey     frontend.Variable
+	Amount          frontend.Variable
+
+	SenderBalance    frontend.Variable
+	SenderNonce      frontend.Variable
+	SenderSiblings   []frontend.Variable
+	SenderPath       []frontend.Variable
+	ReceiverBalance  frontend.Variable
+	ReceiverNonce    frontend.Variable
+	ReceiverSiblings []frontend.Variable // taken after the sender update
+	ReceiverPath     []frontend.Variable
+}
+
+// Circuit proves that a batch of signed transfers moves the account tree from
+// OldRoot to NewRoot. Every enabled transfer is signed by the sender over its
+// current nonce, does not exceed the sender balance and increments the sender
+// nonce. Balances and amounts are 64 bit.
+type Circuit struct {
+	OldRoot   frontend.Variable `gnark:",public"`
+	NewRoot   frontend.Variable `gnark:",public"`
+	Transfers []Transfer
+}
+
+// New returns a circuit for batches of batchSize transfers in trees of the
+// given depth.
+func New(depth, batchSize int) (*Circuit, error) {
+	if depth < 1 || depth > 64 {
+		return nil, fmt.Errorf("depth must be between 1 and 64, got %d", depth)
+	}
+	if batchSize < 1 {
+		return nil, fmt.Errorf("batch size must be positive, got %d", batchSize)
+	}
+	return newCircuit(depth, batchSize), nil
+}
+
+func newCircuit(depth, batchSize int) *Circuit {
+	c := &Circuit{Transfers: make([]Transfer, batchSize)}
+	for i := range c.Transfers {
+		c.Transfers[i] = Transfer{
+			SenderSiblings:   make([]frontend.Variable, depth),
+			SenderPath:       make([]frontend.Variable, depth),
+			ReceiverSiblings: make([]frontend.Variable, depth),
+			ReceiverPath:     make([]frontend.Variable, depth),
+		}
+	}
+	return c
+}
+
+// Depth returns the depth of the account tree the circuit is compiled for.
+func (c *Circuit) Depth() int {
+	return len(c.Transfers[0].SenderSiblings)
+}
+
+// BatchSize returns the number of transfers of a batch.
+func (c *Circuit) BatchSize() int {
+	return len(c.Transfers)
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	curve, err := twistededwards.NewEdCurve(api, tedwards.BLS12_381)
+	if err != nil {
+		return err
+	}
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	hash := func(data ...frontend.Variable) frontend.Variable {
+		h.Reset()
+		h.Write(data...)
+		return h.Sum()
+	}
+
+	root := c.OldRoot
+	for i := range c.Transfers {
+		t := &c.Transfers[i]
+		api.AssertIsBoolean(t.Enabled)
+		senderKey := hash(t.SenderPublicKey.A.X, t.SenderPublicKey.A.Y)
+
+		// The sender signed the transfer over its current nonce, a disabled
+		// transfer verifies the fixed no-op signature instead
+		msg := api.Select(t.Enabled, hash(senderKey, t.ReceiverKey, t.Amount, t.SenderNonce), 0)
+		publicKey := stdeddsa.PublicKey{A: selectPoint(api, t.Enabled, t.SenderPublicKey.A, noOpKey.A)}
+		signature := stdeddsa.Signature{
+			R: selectPoint(api, t.Enabled, t.Signature.R, noOpSignature.R),
+			S: api.Select(t.Enabled, t.Signature.S, noOpSignature.S),
+		}
+		h.Reset()
+		if err := stdeddsa.Verify(curve, signature, msg, publicKey, &h); err != nil {
+			return err
+		}
+
+		// Balances stay 64 bit, so the sender cannot go below zero and the
+		// receiver cannot wrap around the field
+		api.ToBinary(t.Amount, 64)
+		senderBalance := api.Sub(t.SenderBalance, t.Amount)
+		api.ToBinary(senderBalance, 64)
+		receiverBalance := api.Add(t.ReceiverBalance, t.Amount)
+		api.ToBinary(receiverBalance, 64)
+
+		// Update the sender leaf
+		root, err = updateLeaf(api, t.Enabled, root,
+			hash(senderKey, t.SenderBalance, t.SenderNonce),
+			hash(senderKey, senderBalance, api.Add(t.SenderNonce, 1)),
+			t.SenderSiblings, t.SenderPath)
+		if err != nil {
+			return err
+		}
+
+		// Update the receiver leaf in the tree holding the new sender
+		root, err = updateLeaf(api, t.Enabled, root,
+			hash(t.ReceiverKey, t.ReceiverBalance, t.ReceiverNonce),
+			hash(t.ReceiverKey, receiverBalance, t.ReceiverNonce),
+			t.ReceiverSiblings, t.ReceiverPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	api.AssertIsEqual(root, c.NewRoot)
+	return nil
+}
+
+// updateLeaf asserts that the old leaf is in the tree under root and returns
+// the root with the new leaf in its place. When enabled is 0 nothing is
+// asserted and root is returned unchanged.
+func updateLeaf(api frontend.API, enabled, root, oldLeaf, newLeaf frontend.Variable, siblings, path []frontend.Variable) (frontend.Variable, error) {
+	oldRoot, err := merkle_membership.ComputeMerkleRoot(api, oldLeaf, siblings, path)
+	if err != nil {
+		return nil, err
+	}
+	api.AssertIsEqual(api.Mul(enabled, api.Sub(oldRoot, root)), 0)
+	newRoot, err := merkle_membership.ComputeMerkleRoot(api, newLeaf, siblings, path)
+	if err != nil {
+		return nil, err
+	}
+	return api.Select(enabled, newRoot, root), nil
+}
+
+func selectPoint(api frontend.API, b frontend.Variable, p, q twistededwards.Point) twistededwards.Point {
+	return twistededwards.Point{X: api.Select(b, p.X, q.X), Y: api.Select(b, p.Y, q.Y)}
+}
+
+// noOpKey and noOpSignature are a fixed signature of the zero message, the
+// disabled transfers of a batch verify it in place of their own.
+var noOpKey, noOpSignature = noOpSigned()
+
+func noOpSigned() (stdeddsa.PublicKey, stdeddsa.Signature) {
+	key, err := eddsa.GenerateKey(bytes.NewReader(make([]byte, 32)))
+	if err != nil {
+		panic(err)
+	}
+	sig, err := key.Sign(make([]byte, 32), blsMimc.NewMiMC())
+	if err != nil {
+		panic(err)
+	}
+	var publicKey stdeddsa.PublicKey
+	publicKey.Assign(tedwards.BLS12_381, key.PublicKey.Bytes())
+	var signature stdeddsa.Signature
+	signature.Assign(tedwards.BLS12_381, sig)
+	return publicKey, signature
+}
+
+func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
+	var inputData Input
+	switch in := input.(type) {
+	case Input:
+		inputData = in
+	case *Input:
+		if in == nil {
+			return nil, nil, fmt.Errorf("input is nil")
+		}
+		inputData = *in
+	default:
+		return nil, nil, fmt.Errorf("input must be rollup.Batch for RollupTransferCircuit, got %T", input)
+	}
+
+	depth := c.Depth()
+	if inputData.OldRoot == nil || inputData.NewRoot == nil {
+		return nil, nil, fmt.Errorf("old and new root are required")
+	}
+	if len(inputData.Transfers) != c.BatchSize() {
+		return nil, nil, fmt.Errorf("expected %d transfers, got %d, pad the batch with rollup.State.Pad", c.BatchSize(), len(inputData.Transfers))
+	}
+
+	assignment := newCircuit(depth, c.BatchSize())
+	assignment.OldRoot = inputData.OldRoot
+	assignment.NewRoot = inputData.NewRoot
+	for i, w := range inputData.Transfers {
+		t := &assignment.Transfers[i]
+		if w.NoOp {
+			*t = noOpTransfer(depth)
+			continue
+		}
+		if len(w.SenderSiblings) != depth || len(w.ReceiverSiblings) != depth {
+			return nil, nil, fmt.Errorf("transfer %d: expected %d siblings for a depth %d tree", i, depth, depth)
+		}
+		if depth < 64 && (w.SenderIndex >= 1<<depth || w.ReceiverIndex >= 1<<depth) {
+			return nil, nil, fmt.Errorf("transfer %d: index out of range for a depth %d tree", i, depth)
+		}
+		if w.ReceiverKey == nil {
+			return nil, nil, fmt.Errorf("transfer %d: receiver key is required", i)
+		}
+		var sig eddsa.Signature
+		if _, err := sig.SetBytes(w.Signature); err != nil {
+			return nil, nil, fmt.Errorf("transfer %d: invalid signature: %w", i, err)
+		}
+
+		t.Enabled = 1
+		t.SenderPublicKey.Assign(tedwards.BLS12_381, w.SenderPublicKey.Bytes())
+		t.Signature.Assign(tedwards.BLS12_381, w.Signature)
+		t.ReceiverKey = w.ReceiverKey
+		t.Amount = w.Amount
+		t.SenderBalance = w.SenderBalance
+		t.SenderNonce = w.SenderNonce
+		t.ReceiverBalance = w.ReceiverBalance
+		t.ReceiverNonce = w.ReceiverNonce
+		for j := 0; j < depth; j++ {
+			if w.SenderSiblings[j] == nil || w.ReceiverSiblings[j] == nil {
+				return nil, nil, fmt.Errorf("transfer %d: sibling %d is nil", i, j)
+			}
+			t.SenderSiblings[j] = w.SenderSiblings[j]
+			t.SenderPath[j] = w.SenderIndex >> j & 1
+			t.ReceiverSiblings[j] = w.ReceiverSiblings[j]
+			t.ReceiverPath[j] = w.ReceiverIndex >> j & 1
+		}
+	}
+
+	return assignment, []string{inputData.OldRoot.String(), inputData.NewRoot.String()}, nil
+}
+
+// noOpTransfer returns the assignment of a disabled transfer, all zero but
+// for the no-op signature.
+func noOpTransfer(depth int) Transfer {
+	zeros := func() []frontend.Variable {
+		v := make([]frontend.Variable, depth)
+		for i := range v {
+			v[i] = 0
+		}
+		return v
+	}
+	return Transfer{
+		Enabled:          0,
+		SenderPublicKey:  noOpKey,
+		Signature:        noOpSignature,
+		ReceiverKey:      0,
+		Amount:           0,
+		SenderBalance:    0,
+		SenderNonce:      0,
+		ReceiverBalance:  0,
+		ReceiverNonce:    0,
+		SenderSiblings:   zeros(),
+		SenderPath:       zeros(),
+		ReceiverSiblings: zeros(),
+		ReceiverPath:     zeros(),
+	}
+}
+
+// ValidInput moves funds back and forth between two accounts, the keys are
+// derived from fixed seeds.
+func (c *Circuit) ValidInput() circuits.Circuit {
+	state, err := rollup.NewState(c.Depth())
+	if err != nil {
+		panic(err)
+	}
+	keys := make([]*eddsa.PrivateKey, 2)
+	for i := range keys {
+		if keys[i], err = eddsa.GenerateKey(bytes.NewReader(bytes.Repeat([]byte{byte(i + 1)}, 32))); err != nil {
+			panic(err)
+		}
+		if _, err := state.AddAccount(keys[i].PublicKey, 1000); err != nil {
+			panic(err)
+		}
+	}
+
+	transfers := make([]rollup.Transfer, c.BatchSize())
+	for i := range transfers {
+		// Each account sends every other transfer, so its nonce grows by one
+		from := uint64(i % 2)
+		transfers[i] = rollup.Transfer{From: from, To: 1 - from, Amount: uint64(100 + i), Nonce: uint64(i / 2)}
+		if err := state.SignTransfer(keys[from], &transfers[i]); err != nil {
+			panic(err)
+		}
+	}
+	batch, err := state.Apply(transfers)
+	if err != nil {
+		panic(err)
+	}
+
+	return circuits.MustPrepareInput(c, batch)
+}
+
+func init() {
+	circuits.Register("rollup_transfer", func() circuits.Circuit { return newCircuit(DefaultDepth, DefaultBatchSize) })
+}
@@ -0,0 +1,208 @@
+package rollup_transfer
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"neo_zk_starter/merkle"
+	"neo_zk_starter/rollup"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/mimc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards/eddsa"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/test"
+)
+
+func TestCircuit(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := newCircuit(4, 2)
+	validAssignment := circuit.ValidInput().(*Circuit)
+
+	// Test with valid inputs
+	assert.ProverSucceeded(circuit, validAssignment,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+
+	// Test with an amount the sender did not sign
+	amount := *validAssignment
+	amount.Transfers = append([]Transfer{}, validAssignment.Transfers...)
+	amount.Transfers[0].Amount = 1
+	assert.ProverFailed(circuit, &amount,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+
+	// Test with a new root that does not follow from the transfers
+	root := *validAssignment
+	root.NewRoot = validAssignment.OldRoot
+	assert.ProverFailed(circuit, &root,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+}
+
+// TestOverspend builds a signed transfer above the sender balance by hand, the
+// sender balance would wrap around the field.
+func TestOverspend(t *testing.T) {
+	const depth = 4
+	circuit := newCircuit(depth, 1)
+
+	keys := make([]*eddsa.PrivateKey, 2)
+	accounts := make([]rollup.Account, 2)
+	tree, _ := merkle.New(depth)
+	for i := range keys {
+		keys[i], _ = eddsa.GenerateKey(bytes.NewReader(bytes.Repeat([]byte{byte(i + 1)}, 32)))
+		accounts[i] = rollup.Account{PublicKey: keys[i].PublicKey, Balance: 10}
+		_, _ = tree.Append(accounts[i].Leaf().Hash())
+	}
+
+	const amount = 11
+	senderKey := rollup.PublicKeyHash(&accounts[0].PublicKey)
+	receiverKey := rollup.PublicKeyHash(&accounts[1].PublicKey)
+	msg := rollup.TransferMessage(senderKey, receiverKey, amount, 0).FillBytes(make([]byte, 32))
+	sig, err := keys[0].Sign(msg, mimc.NewMiMC())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := rollup.TransferWitness{
+		SenderPublicKey: accounts[0].PublicKey,
+		Signature:       sig,
+		ReceiverKey:     receiverKey,
+		Amount:          amount,
+		SenderIndex:     0,
+		SenderBalance:   10,
+		ReceiverIndex:   1,
+		ReceiverBalance: 10,
+	}
+	oldRoot := tree.Root()
+	w.SenderSiblings, _ = tree.Path(0)
+	wrapped := new(big.Int).Sub(ecc.BLS12_381.ScalarField(), big.NewInt(1))
+	_ = tree.Update(0, merkle.HashElements(senderKey, wrapped, big.NewInt(1)))
+	w.ReceiverSiblings, _ = tree.Path(1)
+	_ = tree.Update(1, merkle.HashElements(receiverKey, big.NewInt(10+amount), big.NewInt(0)))
+
+	assignment, _, err := circuit.PrepareInput(Input{OldRoot: oldRoot, NewRoot: tree.Root(), Transfers: []rollup.TransferWitness{w}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err == nil {
+		t.Fatal("transfer above the balance must fail")
+	}
+
+	// The same transfer within the balance is accepted
+	if err := test.IsSolved(circuit, circuit.ValidInput(), ecc.BLS12_381.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBatch cross-checks the witnesses of rollup.State with the circuit.
+func TestBatch(t *testing.T) {
+	const depth = 8
+	state, _ := rollup.NewState(depth)
+	keys := make([]*eddsa.PrivateKey, 3)
+	for i, balance := range []uint64{1000, 0, 50} {
+		keys[i], _ = eddsa.GenerateKey(bytes.NewReader(bytes.Repeat([]byte{byte(i + 1)}, 32)))
+		if _, err := state.AddAccount(keys[i].PublicKey, balance); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	transfers := []rollup.Transfer{
+		{From: 0, To: 1, Amount: 300},
+		{From: 1, To: 2, Amount: 300},
+		{From: 2, To: 2, Amount: 10}, // to itself
+		{From: 0, To: 2, Amount: 0, Nonce: 1},
+	}
+	for i := range transfers {
+		if err := state.SignTransfer(keys[transfers[i].From], &transfers[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	batch, err := state.Apply(transfers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	circuit, err := New(depth, len(transfers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment, _, err := circuit.PrepareInput(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPadding checks that disabled transfers leave the state unchanged.
+func TestPadding(t *testing.T) {
+	const depth = 4
+	state, _ := rollup.NewState(depth)
+	keys := make([]*eddsa.PrivateKey, 2)
+	for i := range keys {
+		keys[i], _ = eddsa.GenerateKey(bytes.NewReader(bytes.Repeat([]byte{byte(i + 1)}, 32)))
+		if _, err := state.AddAccount(keys[i].PublicKey, 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+	transfer := rollup.Transfer{From: 0, To: 1, Amount: 30}
+	if err := state.SignTransfer(keys[0], &transfer); err != nil {
+		t.Fatal(err)
+	}
+	batch, err := state.Apply([]rollup.Transfer{transfer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Pad(batch, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	circuit := newCircuit(depth, 3)
+	assignment, _, err := circuit.PrepareInput(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A batch of padding only keeps the root
+	empty := &rollup.Batch{OldRoot: batch.NewRoot, NewRoot: batch.NewRoot}
+	if err := state.Pad(empty, 3); err != nil {
+		t.Fatal(err)
+	}
+	assignment, _, err = circuit.PrepareInput(empty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The signed transfer disabled does not move the root
+	disabled, _, _ := circuit.PrepareInput(batch)
+	disabled.(*Circuit).Transfers[0].Enabled = 0
+	if err := test.IsSolved(circuit, disabled, ecc.BLS12_381.ScalarField()); err == nil {
+		t.Fatal("a disabled transfer must not change the root")
+	}
+	if err := state.Pad(batch, 2); err == nil {
+		t.Fatal("padding below the batch length must fail")
+	}
+}
+
+func TestPrepareInput(t *testing.T) {
+	circuit := newCircuit(4, 2)
+	if _, _, err := circuit.PrepareInput(Input{OldRoot: big.NewInt(1), NewRoot: big.NewInt(2)}); err == nil {
+		t.Fatal("a batch with missing transfers must be rejected")
+	}
+	if _, _, err := circuit.PrepareInput(struct{}{}); err == nil {
+		t.Fatal("wrong input type must be rejected")
+	}
+	if _, err := New(4, 0); err == nil {
+		t.Fatal("empty batches must be rejected")
+	}
+}
@@ -0,0 +1,205 @@
+// Package sealed_bid proves statements about a sealed bid, a salted MiMC
+// commitment of a bidder to a 64 bit bid, without opening it. In range mode the bid is
+// between the reserve and the maximum bid, in above mode it is higher than a
+// public threshold. The auction contract generated with the verifier runs a
+// second price auction of range proven bids on top.
+package sealed_bid
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/circuits/gadgets"
+	"neo_zk_starter/internal/util"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/nspcc-dev/neo-go/pkg/encoding/address"
+	neoutil "github.com/nspcc-dev/neo-go/pkg/util"
+)
+
+// Mode is the statement a proof makes about the bid.
+type Mode uint64
+
+const (
+	// ModeRange proves Low <= bid <= High.
+	ModeRange Mode = iota
+	// ModeAbove proves Low < bid <= High.
+	ModeAbove
+)
+
+// Circuit proves that the commitment of the bidder opens to a bid within the
+// bounds of the mode. Bids and bounds are 64 bit. The bidder is public, so a
+// copied proof only commits for the bidder it was made for.
+type Circuit struct {
+	Bid  frontend.Variable
+	Salt frontend.Variable
+
+	Commitment frontend.Variable `gnark:",public"` // MiMC(bid, salt, bidder)
+	Mode       frontend.Variable `gnark:",public"`
+	Low        frontend.Variable `gnark:",public"` // reserve or threshold
+	High       frontend.Variable `gnark:",public"` // maximum bid
+	Bidder     frontend.Variable `gnark:",public"` // see BidderElement
+}
+
+// Input is the input of the circuit.
+type Input struct {
+	Bid    uint64
+	Salt   *big.Int // random, keep it with the bid to reveal it
+	Mode   Mode
+	Low    uint64          // the reserve in range mode, the threshold in above mode
+	High   uint64          // the maximum bid
+	Bidder neoutil.Uint160 // the account that commits the bid
+}
+
+// BidderElement returns the script hash of the bidder as a field element, the
+// integer NeoVM converts its 20 bytes to, taken as unsigned.
+func BidderElement(bidder neoutil.Uint160) *big.Int {
+	return new(big.Int).SetBytes(bidder.BytesLE())
+}
+
+// Commitment returns the sealed bid of the bidder, the way the circuit and
+// the auction contract compute it.
+func Commitment(bid uint64, salt *big.Int, bidder neoutil.Uint160) *big.Int {
+	return util.HashInputs(util.MiMC, []interface{}{bid, salt, BidderElement(bidder)})
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	h.Write(c.Bid, c.Salt, c.Bidder)
+	api.AssertIsEqual(c.Commitment, h.Sum())
+
+	// Above mode raises the lower bound by one, Low < bid is Low+1 <= bid
+	api.AssertIsBoolean(c.Mode)
+	gadgets.AssertBits(api, c.Bid, 64)
+	gadgets.AssertBits(api, c.Low, 64)
+	gadgets.AssertBits(api, c.High, 64)
+	gadgets.AssertInRange(api, c.Bid, api.Add(c.Low, c.Mode), c.High, 65)
+	return nil
+}
+
+func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
+	var in Input
+	switch v := input.(type) {
+	case Input:
+		in = v
+	case *Input:
+		if v == nil {
+			return nil, nil, fmt.Errorf("input is nil")
+		}
+		in = *v
+	default:
+		return nil, nil, fmt.Errorf("input must be sealed_bid.Input for SealedBidCircuit, got %T", input)
+	}
+	if in.Salt == nil {
+		return nil, nil, fmt.Errorf("salt is required")
+	}
+	switch in.Mode {
+	case ModeRange:
+		if in.Bid < in.Low || in.Bid > in.High {
+			return nil, nil, fmt.Errorf("bid %d is out of range [%d, %d]", in.Bid, in.Low, in.High)
+		}
+	case ModeAbove:
+		if in.Bid <= in.Low || in.Bid > in.High {
+			return nil, nil, fmt.Errorf("bid %d is not above %d or exceeds %d", in.Bid, in.Low, in.High)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown mode %d", in.Mode)
+	}
+
+	commitment := Commitment(in.Bid, in.Salt, in.Bidder)
+	return &Circuit{
+		Bid:        in.Bid,
+		Salt:       in.Salt,
+		Commitment: commitment,
+		Mode:       uint64(in.Mode),
+		Low:        in.Low,
+		High:       in.High,
+		Bidder:     BidderElement(in.Bidder),
+	}, []string{commitment.String()}, nil
+}
+
+// ParseInput implements circuits.JSONInput, the input is {"bid": 250,
+// "salt": "0x..", "mode": "range", "low": 100, "high": 1000, "bidder":
+// "N.."}, mode is "range" or "above", the bidder is an address or a 0x
+// prefixed script hash.
+func (c *Circuit) ParseInput(data []byte) (interface{}, error) {
+	var in struct {
+		Bid    util.Number `json:"bid"`
+		Salt   util.Number `json:"salt"`
+		Mode   string      `json:"mode"`
+		Low    util.Number `json:"low"`
+		High   util.Number `json:"high"`
+		Bidder string      `json:"bidder"`
+	}
+	if err := util.DecodeInput(data, &in); err != nil {
+		return nil, err
+	}
+
+	var out Input
+	switch in.Mode {
+	case "range":
+		out.Mode = ModeRange
+	case "above":
+		out.Mode = ModeAbove
+	default:
+		return nil, fmt.Errorf("mode: must be range or above, got %q", in.Mode)
+	}
+	salt, err := util.ParseFieldElement("salt", in.Salt)
+	if err != nil {
+		return nil, err
+	}
+	out.Salt = salt
+	for _, f := range []struct {
+		name string
+		n    util.Number
+		v    *uint64
+	}{
+		{"bid", in.Bid, &out.Bid},
+		{"low", in.Low, &out.Low},
+		{"high", in.High, &out.High},
+	} {
+		v, err := util.ParseFieldElement(f.name, f.n)
+		if err != nil {
+			return nil, err
+		}
+		if !v.IsUint64() {
+			return nil, fmt.Errorf("%s: must fit in 64 bits", f.name)
+		}
+		*f.v = v.Uint64()
+	}
+	if out.Bidder, err = parseBidder(in.Bidder); err != nil {
+		return nil, fmt.Errorf("bidder: %w", err)
+	}
+	return out, nil
+}
+
+// parseBidder parses an address or a 0x prefixed script hash.
+func parseBidder(s string) (neoutil.Uint160, error) {
+	if s == "" {
+		return neoutil.Uint160{}, fmt.Errorf("is required")
+	}
+	if hash, ok := strings.CutPrefix(s, "0x"); ok {
+		return neoutil.Uint160DecodeStringLE(hash)
+	}
+	return address.StringToUint160(s)
+}
+
+func (c *Circuit) ValidInput() circuits.Circuit {
+	return circuits.MustPrepareInput(c, Input{
+		Bid:  250,
+		Salt: big.NewInt(123456789),
+		Mode: ModeRange,
+		Low:  100,
+		High: 1000,
+	})
+}
+
+func init() {
+	circuits.Register("sealed_bid", func() circuits.Circuit { return &Circuit{} })
+}
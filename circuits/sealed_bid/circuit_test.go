@@ -0,0 +1,147 @@
+package sealed_bid
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"neo_zk_starter/internal/util"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/test"
+	"github.com/nspcc-dev/neo-go/pkg/encoding/address"
+	neoutil "github.com/nspcc-dev/neo-go/pkg/util"
+)
+
+func TestCircuit(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := &Circuit{}
+	assignment := circuit.ValidInput().(*Circuit)
+	assert.ProverSucceeded(circuit, assignment,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+}
+
+func TestModes(t *testing.T) {
+	salt := big.NewInt(42)
+	bidder := neoutil.Uint160{1, 2, 3}
+	// assign skips PrepareInput, which refuses the bids the circuit must refuse
+	assign := func(bid uint64, mode Mode, low, high uint64) *Circuit {
+		return &Circuit{
+			Bid:        bid,
+			Salt:       salt,
+			Commitment: Commitment(bid, salt, bidder),
+			Mode:       uint64(mode),
+			Low:        low,
+			High:       high,
+			Bidder:     BidderElement(bidder),
+		}
+	}
+
+	for _, tc := range []struct {
+		name       string
+		assignment *Circuit
+		ok         bool
+	}{
+		{"range", assign(250, ModeRange, 100, 1000), true},
+		{"range at the reserve", assign(100, ModeRange, 100, 1000), true},
+		{"range at the maximum", assign(1000, ModeRange, 100, 1000), true},
+		{"range below the reserve", assign(99, ModeRange, 100, 1000), false},
+		{"range above the maximum", assign(1001, ModeRange, 100, 1000), false},
+		{"above", assign(251, ModeAbove, 250, 1000), true},
+		{"above at the threshold", assign(250, ModeAbove, 250, 1000), false},
+		{"above below the threshold", assign(100, ModeAbove, 250, 1000), false},
+		{"above the maximum", assign(1001, ModeAbove, 250, 1000), false},
+		{"unknown mode", assign(250, 2, 100, 1000), false},
+		{"bid beyond 64 bits", func() *Circuit {
+			bid := new(big.Int).Lsh(big.NewInt(1), 64)
+			a := assign(0, ModeRange, 0, 0)
+			a.Bid, a.High = bid, bid
+			a.Commitment = util.HashInputs(util.MiMC, []interface{}{bid, salt, BidderElement(bidder)})
+			return a
+		}(), false},
+		{"commitment to another bid", func() *Circuit {
+			a := assign(250, ModeRange, 100, 1000)
+			a.Commitment = Commitment(251, salt, bidder)
+			return a
+		}(), false},
+		{"commitment of another bidder", func() *Circuit {
+			a := assign(250, ModeRange, 100, 1000)
+			a.Bidder = BidderElement(neoutil.Uint160{4})
+			return a
+		}(), false},
+	} {
+		err := test.IsSolved(&Circuit{}, tc.assignment, ecc.BLS12_381.ScalarField())
+		if tc.ok && err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !tc.ok && err == nil {
+			t.Fatalf("%s: accepted", tc.name)
+		}
+	}
+}
+
+func TestPrepareInput(t *testing.T) {
+	circuit := &Circuit{}
+	in := Input{Bid: 250, Salt: big.NewInt(42), Mode: ModeAbove, Low: 200, High: 1000, Bidder: neoutil.Uint160{1}}
+	_, public, err := circuit.PrepareInput(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public[0] != Commitment(in.Bid, in.Salt, in.Bidder).String() {
+		t.Fatalf("unexpected commitment %s", public[0])
+	}
+	if _, pointerPublic, err := circuit.PrepareInput(&in); err != nil || pointerPublic[0] != public[0] {
+		t.Fatalf("pointer input: %v", err)
+	}
+	if _, _, err := circuit.PrepareInput((*Input)(nil)); err == nil {
+		t.Fatal("nil input accepted")
+	}
+
+	for name, change := range map[string]func(in *Input){
+		"below the reserve": func(in *Input) { in.Mode, in.Low = ModeRange, 251 },
+		"at the threshold":  func(in *Input) { in.Low = 250 },
+		"above the maximum": func(in *Input) { in.High = 249 },
+		"unknown mode":      func(in *Input) { in.Mode = 2 },
+		"missing salt":      func(in *Input) { in.Salt = nil },
+	} {
+		in := in
+		change(&in)
+		if _, _, err := circuit.PrepareInput(in); err == nil {
+			t.Fatalf("%s: accepted", name)
+		}
+	}
+}
+
+func TestParseInput(t *testing.T) {
+	circuit := &Circuit{}
+	bidder := neoutil.Uint160{1, 2, 3}
+	input, err := circuit.ParseInput([]byte(`{"bid": 250, "salt": "0x2a", "mode": "above", "low": 200, "high": 1000, "bidder": "0x` + bidder.StringLE() + `"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in := input.(Input); in.Bid != 250 || in.Salt.Int64() != 42 || in.Mode != ModeAbove || in.Low != 200 || in.High != 1000 || in.Bidder != bidder {
+		t.Fatalf("unexpected input %+v", in)
+	}
+	input, err = circuit.ParseInput([]byte(`{"bid": 250, "salt": 42, "mode": "range", "low": 200, "high": 1000, "bidder": "` + address.Uint160ToString(bidder) + `"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.(Input).Bidder != bidder {
+		t.Fatalf("unexpected bidder %s", input.(Input).Bidder.StringLE())
+	}
+
+	for data, field := range map[string]string{
+		`{"bid": 250, "salt": 42, "mode": "below", "low": 200, "high": 1000, "bidder": "0x00"}`:                   "mode",
+		`{"bid": "0x10000000000000000", "salt": 42, "mode": "range", "low": 200, "high": 1000, "bidder": "0x00"}`: "bid",
+		`{"bid": 250, "mode": "range", "low": 200, "high": 1000, "bidder": "0x00"}`:                               "salt",
+		`{"bid": 250, "salt": 42, "mode": "range", "low": 200, "high": 1000}`:                                     "bidder",
+		`{"bid": 250, "salt": 42, "mode": "range", "low": 200, "high": 1000, "bidder": "0x00"}`:                   "bidder",
+	} {
+		if _, err := circuit.ParseInput([]byte(data)); err == nil || !strings.Contains(err.Error(), field) {
+			t.Fatalf("input %s: expected an error naming %s, got %v", data, field, err)
+		}
+	}
+}
@@ -0,0 +1,486 @@
+package sealed_bid
+
+import (
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/contracts"
+
+	"github.com/nspcc-dev/neo-go/pkg/core/native/nativehashes"
+)
+
+// Auction phases of the auction contract.
+const (
+	PhaseCommit = iota
+	PhaseReveal
+	PhaseClosed
+)
+
+// ContractMethods implements circuits.ContractExtension. The verifier becomes
+// a second price sealed bid auction in GAS, deployed with the owner, the
+// reserve, the maximum bid, the deposit and the payment period in blocks.
+// Bidders pay the deposit and commit their sealed bid with a range mode proof
+// bound to them. Every bid must be revealed once committing has ended,
+// deposits of bids that are not revealed are forfeited to the owner. After the
+// reveal phase the highest revealed bid wins at the second highest one, at
+// least the reserve. The deposit of the winner counts towards the price, the
+// rest is due within the payment period or the deposit is forfeited too.
+func (c *Circuit) ContractMethods() circuits.ContractMethods {
+	auction := circuits.ContractMethods{
+		Imports: []string{
+			"github.com/nspcc-dev/neo-go/pkg/interop",
+			"github.com/nspcc-dev/neo-go/pkg/interop/convert",
+			"github.com/nspcc-dev/neo-go/pkg/interop/native/gas",
+			"github.com/nspcc-dev/neo-go/pkg/interop/native/ledger",
+			"github.com/nspcc-dev/neo-go/pkg/interop/runtime",
+			"github.com/nspcc-dev/neo-go/pkg/interop/storage",
+		},
+		Source: auctionContractMethods,
+		SafeMethods: []string{
+			"getAuction", "getPhase", "hasDeposit", "getCommitment", "isRevealed",
+			"getHighestBid", "getSecondBid", "getWinner", "getPrice", "getPaymentDeadline", "isPaid",
+			"getForfeited",
+		},
+		Events: []circuits.ContractEvent{
+			{
+				Name:       "Deposited",
+				Parameters: []circuits.ContractEventParameter{{Name: "bidder", Type: "Hash160"}},
+			},
+			{
+				Name: "Committed",
+				Parameters: []circuits.ContractEventParameter{
+					{Name: "bidder", Type: "Hash160"},
+					{Name: "commitment", Type: "Integer"},
+				},
+			},
+			{
+				Name: "Revealed",
+				Parameters: []circuits.ContractEventParameter{
+					{Name: "bidder", Type: "Hash160"},
+					{Name: "bid", Type: "Integer"},
+				},
+			},
+			{
+				Name:       "PhaseChanged",
+				Parameters: []circuits.ContractEventParameter{{Name: "phase", Type: "Integer"}},
+			},
+			{
+				Name: "Settled",
+				Parameters: []circuits.ContractEventParameter{
+					{Name: "winner", Type: "Hash160"},
+					{Name: "price", Type: "Integer"},
+				},
+			},
+			{
+				Name: "Paid",
+				Parameters: []circuits.ContractEventParameter{
+					{Name: "winner", Type: "Hash160"},
+					{Name: "price", Type: "Integer"},
+				},
+			},
+			{
+				Name:       "Defaulted",
+				Parameters: []circuits.ContractEventParameter{{Name: "winner", Type: "Hash160"}},
+			},
+		},
+		Permissions: []circuits.ContractPermission{
+			{Hash: nativehashes.GAS.StringLE(), Methods: []string{"transfer"}},
+		},
+	}
+	return auction.Merge(contracts.MiMC()).Merge(contracts.Items())
+}
+
+const auctionContractMethods = `// Auction of the sealed_bid circuit. The public inputs are the commitment
+// MiMC(bid, salt, bidder), the mode, the low and the high bound and the
+// bidder. Bids, the reserve and the deposit are in GAS fractions.
+const (
+	phaseCommit = iota
+	phaseReveal
+	phaseClosed
+)
+
+// modeRange is the proof mode of committed bids.
+const modeRange = 0
+
+// Storage keys of the auction, bidder keys append the bidder hash.
+const (
+	ownerKey         = "o"
+	reserveKey       = "r"
+	maxBidKey        = "m"
+	depositKey       = "d"
+	periodKey        = "t"
+	phaseKey         = "p"
+	depositsKey      = "n"
+	revealsKey       = "c"
+	highestKey       = "h"
+	secondKey        = "s"
+	highestBidderKey = "b"
+	winnerKey        = "w"
+	priceKey         = "x"
+	deadlineKey      = "y"
+	paidKey          = "q"
+	forfeitedKey     = "f"
+	depositPrefix    = "g"
+	commitmentPrefix = "k"
+	revealedPrefix   = "e"
+)
+
+func _deploy(data any, isUpdate bool) {
+	if isUpdate {
+		return
+	}
+	args := data.([]any)
+	if len(args) != 5 {
+		panic("expected owner, reserve, maximum bid, deposit and payment period")
+	}
+	owner := args[0].(interop.Hash160)
+	if len(owner) != interop.Hash160Len {
+		panic("invalid owner")
+	}
+	reserve, maxBid, deposit, period := args[1].(int), args[2].(int), args[3].(int), args[4].(int)
+	if reserve < 0 || maxBid < reserve {
+		panic("invalid bid range")
+	}
+	if deposit <= 0 {
+		panic("invalid deposit")
+	}
+	if period <= 0 {
+		panic("invalid payment period")
+	}
+	ctx := storage.GetContext()
+	storage.Put(ctx, ownerKey, owner)
+	storage.Put(ctx, reserveKey, reserve)
+	storage.Put(ctx, maxBidKey, maxBid)
+	storage.Put(ctx, depositKey, deposit)
+	storage.Put(ctx, periodKey, period)
+	storage.Put(ctx, phaseKey, phaseCommit)
+}
+
+// OnNEP17Payment accepts GAS only: the deposit of a bidder while committing,
+// the rest of the price from the winner once the auction is closed.
+func OnNEP17Payment(from interop.Hash160, amount int, data any) {
+	ctx := storage.GetContext()
+	if string(runtime.GetCallingScriptHash()) != gas.Hash {
+		panic("only GAS is accepted")
+	}
+	switch getInt(ctx, phaseKey) {
+	case phaseCommit:
+		acceptDeposit(ctx, from, amount)
+	case phaseClosed:
+		acceptPayment(ctx, from, amount)
+	default:
+		panic("commit phase has ended")
+	}
+}
+
+// acceptDeposit records the deposit of a bidder, exactly the deposit once per
+// bidder.
+func acceptDeposit(ctx storage.Context, from interop.Hash160, amount int) {
+	if amount != getInt(ctx, depositKey) {
+		panic("wrong deposit")
+	}
+	key := bidderKey(depositPrefix, from)
+	if storage.Get(ctx, key) != nil {
+		panic("bidder has paid the deposit")
+	}
+	storage.Put(ctx, key, 1)
+	storage.Put(ctx, depositsKey, getInt(ctx, depositsKey)+1)
+	runtime.Notify("Deposited", from)
+}
+
+// acceptPayment takes the price less the deposit from the winner, exactly and
+// within the payment period.
+func acceptPayment(ctx storage.Context, from interop.Hash160, amount int) {
+	winner := getHash(ctx, winnerKey)
+	if winner == nil || !winner.Equals(from) {
+		panic("only the winner pays")
+	}
+	if storage.Get(ctx, paidKey) != nil {
+		panic("price is paid")
+	}
+	if ledger.CurrentIndex() > getInt(ctx, deadlineKey) {
+		panic("payment period has ended")
+	}
+	if amount != getInt(ctx, priceKey)-getInt(ctx, depositKey) {
+		panic("wrong payment")
+	}
+	settle(ctx, winner)
+}
+
+// Commit seals the bid of the bidder with a range mode proof that it is
+// between the reserve and the maximum bid. The bidder signs, has paid the
+// deposit and commits once. The proof is bound to the bidder, nobody can copy
+// a bid and its proof. A deposit without a commitment cannot be revealed, it
+// is forfeited like the deposits of bids that are not revealed.
+func Commit(bidder interop.Hash160, a []byte, b []byte, c []byte, publicInput [][]byte) bool {
+	ctx := storage.GetContext()
+	if getInt(ctx, phaseKey) != phaseCommit {
+		panic("commit phase has ended")
+	}
+	if !runtime.CheckWitness(bidder) {
+		panic("bidder did not sign")
+	}
+	if storage.Get(ctx, bidderKey(depositPrefix, bidder)) == nil {
+		panic("bidder has not paid the deposit")
+	}
+	if storage.Get(ctx, bidderKey(commitmentPrefix, bidder)) != nil {
+		panic("bidder has committed")
+	}
+	if len(publicInput) != 5 ||
+		convert.ToInteger(publicInput[1]) != modeRange ||
+		convert.ToInteger(publicInput[2]) != getInt(ctx, reserveKey) ||
+		convert.ToInteger(publicInput[3]) != getInt(ctx, maxBidKey) ||
+		convert.ToInteger(publicInput[4]) != bidderElement(bidder) {
+		return false
+	}
+	if !VerifyProof(a, b, c, publicInput) {
+		return false
+	}
+	commitment := convert.ToInteger(publicInput[0])
+	storage.Put(ctx, bidderKey(commitmentPrefix, bidder), commitment)
+	runtime.Notify("Committed", bidder, commitment)
+	return true
+}
+
+// Reveal opens the sealed bid of the bidder, once committing has ended. Every
+// bid is revealed once, the highest and the second highest bids are kept.
+func Reveal(bidder interop.Hash160, bid int, salt int) bool {
+	ctx := storage.GetContext()
+	if getInt(ctx, phaseKey) != phaseReveal {
+		panic("not revealing")
+	}
+	commitment := storage.Get(ctx, bidderKey(commitmentPrefix, bidder))
+	if commitment == nil || IsRevealed(bidder) ||
+		bid < 0 || salt < 0 || salt >= Modulus() ||
+		Hash([]int{bid, salt, bidderElement(bidder)}) != convert.ToInteger(commitment) {
+		return false
+	}
+	storage.Put(ctx, bidderKey(revealedPrefix, bidder), 1)
+	storage.Put(ctx, revealsKey, getInt(ctx, revealsKey)+1)
+	if highest := getInt(ctx, highestKey); bid > highest {
+		storage.Put(ctx, secondKey, highest)
+		storage.Put(ctx, highestKey, bid)
+		storage.Put(ctx, highestBidderKey, bidder)
+	} else if bid > getInt(ctx, secondKey) {
+		storage.Put(ctx, secondKey, bid)
+	}
+	runtime.Notify("Revealed", bidder, bid)
+	return true
+}
+
+// EndCommit ends committing and starts the reveal phase, only the owner can
+// call it.
+func EndCommit() {
+	setPhase(phaseCommit, phaseReveal)
+}
+
+// Close ends the reveal phase and settles the auction, only the owner can call
+// it. The highest revealed bid wins and pays the second highest revealed bid,
+// at least the reserve. Among equal bids the first revealed one wins. The
+// deposits of the bidders that did not reveal are forfeited. A price up to the
+// deposit is paid from the deposit at once, otherwise the payment period
+// starts.
+func Close() {
+	setPhase(phaseReveal, phaseClosed)
+	ctx := storage.GetContext()
+	deposit := getInt(ctx, depositKey)
+	unrevealed := getInt(ctx, depositsKey) - getInt(ctx, revealsKey)
+	storage.Put(ctx, forfeitedKey, unrevealed*deposit)
+
+	winner := getHash(ctx, highestBidderKey)
+	if winner == nil {
+		return
+	}
+	price := getInt(ctx, secondKey)
+	if reserve := getInt(ctx, reserveKey); price < reserve {
+		price = reserve
+	}
+	storage.Put(ctx, winnerKey, winner)
+	storage.Put(ctx, priceKey, price)
+	storage.Put(ctx, deadlineKey, ledger.CurrentIndex()+getInt(ctx, periodKey))
+	runtime.Notify("Settled", winner, price)
+	if price <= deposit {
+		settle(ctx, winner)
+	}
+}
+
+// settle marks the price as paid and transfers it to the owner, the contract
+// holds the deposit of the winner and the payment.
+func settle(ctx storage.Context, winner interop.Hash160) {
+	price := getInt(ctx, priceKey)
+	storage.Put(ctx, paidKey, 1)
+	if price > 0 && !gas.Transfer(runtime.GetExecutingScriptHash(), getHash(ctx, ownerKey), price, nil) {
+		panic("failed to pay the owner")
+	}
+	runtime.Notify("Paid", winner, price)
+}
+
+// ForfeitWinner forfeits the deposit of a winner that has not paid by the end
+// of the payment period, anyone can call it once the period has ended.
+func ForfeitWinner() bool {
+	ctx := storage.GetContext()
+	if getInt(ctx, phaseKey) != phaseClosed {
+		panic("auction is not closed")
+	}
+	winner := getHash(ctx, winnerKey)
+	if winner == nil || storage.Get(ctx, paidKey) != nil ||
+		ledger.CurrentIndex() <= getInt(ctx, deadlineKey) {
+		return false
+	}
+	key := bidderKey(depositPrefix, winner)
+	if storage.Get(ctx, key) == nil {
+		return false
+	}
+	storage.Delete(ctx, key)
+	storage.Put(ctx, forfeitedKey, getInt(ctx, forfeitedKey)+getInt(ctx, depositKey))
+	runtime.Notify("Defaulted", winner)
+	return true
+}
+
+// Refund returns the deposit of a bidder whose bid was revealed, once the
+// auction is closed. The winner gets back what is left of its deposit after
+// paying the price, nothing when the price exceeds the deposit.
+func Refund(bidder interop.Hash160) bool {
+	ctx := storage.GetContext()
+	if getInt(ctx, phaseKey) != phaseClosed {
+		panic("auction is not closed")
+	}
+	key := bidderKey(depositPrefix, bidder)
+	if !IsRevealed(bidder) || storage.Get(ctx, key) == nil {
+		return false
+	}
+	amount := getInt(ctx, depositKey)
+	if bidder.Equals(getHash(ctx, winnerKey)) {
+		if storage.Get(ctx, paidKey) == nil {
+			return false
+		}
+		amount -= getInt(ctx, priceKey)
+	}
+	storage.Delete(ctx, key)
+	if amount <= 0 {
+		return false
+	}
+	return gas.Transfer(runtime.GetExecutingScriptHash(), bidder, amount, nil)
+}
+
+// CollectForfeited transfers the forfeited deposits to the owner, only the
+// owner can call it once the auction is closed.
+func CollectForfeited() bool {
+	ctx := storage.GetContext()
+	owner := getHash(ctx, ownerKey)
+	if !runtime.CheckWitness(owner) {
+		panic("only the owner can collect")
+	}
+	if getInt(ctx, phaseKey) != phaseClosed {
+		panic("auction is not closed")
+	}
+	amount := getInt(ctx, forfeitedKey)
+	if amount == 0 {
+		return false
+	}
+	storage.Put(ctx, forfeitedKey, 0)
+	return gas.Transfer(runtime.GetExecutingScriptHash(), owner, amount, nil)
+}
+
+// GetAuction returns the reserve, the maximum bid, the deposit and the
+// payment period.
+func GetAuction() []int {
+	ctx := storage.GetReadOnlyContext()
+	return []int{getInt(ctx, reserveKey), getInt(ctx, maxBidKey), getInt(ctx, depositKey), getInt(ctx, periodKey)}
+}
+
+// GetPhase returns the phase of the auction: 0 commit, 1 reveal, 2 closed.
+func GetPhase() int {
+	return getInt(storage.GetReadOnlyContext(), phaseKey)
+}
+
+// HasDeposit reports whether the bidder has a deposit in the auction.
+func HasDeposit(bidder interop.Hash160) bool {
+	return storage.Get(storage.GetReadOnlyContext(), bidderKey(depositPrefix, bidder)) != nil
+}
+
+// GetCommitment returns the sealed bid of the bidder, zero when it did not
+// commit.
+func GetCommitment(bidder interop.Hash160) int {
+	return getInt(storage.GetReadOnlyContext(), bidderKey(commitmentPrefix, bidder))
+}
+
+// IsRevealed reports whether the bid of the bidder was revealed.
+func IsRevealed(bidder interop.Hash160) bool {
+	return storage.Get(storage.GetReadOnlyContext(), bidderKey(revealedPrefix, bidder)) != nil
+}
+
+// GetHighestBid returns the highest revealed bid.
+func GetHighestBid() int {
+	return getInt(storage.GetReadOnlyContext(), highestKey)
+}
+
+// GetSecondBid returns the second highest revealed bid.
+func GetSecondBid() int {
+	return getInt(storage.GetReadOnlyContext(), secondKey)
+}
+
+// GetWinner returns the winner of a closed auction, nil when there is none.
+func GetWinner() interop.Hash160 {
+	return getHash(storage.GetReadOnlyContext(), winnerKey)
+}
+
+// GetPrice returns the price the winner pays.
+func GetPrice() int {
+	return getInt(storage.GetReadOnlyContext(), priceKey)
+}
+
+// GetPaymentDeadline returns the last block the winner can pay in.
+func GetPaymentDeadline() int {
+	return getInt(storage.GetReadOnlyContext(), deadlineKey)
+}
+
+// IsPaid reports whether the price is paid to the owner.
+func IsPaid() bool {
+	return storage.Get(storage.GetReadOnlyContext(), paidKey) != nil
+}
+
+// GetForfeited returns the forfeited deposits the owner has not collected.
+func GetForfeited() int {
+	return getInt(storage.GetReadOnlyContext(), forfeitedKey)
+}
+
+// setPhase moves the auction from the phase to the next one.
+func setPhase(from, to int) {
+	ctx := storage.GetContext()
+	if !runtime.CheckWitness(getHash(ctx, ownerKey)) {
+		panic("only the owner can change the phase")
+	}
+	if getInt(ctx, phaseKey) != from {
+		panic("wrong phase")
+	}
+	storage.Put(ctx, phaseKey, to)
+	runtime.Notify("PhaseChanged", to)
+}
+
+// bidderKey returns the storage key of the bidder item.
+func bidderKey(prefix string, bidder interop.Hash160) string {
+	if len(bidder) != interop.Hash160Len {
+		panic("invalid bidder")
+	}
+	return prefix + string(bidder)
+}
+
+// bidderElement returns the bidder hash as the field element of the proofs,
+// its 20 bytes as an unsigned little endian integer.
+func bidderElement(bidder interop.Hash160) int {
+	x := convert.ToInteger(bidder)
+	if x < 0 {
+		bits := 8 * interop.Hash160Len
+		x += 1 << bits
+	}
+	return x
+}
+
+// getHash returns the hash stored under the key, nil when absent.
+func getHash(ctx storage.Context, key string) interop.Hash160 {
+	v := storage.Get(ctx, key)
+	if v == nil {
+		return nil
+	}
+	return v.(interop.Hash160)
+}
+`
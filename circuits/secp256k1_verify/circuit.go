@@ -0,0 +1,180 @@
+package secp256k1_verify
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/internal/util"
+
+	"github.com/consensys/gnark-crypto/ecc/secp256k1"
+	cryptoecdsa "github.com/consensys/gnark-crypto/ecc/secp256k1/ecdsa"
+	"github.com/consensys/gnark-crypto/ecc/secp256k1/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_emulated"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/signature/ecdsa"
+)
+
+// Input is the input of the circuit, it is shared with api.Secp256k1Proof.
+// NewInput builds it from a recoverable signature.
+type Input struct {
+	PublicKey   *secp256k1.G1Affine
+	MessageHash []byte // 32 bytes, big endian
+	Signature   []byte // 64 bytes, r || s
+}
+
+type Circuit struct {
+	PublicKey   ecdsa.PublicKey[emulated.Secp256k1Fp, emulated.Secp256k1Fr] `gnark:",public"`
+	Signature   ecdsa.Signature[emulated.Secp256k1Fr]                       `gnark:",public"`
+	MessageHash emulated.Element[emulated.Secp256k1Fr]                      `gnark:",public"`
+}
+
+func VerifySecp256k1Sig(api frontend.API, publicKey ecdsa.PublicKey[emulated.Secp256k1Fp, emulated.Secp256k1Fr], messageHash emulated.Element[emulated.Secp256k1Fr], signature ecdsa.Signature[emulated.Secp256k1Fr]) {
+	publicKey.Verify(api, sw_emulated.GetSecp256k1Params(), &messageHash, &signature)
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	VerifySecp256k1Sig(api, c.PublicKey, c.MessageHash, c.Signature)
+	return nil
+}
+
+func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
+	var inputData Input
+	switch in := input.(type) {
+	case Input:
+		inputData = in
+	case *Input:
+		if in == nil {
+			return nil, nil, fmt.Errorf("input is nil")
+		}
+		inputData = *in
+	default:
+		return nil, nil, fmt.Errorf("input must be secp256k1_verify.Input for Secp256k1SigVerifyCircuit, got %T", input)
+	}
+	if inputData.PublicKey == nil {
+		return nil, nil, fmt.Errorf("public key is required")
+	}
+	if inputData.PublicKey.IsInfinity() || !inputData.PublicKey.IsOnCurve() {
+		return nil, nil, fmt.Errorf("public key is not a secp256k1 point")
+	}
+	if len(inputData.Signature) != 64 {
+		return nil, nil, fmt.Errorf("signature must be 64 bytes, got %d", len(inputData.Signature))
+	}
+	if len(inputData.MessageHash) != 32 {
+		return nil, nil, fmt.Errorf("message hash must be 32 bytes, got %d", len(inputData.MessageHash))
+	}
+
+	keyX := inputData.PublicKey.X.Bytes()
+	keyY := inputData.PublicKey.Y.Bytes()
+	return &Circuit{
+		PublicKey: ecdsa.PublicKey[emulated.Secp256k1Fp, emulated.Secp256k1Fr]{
+			X: emulated.ValueOf[emulated.Secp256k1Fp](keyX[:]),
+			Y: emulated.ValueOf[emulated.Secp256k1Fp](keyY[:]),
+		},
+		Signature: ecdsa.Signature[emulated.Secp256k1Fr]{
+			R: emulated.ValueOf[emulated.Secp256k1Fr](inputData.Signature[:32]),
+			S: emulated.ValueOf[emulated.Secp256k1Fr](inputData.Signature[32:]),
+		},
+		MessageHash: emulated.ValueOf[emulated.Secp256k1Fr](inputData.MessageHash),
+	}, []string{}, nil
+}
+
+// ParseInput implements circuits.JSONInput, the input holds hex strings:
+// {"publicKey": "<compressed or uncompressed key>", "messageHash": "<hash>",
+// "signature": "<signature>", "signatureFormat": "rs"}. The format is rs,
+// recoverable or compact, rs by default. The public key is recovered from
+// recoverable and compact signatures when it is left out.
+func (c *Circuit) ParseInput(data []byte) (interface{}, error) {
+	var in struct {
+		PublicKey       string          `json:"publicKey"`
+		MessageHash     string          `json:"messageHash"`
+		Signature       string          `json:"signature"`
+		SignatureFormat SignatureFormat `json:"signatureFormat"`
+	}
+	if err := util.DecodeInput(data, &in); err != nil {
+		return nil, err
+	}
+
+	messageHash, err := util.ParseHex("messageHash", in.MessageHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(messageHash) != 32 {
+		return nil, fmt.Errorf("messageHash: must be 32 bytes, got %d", len(messageHash))
+	}
+	signature, err := util.ParseHex("signature", in.Signature)
+	if err != nil {
+		return nil, err
+	}
+	format := in.SignatureFormat
+	if format == "" {
+		format = RS
+	}
+
+	if in.PublicKey == "" {
+		if format == RS {
+			return nil, fmt.Errorf("publicKey: required for rs signatures")
+		}
+		input, err := NewInput(messageHash, signature, format)
+		if err != nil {
+			return nil, fmt.Errorf("signature: %w", err)
+		}
+		return input, nil
+	}
+
+	keyBytes, err := util.ParseHex("publicKey", in.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := ParsePublicKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("publicKey: %w", err)
+	}
+	rs, _, err := ParseSignature(signature, format)
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+
+	return Input{
+		PublicKey:   publicKey,
+		MessageHash: messageHash,
+		Signature:   rs,
+	}, nil
+}
+
+// validKey and validNonce are the private key and the nonce of the ValidInput
+// signature, fixed so that every call returns the same input.
+var (
+	validKey   = big.NewInt(0x5eed)
+	validNonce = big.NewInt(0x1337)
+)
+
+func (c *Circuit) ValidInput() circuits.Circuit {
+	hashed := sha256.Sum256([]byte("hello world"))
+
+	// r = x(kG) mod n, s = (h + r*d) / k mod n
+	var publicKey, noncePoint secp256k1.G1Affine
+	publicKey.ScalarMultiplicationBase(validKey)
+	noncePoint.ScalarMultiplicationBase(validNonce)
+	n := fr.Modulus()
+	r := noncePoint.X.BigInt(new(big.Int))
+	r.Mod(r, n)
+	s := new(big.Int).Mul(r, validKey)
+	s.Add(s, cryptoecdsa.HashToInt(hashed[:]))
+	s.Mul(s, new(big.Int).ModInverse(validNonce, n))
+	s.Mod(s, n)
+
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return circuits.MustPrepareInput(c, Input{
+		PublicKey:   &publicKey,
+		MessageHash: hashed[:],
+		Signature:   signature,
+	})
+}
+
+func init() {
+	circuits.Register("secp256k1_verify", func() circuits.Circuit { return &Circuit{} })
+}
@@ -0,0 +1,71 @@
+package secp256k1_verify
+
+import (
+	"crypto/sha256"
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/secp256k1"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/math/emulated/emparams"
+	"github.com/consensys/gnark/std/signature/ecdsa"
+	"github.com/consensys/gnark/test"
+)
+
+func TestSecp256k1SigVerifyCircuit(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := &Circuit{}
+	validAssignment := circuit.ValidInput().(*Circuit)
+
+	// Test with valid inputs
+	assert.ProverSucceeded(circuit, validAssignment,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+
+	// Test with invalid signature
+	invalidSigAssignment := &Circuit{
+		PublicKey: validAssignment.PublicKey,
+		Signature: ecdsa.Signature[emparams.Secp256k1Fr]{
+			R: validAssignment.Signature.R,
+			S: validAssignment.Signature.R, // duplicated R
+		},
+		MessageHash: validAssignment.MessageHash,
+	}
+	assert.ProverFailed(circuit, invalidSigAssignment,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+
+	// Test with invalid MessageHash
+	mockHash := sha256.Sum256([]byte("construct additional pylons"))
+	invalidMsgAssignment := &Circuit{
+		PublicKey:   validAssignment.PublicKey,
+		Signature:   validAssignment.Signature,
+		MessageHash: emulated.ValueOf[emparams.Secp256k1Fr](mockHash[:]),
+	}
+	assert.ProverFailed(circuit, invalidMsgAssignment,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+}
+
+func TestValidInput(t *testing.T) {
+	c := &Circuit{}
+	if !reflect.DeepEqual(c.ValidInput(), c.ValidInput()) {
+		t.Fatal("ValidInput returned another input")
+	}
+}
+
+// TestMessageHashLength checks that only 32 byte hashes are proven, other
+// lengths would be read as other numbers.
+func TestMessageHashLength(t *testing.T) {
+	var publicKey secp256k1.G1Affine
+	publicKey.ScalarMultiplicationBase(validKey)
+	for _, n := range []int{0, 31, 33} {
+		in := Input{PublicKey: &publicKey, MessageHash: make([]byte, n), Signature: make([]byte, 64)}
+		if _, _, err := (&Circuit{}).PrepareInput(in); err == nil {
+			t.Fatalf("%d byte message hash accepted", n)
+		}
+	}
+}
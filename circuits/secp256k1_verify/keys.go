@@ -0,0 +1,170 @@
+package secp256k1_verify
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/secp256k1"
+	"github.com/consensys/gnark-crypto/ecc/secp256k1/ecdsa"
+	"github.com/consensys/gnark-crypto/ecc/secp256k1/fp"
+	"github.com/consensys/gnark-crypto/ecc/secp256k1/fr"
+)
+
+// halfOrder is the largest low s value, wallets sign with s <= n/2.
+var halfOrder = new(big.Int).Rsh(fr.Modulus(), 1)
+
+// ParsePublicKey decodes a SEC1 public key, compressed (33 bytes, 02 or 03
+// prefix) or uncompressed (65 bytes, 04 prefix).
+func ParsePublicKey(b []byte) (*secp256k1.G1Affine, error) {
+	var p secp256k1.G1Affine
+	switch {
+	case len(b) == 33 && (b[0] == 0x02 || b[0] == 0x03):
+		if err := p.X.SetBytesCanonical(b[1:]); err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		// y^2 = x^3 + 7
+		var y2, seven fp.Element
+		seven.SetUint64(7)
+		y2.Square(&p.X).Mul(&y2, &p.X).Add(&y2, &seven)
+		if p.Y.Sqrt(&y2) == nil {
+			return nil, fmt.Errorf("x coordinate is not on the curve")
+		}
+		if p.Y.Bytes()[fp.Bytes-1]&1 != b[0]&1 {
+			p.Y.Neg(&p.Y)
+		}
+	case len(b) == 65 && b[0] == 0x04:
+		if err := p.X.SetBytesCanonical(b[1:33]); err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		if err := p.Y.SetBytesCanonical(b[33:]); err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("expected a 33 or 65 byte SEC1 key, got %d bytes", len(b))
+	}
+	if p.IsInfinity() || !p.IsOnCurve() {
+		return nil, fmt.Errorf("point is not on the curve")
+	}
+	return &p, nil
+}
+
+// CompressPublicKey encodes the key as a 33 byte compressed SEC1 key, the
+// form CryptoLib.verifyWithECDsa takes.
+func CompressPublicKey(p *secp256k1.G1Affine) []byte {
+	x, y := p.X.Bytes(), p.Y.Bytes()
+	return append([]byte{0x02 | y[fp.Bytes-1]&1}, x[:]...)
+}
+
+// SignatureFormat is the encoding of a signature.
+type SignatureFormat string
+
+const (
+	// RS is a 64 byte r || s signature, the public key cannot be recovered
+	// from it.
+	RS SignatureFormat = "rs"
+	// Recoverable is a 65 byte r || s || v signature, v is 0, 1, 27 or 28.
+	Recoverable SignatureFormat = "recoverable"
+	// Compact is a 64 byte compact (EIP-2098) signature, which keeps the y
+	// parity in the top bit of s.
+	Compact SignatureFormat = "compact"
+)
+
+// ParseSignature splits a signature of the format into r || s and the
+// recovery id, which is zero for RS signatures. The returned s is low, a high
+// s is negated and the parity flipped.
+func ParseSignature(sig []byte, format SignatureFormat) ([]byte, uint, error) {
+	size := 64
+	if format == Recoverable {
+		size = 65
+	}
+	if len(sig) != size {
+		return nil, 0, fmt.Errorf("expected a %d byte %s signature, got %d bytes", size, format, len(sig))
+	}
+
+	var r, s *big.Int
+	var v uint
+	switch format {
+	case RS:
+		r, s = new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	case Recoverable:
+		switch sig[64] {
+		case 0, 1:
+			v = uint(sig[64])
+		case 27, 28:
+			v = uint(sig[64] - 27)
+		default:
+			return nil, 0, fmt.Errorf("invalid recovery id %d", sig[64])
+		}
+		r, s = new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+	case Compact:
+		v = uint(sig[32] >> 7)
+		vs := append([]byte{sig[32] & 0x7f}, sig[33:]...)
+		r, s = new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(vs)
+	default:
+		return nil, 0, fmt.Errorf("unknown signature format %q", format)
+	}
+	if r.Sign() == 0 || r.Cmp(fr.Modulus()) >= 0 || s.Sign() == 0 || s.Cmp(fr.Modulus()) >= 0 {
+		return nil, 0, fmt.Errorf("r and s must be in [1, n)")
+	}
+	if s.Cmp(halfOrder) > 0 {
+		s.Sub(fr.Modulus(), s)
+		if format != RS {
+			v ^= 1
+		}
+	}
+
+	rs := make([]byte, 64)
+	r.FillBytes(rs[:32])
+	s.FillBytes(rs[32:])
+	return rs, v, nil
+}
+
+// CompactSignature encodes a 65 byte recoverable signature as a 64 byte
+// compact (EIP-2098) one.
+func CompactSignature(sig []byte) ([]byte, error) {
+	rs, v, err := ParseSignature(sig, Recoverable)
+	if err != nil {
+		return nil, err
+	}
+	rs[32] |= byte(v) << 7
+	return rs, nil
+}
+
+// RecoverPublicKey recovers the signer of the message hash from a
+// Recoverable or Compact signature.
+func RecoverPublicKey(messageHash, sig []byte, format SignatureFormat) (*secp256k1.G1Affine, error) {
+	if format == RS {
+		return nil, fmt.Errorf("the public key cannot be recovered from a %s signature", format)
+	}
+	rs, v, err := ParseSignature(sig, format)
+	if err != nil {
+		return nil, err
+	}
+	var pk ecdsa.PublicKey
+	r, s := new(big.Int).SetBytes(rs[:32]), new(big.Int).SetBytes(rs[32:])
+	if err := pk.RecoverFrom(messageHash, v, r, s); err != nil {
+		return nil, fmt.Errorf("recovering the public key: %w", err)
+	}
+	return &pk.A, nil
+}
+
+// NewInput returns the circuit input for a Recoverable or Compact signature
+// of the message hash, the public key is recovered from it.
+func NewInput(messageHash, sig []byte, format SignatureFormat) (Input, error) {
+	if len(messageHash) != 32 {
+		return Input{}, fmt.Errorf("message hash must be 32 bytes, got %d", len(messageHash))
+	}
+	publicKey, err := RecoverPublicKey(messageHash, sig, format)
+	if err != nil {
+		return Input{}, err
+	}
+	rs, _, err := ParseSignature(sig, format)
+	if err != nil {
+		return Input{}, err
+	}
+	return Input{
+		PublicKey:   publicKey,
+		MessageHash: messageHash,
+		Signature:   rs,
+	}, nil
+}
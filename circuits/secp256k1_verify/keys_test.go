@@ -0,0 +1,219 @@
+package secp256k1_verify
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/secp256k1/ecdsa"
+	"github.com/consensys/gnark-crypto/ecc/secp256k1/fr"
+)
+
+// sign returns a 65 byte r || s || v signature of the hash.
+func sign(t *testing.T, key *ecdsa.PrivateKey, hash []byte) []byte {
+	v, r, s, err := key.SignForRecover(hash, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 65)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+	sig[64] = byte(v)
+	return sig
+}
+
+func TestRecoverPublicKey(t *testing.T) {
+	hash := sha256.Sum256([]byte("hello neo"))
+	for i := 0; i < 8; i++ {
+		key, err := ecdsa.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := sign(t, key, hash[:])
+		if sig[64] > 1 {
+			continue // x overflowed the order, not representable in 65 bytes
+		}
+		eth := append(bytes.Clone(sig[:64]), sig[64]+27)
+		compact, err := CompactSignature(sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(compact) != 64 {
+			t.Fatalf("compact signature is %d bytes", len(compact))
+		}
+
+		for _, tc := range []struct {
+			name   string
+			sig    []byte
+			format SignatureFormat
+		}{
+			{"65 bytes", sig, Recoverable},
+			{"v 27/28", eth, Recoverable},
+			{"compact", compact, Compact},
+		} {
+			name, sig := tc.name, tc.sig
+			pk, err := RecoverPublicKey(hash[:], sig, tc.format)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if !pk.Equal(&key.PublicKey.A) {
+				t.Fatalf("%s: recovered the wrong key", name)
+			}
+
+			input, err := NewInput(hash[:], sig, tc.format)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if _, _, err := (&Circuit{}).PrepareInput(input); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if new(big.Int).SetBytes(input.Signature[32:]).Cmp(halfOrder) > 0 {
+				t.Fatalf("%s: s is not normalised", name)
+			}
+		}
+		if _, err := RecoverPublicKey(hash[:], sig[:64], RS); err == nil {
+			t.Fatal("recovered a key from an rs signature")
+		}
+	}
+}
+
+func TestParseSignature(t *testing.T) {
+	hash := sha256.Sum256([]byte("hello neo"))
+	key, err := ecdsa.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := sign(t, key, hash[:])
+	rs, v, err := ParseSignature(sig, Recoverable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The other s of the signature has the other parity and recovers the
+	// same key
+	high := bytes.Clone(rs)
+	s := new(big.Int).SetBytes(rs[32:])
+	s.Sub(fr.Modulus(), s).FillBytes(high[32:])
+	high = append(high, byte(v^1))
+	normal, nv, err := ParseSignature(high, Recoverable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(normal, rs) || nv != v {
+		t.Fatal("high s is not normalised")
+	}
+	if normal, _, err := ParseSignature(high[:64], RS); err != nil || !bytes.Equal(normal, rs) {
+		t.Fatalf("high s of an rs signature is not normalised: %v", err)
+	}
+
+	// The parity of a compact signature is read from s
+	compact, err := CompactSignature(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, cv, err := ParseSignature(compact, Compact); err != nil || cv != v {
+		t.Fatalf("compact signature: %v", err)
+	}
+
+	for _, tc := range []struct {
+		sig    []byte
+		format SignatureFormat
+	}{
+		{sig[:63], Recoverable},
+		{append(bytes.Clone(sig), 0), Recoverable},
+		{append(bytes.Clone(sig[:64]), 2), Recoverable},
+		{sig, Compact},
+		{sig, RS},
+		{rs, Recoverable},
+		{rs, "der"},
+		{make([]byte, 64), RS}, // zero r and s
+	} {
+		if _, _, err := ParseSignature(tc.sig, tc.format); err == nil {
+			t.Fatalf("%s %x accepted", tc.format, tc.sig)
+		}
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed := CompressPublicKey(&key.PublicKey.A)
+	x, y := key.PublicKey.A.X.Bytes(), key.PublicKey.A.Y.Bytes()
+	uncompressed := append(append([]byte{0x04}, x[:]...), y[:]...)
+	for _, b := range [][]byte{compressed, uncompressed} {
+		pk, err := ParsePublicKey(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pk.Equal(&key.PublicKey.A) {
+			t.Fatalf("%x: wrong key", b)
+		}
+	}
+
+	offCurve := bytes.Clone(uncompressed)
+	offCurve[64] ^= 1
+	for _, b := range [][]byte{compressed[1:], offCurve, append([]byte{0x05}, compressed[1:]...)} {
+		if _, err := ParsePublicKey(b); err == nil {
+			t.Fatalf("%x accepted", b)
+		}
+	}
+}
+
+func TestParseInput(t *testing.T) {
+	hash := sha256.Sum256([]byte("hello neo"))
+	key, err := ecdsa.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := sign(t, key, hash[:])
+	compact, err := CompactSignature(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, _, err := ParseSignature(sig, Recoverable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Circuit{}
+	publicKey := hex.EncodeToString(CompressPublicKey(&key.PublicKey.A))
+	for _, tc := range []struct {
+		publicKey, signature string
+		format               SignatureFormat
+		ok                   bool
+	}{
+		{publicKey, hex.EncodeToString(rs), "", true},
+		{publicKey, hex.EncodeToString(rs), RS, true},
+		{publicKey, hex.EncodeToString(compact), Compact, true},
+		{publicKey, hex.EncodeToString(sig), Recoverable, true},
+		{"", hex.EncodeToString(compact), Compact, true},
+		{"", hex.EncodeToString(sig), Recoverable, true},
+		{"", hex.EncodeToString(compact), "", false},
+		{"", hex.EncodeToString(rs), RS, false},
+		{publicKey, hex.EncodeToString(sig), "", false},
+		{"", hex.EncodeToString(sig[:63]), Recoverable, false},
+		{"", hex.EncodeToString(sig), "der", false},
+	} {
+		data := fmt.Sprintf(`{"publicKey": %q, "messageHash": %q, "signature": %q, "signatureFormat": %q}`, tc.publicKey, hex.EncodeToString(hash[:]), tc.signature, tc.format)
+		in, err := c.ParseInput([]byte(data))
+		if (err == nil) != tc.ok {
+			t.Fatalf("%s: expected ok %v, got %v", data, tc.ok, err)
+		}
+		if !tc.ok {
+			continue
+		}
+		input := in.(Input)
+		if !input.PublicKey.Equal(&key.PublicKey.A) {
+			t.Fatalf("%s: wrong key", data)
+		}
+		if _, _, err := c.PrepareInput(input); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
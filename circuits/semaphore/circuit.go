@@ -0,0 +1,256 @@
+// Package semaphore implements Semaphore style anonymous signalling: a member
+// of a group proves that its identity commitment is in the group tree and
+// broadcasts a signal, without revealing which member it is. The nullifier
+// hash allows one signal per identity and external nullifier.
+package semaphore
+
+import (
+	"fmt"
+	"math/big"
+
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/circuits/merkle_membership"
+	"neo_zk_starter/circuits/nullifier"
+	"neo_zk_starter/internal/util"
+	"neo_zk_starter/merkle"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// Supported group tree depths, a depth d group holds 2^d members.
+const (
+	MinDepth     = 1
+	MaxDepth     = 32
+	DefaultDepth = 20
+)
+
+// Circuit proves membership of the identity in the group and binds the signal
+// to the proof. The identity commitment is MiMC(MiMC(nullifier, trapdoor)),
+// the nullifier hash is nullifier.Hash of the identity nullifier and the
+// external nullifier.
+type Circuit struct {
+	IdentityNullifier frontend.Variable
+	IdentityTrapdoor  frontend.Variable
+	Siblings          []frontend.Variable // Sibling hashes from the leaf level up
+	PathIndices       []frontend.Variable // Path bits from the leaf level up
+
+	Root              frontend.Variable `gnark:",public"` // Group tree root
+	NullifierHash     frontend.Variable `gnark:",public"`
+	SignalHash        frontend.Variable `gnark:",public"`
+	ExternalNullifier frontend.Variable `gnark:",public"`
+}
+
+// Input is the input of the circuit, identity.Group.Proof builds it.
+type Input struct {
+	IdentityNullifier *big.Int
+	IdentityTrapdoor  *big.Int
+	Index             uint64     // position of the identity commitment in the group
+	Siblings          []*big.Int // one per level, from the leaf level up
+	Root              *big.Int
+	SignalHash        *big.Int
+	ExternalNullifier *big.Int
+}
+
+// New returns a circuit for groups of the given depth.
+func New(depth int) (*Circuit, error) {
+	if depth < MinDepth || depth > MaxDepth {
+		return nil, fmt.Errorf("depth must be between %d and %d, got %d", MinDepth, MaxDepth, depth)
+	}
+	return newCircuit(depth), nil
+}
+
+func newCircuit(depth int) *Circuit {
+	return &Circuit{
+		Siblings:    make([]frontend.Variable, depth),
+		PathIndices: make([]frontend.Variable, depth),
+	}
+}
+
+// Depth returns the depth of the group tree the circuit is compiled for.
+func (c *Circuit) Depth() int {
+	return len(c.Siblings)
+}
+
+// IdentityCommitment returns the identity commitment, the leaf of the identity
+// in the group tree.
+func IdentityCommitment(api frontend.API, identityNullifier, identityTrapdoor frontend.Variable) (frontend.Variable, error) {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(identityNullifier, identityTrapdoor)
+	secret := h.Sum()
+
+	h.Reset()
+	h.Write(secret)
+	return h.Sum(), nil
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	commitment, err := IdentityCommitment(api, c.IdentityNullifier, c.IdentityTrapdoor)
+	if err != nil {
+		return err
+	}
+	if err := merkle_membership.VerifyMerklePath(api, commitment, c.Root, c.Siblings, c.PathIndices); err != nil {
+		return err
+	}
+
+	nullifierHash, err := nullifier.Hash(api, c.IdentityNullifier, c.ExternalNullifier)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(c.NullifierHash, nullifierHash)
+
+	// The signal hash takes no part in the statement, squaring it keeps it
+	// in the constraints so a proof cannot be replayed with another signal
+	api.Mul(c.SignalHash, c.SignalHash)
+	return nil
+}
+
+// NullifierInput implements circuits.NullifierCircuit.
+func (c *Circuit) NullifierInput() int {
+	return 1
+}
+
+// ExternalNullifierInput implements circuits.NullifierCircuit.
+func (c *Circuit) ExternalNullifierInput() int {
+	return 3
+}
+
+func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
+	var in Input
+	switch v := input.(type) {
+	case Input:
+		in = v
+	case *Input:
+		if v == nil {
+			return nil, nil, fmt.Errorf("input is nil")
+		}
+		in = *v
+	default:
+		return nil, nil, fmt.Errorf("input must be semaphore.Input for SemaphoreCircuit, got %T", input)
+	}
+
+	depth := c.Depth()
+	if in.IdentityNullifier == nil || in.IdentityTrapdoor == nil {
+		return nil, nil, fmt.Errorf("identity nullifier and trapdoor are required")
+	}
+	if in.Root == nil || in.SignalHash == nil || in.ExternalNullifier == nil {
+		return nil, nil, fmt.Errorf("root, signal hash and external nullifier are required")
+	}
+	if len(in.Siblings) != depth {
+		return nil, nil, fmt.Errorf("expected %d siblings for a depth %d group, got %d", depth, depth, len(in.Siblings))
+	}
+	if in.Index >= 1<<depth {
+		return nil, nil, fmt.Errorf("index %d is out of range for a depth %d group", in.Index, depth)
+	}
+
+	nullifierHash := nullifier.Compute(in.IdentityNullifier, in.ExternalNullifier)
+	assignment := newCircuit(depth)
+	assignment.IdentityNullifier = in.IdentityNullifier
+	assignment.IdentityTrapdoor = in.IdentityTrapdoor
+	assignment.Root = in.Root
+	assignment.NullifierHash = nullifierHash
+	assignment.SignalHash = in.SignalHash
+	assignment.ExternalNullifier = in.ExternalNullifier
+	for i, sibling := range in.Siblings {
+		if sibling == nil {
+			return nil, nil, fmt.Errorf("sibling %d is nil", i)
+		}
+		assignment.Siblings[i] = sibling
+		assignment.PathIndices[i] = in.Index >> i & 1
+	}
+
+	return assignment, []string{in.Root.String(), nullifierHash.String(), in.SignalHash.String(), in.ExternalNullifier.String()}, nil
+}
+
+// ParseInput implements circuits.JSONInput, the input is
+// {"identityNullifier": "0x..", "identityTrapdoor": "0x..", "index": 5,
+// "siblings": ["0x..", ...], "root": "0x..", "signalHash": "0x..",
+// "externalNullifier": "0x.."}.
+func (c *Circuit) ParseInput(data []byte) (interface{}, error) {
+	var in struct {
+		IdentityNullifier util.Number   `json:"identityNullifier"`
+		IdentityTrapdoor  util.Number   `json:"identityTrapdoor"`
+		Index             util.Number   `json:"index"`
+		Siblings          []util.Number `json:"siblings"`
+		Root              util.Number   `json:"root"`
+		SignalHash        util.Number   `json:"signalHash"`
+		ExternalNullifier util.Number   `json:"externalNullifier"`
+	}
+	if err := util.DecodeInput(data, &in); err != nil {
+		return nil, err
+	}
+
+	var out Input
+	for _, f := range []struct {
+		name string
+		n    util.Number
+		v    **big.Int
+	}{
+		{"identityNullifier", in.IdentityNullifier, &out.IdentityNullifier},
+		{"identityTrapdoor", in.IdentityTrapdoor, &out.IdentityTrapdoor},
+		{"root", in.Root, &out.Root},
+		{"signalHash", in.SignalHash, &out.SignalHash},
+		{"externalNullifier", in.ExternalNullifier, &out.ExternalNullifier},
+	} {
+		v, err := util.ParseFieldElement(f.name, f.n)
+		if err != nil {
+			return nil, err
+		}
+		*f.v = v
+	}
+
+	index, err := util.ParseFieldElement("index", in.Index)
+	if err != nil {
+		return nil, err
+	}
+	if depth := c.Depth(); index.BitLen() > depth {
+		return nil, fmt.Errorf("index: out of range for a depth %d group", depth)
+	}
+	out.Index = index.Uint64()
+	if len(in.Siblings) != c.Depth() {
+		return nil, fmt.Errorf("siblings: expected %d, got %d", c.Depth(), len(in.Siblings))
+	}
+	out.Siblings = make([]*big.Int, len(in.Siblings))
+	for i, s := range in.Siblings {
+		if out.Siblings[i], err = util.ParseFieldElement(fmt.Sprintf("siblings[%d]", i), s); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// ComputeIdentityCommitment returns the identity commitment the way
+// IdentityCommitment does in a circuit.
+func ComputeIdentityCommitment(identityNullifier, identityTrapdoor *big.Int) *big.Int {
+	secret := util.HashInputs(util.MiMC, []interface{}{identityNullifier, identityTrapdoor})
+	return util.HashInputs(util.MiMC, []interface{}{secret})
+}
+
+func (c *Circuit) ValidInput() circuits.Circuit {
+	identityNullifier, identityTrapdoor := big.NewInt(1337), big.NewInt(9001)
+	commitment := ComputeIdentityCommitment(identityNullifier, identityTrapdoor)
+	index := uint64(1)
+
+	// Arbitrary sibling hashes
+	siblings := make([]*big.Int, c.Depth())
+	for i := range siblings {
+		siblings[i] = util.HashInputs(util.MiMC, []interface{}{uint64(i)})
+	}
+
+	return circuits.MustPrepareInput(c, Input{
+		IdentityNullifier: identityNullifier,
+		IdentityTrapdoor:  identityTrapdoor,
+		Index:             index,
+		Siblings:          siblings,
+		Root:              merkle.ComputeRoot(commitment, index, siblings),
+		SignalHash:        big.NewInt(42),
+		ExternalNullifier: big.NewInt(1),
+	})
+}
+
+func init() {
+	circuits.Register("semaphore", func() circuits.Circuit { return newCircuit(DefaultDepth) })
+}
@@ -0,0 +1,202 @@
+package semaphore
+
+import (
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/circuits/nullifier"
+	"neo_zk_starter/merkle"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/test"
+)
+
+func TestCircuit(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := newCircuit(DefaultDepth)
+	assignment := circuit.ValidInput().(*Circuit)
+	assert.ProverSucceeded(circuit, assignment,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+}
+
+// validInput returns the input of ValidInput for a group of the depth.
+func validInput(depth int) Input {
+	in := Input{
+		IdentityNullifier: big.NewInt(1337),
+		IdentityTrapdoor:  big.NewInt(9001),
+		Index:             1,
+		Siblings:          make([]*big.Int, depth),
+		SignalHash:        big.NewInt(42),
+		ExternalNullifier: big.NewInt(1),
+	}
+	for i := range in.Siblings {
+		in.Siblings[i] = big.NewInt(int64(i + 1))
+	}
+	commitment := ComputeIdentityCommitment(in.IdentityNullifier, in.IdentityTrapdoor)
+	in.Root = merkle.ComputeRoot(commitment, in.Index, in.Siblings)
+	return in
+}
+
+func TestInvalid(t *testing.T) {
+	const depth = 4
+	circuit := newCircuit(depth)
+	prepare := func(in Input) *Circuit {
+		assignment, _, err := circuit.PrepareInput(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return assignment.(*Circuit)
+	}
+
+	valid := prepare(validInput(depth))
+	if err := test.IsSolved(circuit, valid, ecc.BLS12_381.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, change := range map[string]func(in *Input, a *Circuit){
+		"wrong trapdoor":  func(in *Input, a *Circuit) { a.IdentityTrapdoor = 9002 },
+		"wrong nullifier": func(in *Input, a *Circuit) { a.IdentityNullifier = 1338 },
+		"wrong root":      func(in *Input, a *Circuit) { a.Root = 1 },
+		"wrong position":  func(in *Input, a *Circuit) { a.PathIndices[0] = 0 },
+		"path bit not boolean": func(in *Input, a *Circuit) {
+			a.PathIndices[0] = 2
+		},
+		"nullifier hash of another identity": func(in *Input, a *Circuit) {
+			a.NullifierHash = nullifier.Compute(big.NewInt(1338), in.ExternalNullifier)
+		},
+		"nullifier hash of another external nullifier": func(in *Input, a *Circuit) {
+			a.ExternalNullifier = 2
+		},
+		"not a member": func(in *Input, a *Circuit) {
+			// A valid path of another identity commitment
+			other := ComputeIdentityCommitment(big.NewInt(1), big.NewInt(2))
+			a.Root = merkle.ComputeRoot(other, in.Index, in.Siblings)
+		},
+	} {
+		in := validInput(depth)
+		assignment := prepare(in)
+		change(&in, assignment)
+		if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err == nil {
+			t.Fatalf("%s: accepted", name)
+		}
+	}
+}
+
+// TestSignalBinding checks that a proof does not verify for another signal.
+func TestSignalBinding(t *testing.T) {
+	circuit := newCircuit(4)
+	ccs, err := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := validInput(4)
+	assignment, _, err := circuit.PrepareInput(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness, publicWitness, err := circuits.PrepareWitness(assignment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ccs, pk, witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+
+	in.SignalHash = big.NewInt(43)
+	other, _, _ := circuit.PrepareInput(in)
+	_, otherPublic, err := circuits.PrepareWitness(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, otherPublic); err == nil {
+		t.Fatal("proof verified for another signal")
+	}
+}
+
+func TestPrepareInput(t *testing.T) {
+	circuit := newCircuit(4)
+	in := validInput(4)
+	_, public, err := circuit.PrepareInput(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		in.Root.String(),
+		nullifier.Compute(in.IdentityNullifier, in.ExternalNullifier).String(),
+		in.SignalHash.String(),
+		in.ExternalNullifier.String(),
+	}
+	if !slices.Equal(public, expected) {
+		t.Fatalf("expected public inputs %v, got %v", expected, public)
+	}
+
+	in.Index = 16
+	if _, _, err := circuit.PrepareInput(in); err == nil {
+		t.Fatal("index out of range accepted")
+	}
+	in = validInput(3)
+	if _, _, err := circuit.PrepareInput(in); err == nil {
+		t.Fatal("wrong number of siblings accepted")
+	}
+	if _, _, err := circuit.PrepareInput(uint64(1)); err == nil {
+		t.Fatal("wrong input type accepted")
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, depth := range []int{MinDepth, DefaultDepth, MaxDepth} {
+		c, err := New(depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Depth() != depth {
+			t.Fatalf("expected depth %d, got %d", depth, c.Depth())
+		}
+	}
+	for _, depth := range []int{MinDepth - 1, MaxDepth + 1} {
+		if _, err := New(depth); err == nil {
+			t.Fatalf("depth %d accepted", depth)
+		}
+	}
+}
+
+func TestParseInput(t *testing.T) {
+	circuit := newCircuit(2)
+	input, err := circuit.ParseInput([]byte(`{"identityNullifier": 1, "identityTrapdoor": 2, "index": 3,
+		"siblings": [4, 5], "root": 6, "signalHash": 7, "externalNullifier": 8}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in := input.(Input); in.Index != 3 || in.Siblings[1].Int64() != 5 || in.ExternalNullifier.Int64() != 8 {
+		t.Fatalf("unexpected input %+v", in)
+	}
+
+	for data, field := range map[string]string{
+		`{"identityTrapdoor": 2, "index": 3, "siblings": [4, 5], "root": 6, "signalHash": 7, "externalNullifier": 8}`:                           "identityNullifier",
+		`{"identityNullifier": 1, "identityTrapdoor": 2, "index": 4, "siblings": [4, 5], "root": 6, "signalHash": 7, "externalNullifier": 8}`:   "index",
+		`{"identityNullifier": 1, "identityTrapdoor": 2, "index": 3, "siblings": [4], "root": 6, "signalHash": 7, "externalNullifier": 8}`:      "siblings",
+		`{"identityNullifier": 1, "identityTrapdoor": 2, "index": 3, "siblings": [4, 5], "root": 6, "signalHash": "x", "externalNullifier": 8}`: "signalHash",
+	} {
+		if _, err := circuit.ParseInput([]byte(data)); err == nil || !strings.Contains(err.Error(), field) {
+			t.Fatalf("input %s: expected an error naming %s, got %v", data, field, err)
+		}
+	}
+}
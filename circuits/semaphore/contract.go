@@ -0,0 +1,44 @@
+package semaphore
+
+import (
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/circuits/merkle_membership"
+)
+
+// ContractMethods implements circuits.ContractExtension. The verifier holds
+// the group as the incremental Merkle tree of merkle_membership, deposit adds
+// an identity commitment, verifySignal accepts a signal of a member once per
+// identity and external nullifier.
+func (c *Circuit) ContractMethods() circuits.ContractMethods {
+	signal := circuits.ContractMethods{
+		Imports: []string{
+			"github.com/nspcc-dev/neo-go/pkg/interop/convert",
+			"github.com/nspcc-dev/neo-go/pkg/interop/runtime",
+		},
+		Source: signalContractMethods,
+		Events: []circuits.ContractEvent{{
+			Name: "Signal",
+			Parameters: []circuits.ContractEventParameter{
+				{Name: "externalNullifier", Type: "Integer"},
+				{Name: "signalHash", Type: "Integer"},
+			},
+		}},
+	}
+	return merkle_membership.TreeContractMethods(c.Depth()).Merge(signal)
+}
+
+const signalContractMethods = `// VerifySignal verifies a semaphore proof for the external nullifier whose
+// root, the first public input, is a recent root of the group, and records its
+// nullifier hash. Callers pass the external nullifier they accept signals for,
+// every identity signals once for it.
+func VerifySignal(externalNullifier int, a []byte, b []byte, c []byte, publicInput [][]byte) bool {
+	if len(publicInput) != 4 || !IsKnownRoot(convert.ToInteger(publicInput[0])) {
+		return false
+	}
+	if !verifyAndNullify(externalNullifier, a, b, c, publicInput) {
+		return false
+	}
+	runtime.Notify("Signal", externalNullifier, convert.ToInteger(publicInput[2]))
+	return true
+}
+`
@@ -0,0 +1,233 @@
+package smt_verify
+
+import (
+	"fmt"
+	"math/big"
+	"neo_zk_starter/circuits"
+	"neo_zk_starter/circuits/merkle_membership"
+	"neo_zk_starter/internal/util"
+	"neo_zk_starter/merkle"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// FullDepth uses every bit of the key as path, no two keys share a leaf.
+const FullDepth = fr.Bits
+
+// Circuit proves that a key is (Exists = 1) or is not (Exists = 0) in a sparse
+// Merkle tree under the public root.
+//
+// The leaf of a key is at the position given by the lowest depth bits of the
+// key, it is MiMC(key, value) when the key is set and zero when the position
+// is empty. In a truncated tree a position can hold another key instead, which
+// also proves that the key is absent.
+type Circuit struct {
+	Root   frontend.Variable `gnark:",public"`
+	Key    frontend.Variable `gnark:",public"`
+	Exists frontend.Variable `gnark:",public"` // 1 for inclusion, 0 for exclusion
+
+	Value    frontend.Variable   // value of the key, inclusion only
+	Siblings []frontend.Variable // sibling hashes from the leaf level up
+	IsEmpty  frontend.Variable   // exclusion: the position of the key is empty
+	OldKey   frontend.Variable   // exclusion: the other key at the position
+	OldValue frontend.Variable   // exclusion: the value of the other key
+}
+
+// Input is the input of the circuit, it is produced by smt.Tree.Proof.
+type Input struct {
+	Root     *big.Int
+	Key      *big.Int
+	Exists   bool
+	Value    *big.Int   // nil for exclusion
+	Siblings []*big.Int // one per level, from the leaf level up
+	// OldKey and OldValue are the key at the position of an absent key, nil
+	// when the position is empty.
+	OldKey   *big.Int
+	OldValue *big.Int
+}
+
+// New returns a circuit for sparse trees of the given depth.
+func New(depth int) (*Circuit, error) {
+	if depth < 1 || depth > FullDepth {
+		return nil, fmt.Errorf("depth must be between 1 and %d, got %d", FullDepth, depth)
+	}
+	return newCircuit(depth), nil
+}
+
+func newCircuit(depth int) *Circuit {
+	return &Circuit{Siblings: make([]frontend.Variable, depth)}
+}
+
+// Depth returns the depth of the tree the circuit is compiled for.
+func (c *Circuit) Depth() int {
+	return len(c.Siblings)
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	hashLeaf := func(key, value frontend.Variable) frontend.Variable {
+		h.Reset()
+		h.Write(key, value)
+		return h.Sum()
+	}
+
+	api.AssertIsBoolean(c.Exists)
+	api.AssertIsBoolean(c.IsEmpty)
+
+	// The leaf at the position of the key
+	occupied := api.Select(c.IsEmpty, 0, hashLeaf(c.OldKey, c.OldValue))
+	leaf := api.Select(c.Exists, hashLeaf(c.Key, c.Value), occupied)
+
+	// A colliding leaf must belong to another key
+	collides := api.Mul(api.Sub(1, c.Exists), api.Sub(1, c.IsEmpty))
+	api.AssertIsEqual(api.Mul(collides, api.IsZero(api.Sub(c.OldKey, c.Key))), 0)
+
+	// The path is the canonical binary decomposition of the key
+	pathBits := api.ToBinary(c.Key)[:c.Depth()]
+	return merkle_membership.VerifyMerklePath(api, leaf, c.Root, c.Siblings, pathBits)
+}
+
+func (c *Circuit) PrepareInput(input interface{}) (circuits.Circuit, []string, error) {
+	var inputData Input
+	switch in := input.(type) {
+	case Input:
+		inputData = in
+	case *Input:
+		if in == nil {
+			return nil, nil, fmt.Errorf("input is nil")
+		}
+		inputData = *in
+	default:
+		return nil, nil, fmt.Errorf("input must be smt_verify.Input for SMTVerifyCircuit, got %T", input)
+	}
+
+	depth := c.Depth()
+	if inputData.Root == nil || inputData.Key == nil {
+		return nil, nil, fmt.Errorf("root and key are required")
+	}
+	if len(inputData.Siblings) != depth {
+		return nil, nil, fmt.Errorf("expected %d siblings for a depth %d tree, got %d", depth, depth, len(inputData.Siblings))
+	}
+	if inputData.Exists && inputData.Value == nil {
+		return nil, nil, fmt.Errorf("value is required for an inclusion proof")
+	}
+	if (inputData.OldKey == nil) != (inputData.OldValue == nil) {
+		return nil, nil, fmt.Errorf("old key and old value must be given together")
+	}
+
+	assignment := newCircuit(depth)
+	assignment.Root = inputData.Root
+	assignment.Key = inputData.Key
+	assignment.Exists = 0
+	assignment.Value = 0
+	assignment.IsEmpty = 1
+	assignment.OldKey = 0
+	assignment.OldValue = 0
+	if inputData.Exists {
+		assignment.Exists = 1
+		assignment.Value = inputData.Value
+	}
+	if inputData.OldKey != nil {
+		assignment.IsEmpty = 0
+		assignment.OldKey = inputData.OldKey
+		assignment.OldValue = inputData.OldValue
+	}
+	for i, sibling := range inputData.Siblings {
+		if sibling == nil {
+			return nil, nil, fmt.Errorf("sibling %d is nil", i)
+		}
+		assignment.Siblings[i] = sibling
+	}
+
+	return assignment, []string{inputData.Root.String(), inputData.Key.String()}, nil
+}
+
+// ParseInput implements circuits.JSONInput, the input is
+// {"root": "<hash>", "key": "<key>", "exists": true, "value": "<value>",
+// "siblings": ["<hash>", ...]}, exclusion proofs at an occupied position add
+// "oldKey" and "oldValue".
+func (c *Circuit) ParseInput(data []byte) (interface{}, error) {
+	var in struct {
+		Root     util.Number   `json:"root"`
+		Key      util.Number   `json:"key"`
+		Exists   bool          `json:"exists"`
+		Value    util.Number   `json:"value"`
+		Siblings []util.Number `json:"siblings"`
+		OldKey   util.Number   `json:"oldKey"`
+		OldValue util.Number   `json:"oldValue"`
+	}
+	if err := util.DecodeInput(data, &in); err != nil {
+		return nil, err
+	}
+
+	var (
+		out Input
+		err error
+	)
+	out.Exists = in.Exists
+	if out.Root, err = util.ParseFieldElement("root", in.Root); err != nil {
+		return nil, err
+	}
+	if out.Key, err = util.ParseFieldElement("key", in.Key); err != nil {
+		return nil, err
+	}
+	if in.Exists {
+		if out.Value, err = util.ParseFieldElement("value", in.Value); err != nil {
+			return nil, err
+		}
+	}
+	if in.OldKey != "" || in.OldValue != "" {
+		if out.OldKey, err = util.ParseFieldElement("oldKey", in.OldKey); err != nil {
+			return nil, err
+		}
+		if out.OldValue, err = util.ParseFieldElement("oldValue", in.OldValue); err != nil {
+			return nil, err
+		}
+	}
+	if len(in.Siblings) != c.Depth() {
+		return nil, fmt.Errorf("siblings: expected %d, got %d", c.Depth(), len(in.Siblings))
+	}
+	out.Siblings = make([]*big.Int, len(in.Siblings))
+	for i, s := range in.Siblings {
+		if out.Siblings[i], err = util.ParseFieldElement(fmt.Sprintf("siblings[%d]", i), s); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// ValidInput proves that a key is absent from a tree holding a single other
+// key, every sibling is the root of an empty subtree.
+func (c *Circuit) ValidInput() circuits.Circuit {
+	absent := big.NewInt(420)
+	present := big.NewInt(421)
+	value := big.NewInt(9001)
+
+	siblings := make([]*big.Int, c.Depth())
+	empty := new(big.Int)
+	for i := range siblings {
+		siblings[i] = empty
+		empty = util.StringToBigInt(util.HashInputsToString([]interface{}{empty, empty}), 10)
+	}
+
+	// The keys differ in the lowest bit only, so the present key is the
+	// sibling leaf of the absent one.
+	leaf := util.StringToBigInt(util.HashInputsToString([]interface{}{present, value}), 10)
+	siblings[0] = leaf
+	root := merkle.ComputeRoot(new(big.Int), absent.Uint64(), siblings)
+
+	return circuits.MustPrepareInput(c, Input{
+		Root:     root,
+		Key:      absent,
+		Siblings: siblings,
+	})
+}
+
+func init() {
+	circuits.Register("smt_verify", func() circuits.Circuit { return newCircuit(FullDepth) })
+}
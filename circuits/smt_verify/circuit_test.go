@@ -0,0 +1,125 @@
+package smt_verify
+
+import (
+	"math/big"
+	"testing"
+
+	"neo_zk_starter/internal/util"
+	"neo_zk_starter/merkle"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/test"
+)
+
+func hash(a, b *big.Int) *big.Int {
+	return util.StringToBigInt(util.HashInputsToString([]interface{}{a, b}), 10)
+}
+
+func TestCircuit(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := newCircuit(16)
+	validAssignment := circuit.ValidInput().(*Circuit)
+
+	// Test with valid inputs
+	assert.ProverSucceeded(circuit, validAssignment,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+
+	// Test with the present key claimed to be absent, the proof of another key
+	// must not be accepted for it
+	claimed := *validAssignment
+	claimed.Key = 421
+	assert.ProverFailed(circuit, &claimed,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+
+	// Test with the absent key claimed to exist
+	exists := *validAssignment
+	exists.Exists = 1
+	assert.ProverFailed(circuit, &exists,
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16))
+}
+
+func TestCollision(t *testing.T) {
+	const depth = 8
+	circuit := newCircuit(depth)
+
+	// Both keys share the position 5 of a depth 8 tree
+	key := big.NewInt(5)
+	oldKey := big.NewInt(5 + 1<<depth)
+	oldValue := big.NewInt(7)
+	empty := new(big.Int)
+	siblings := make([]*big.Int, depth)
+	for i := range siblings {
+		siblings[i] = empty
+		empty = hash(empty, empty)
+	}
+	root := merkle.ComputeRoot(hash(oldKey, oldValue), 5, siblings)
+
+	assignment, _, err := circuit.PrepareInput(Input{Root: root, Key: key, Siblings: siblings, OldKey: oldKey, OldValue: oldValue})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stored key cannot be proven absent with its own leaf
+	assignment, _, _ = circuit.PrepareInput(Input{Root: root, Key: oldKey, Siblings: siblings, OldKey: oldKey, OldValue: oldValue})
+	if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err == nil {
+		t.Fatal("a key must not be proven absent by its own leaf")
+	}
+
+	// Nor can it be proven absent by claiming its position is empty
+	assignment, _, _ = circuit.PrepareInput(Input{Root: root, Key: oldKey, Siblings: siblings})
+	if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err == nil {
+		t.Fatal("an occupied position must not be proven empty")
+	}
+}
+
+func TestPrepareInput(t *testing.T) {
+	circuit := newCircuit(4)
+	siblings := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+
+	if _, _, err := circuit.PrepareInput(Input{Root: big.NewInt(1), Key: big.NewInt(1), Siblings: siblings[:3]}); err == nil {
+		t.Fatal("too few siblings must be rejected")
+	}
+	if _, _, err := circuit.PrepareInput(Input{Root: big.NewInt(1), Key: big.NewInt(1), Exists: true, Siblings: siblings}); err == nil {
+		t.Fatal("inclusion without a value must be rejected")
+	}
+	if _, _, err := circuit.PrepareInput(Input{Root: big.NewInt(1), Key: big.NewInt(1), Siblings: siblings, OldKey: big.NewInt(2)}); err == nil {
+		t.Fatal("old key without old value must be rejected")
+	}
+
+	parsed, err := circuit.ParseInput([]byte(`{"root": "1", "key": 3, "siblings": [1, 2, 3, 4], "oldKey": "19", "oldValue": "0x10"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := parsed.(Input)
+	if in.Exists || in.OldKey.Int64() != 19 || in.OldValue.Int64() != 16 {
+		t.Fatalf("unexpected input %+v", in)
+	}
+	if _, err := circuit.ParseInput([]byte(`{"root": "1", "key": 3, "exists": true, "siblings": [1, 2, 3, 4]}`)); err == nil {
+		t.Fatal("inclusion without a value must be rejected")
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, depth := range []int{1, 64, FullDepth} {
+		c, err := New(depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Depth() != depth {
+			t.Fatalf("expected depth %d, got %d", depth, c.Depth())
+		}
+	}
+	for _, depth := range []int{0, FullDepth + 1} {
+		if _, err := New(depth); err == nil {
+			t.Fatalf("depth %d must be rejected", depth)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Package contracts adds the contract libraries of its subdirectories to
+// generated contracts. Contracts of this module import the libraries, the
+// contracts generated by the build are single files that get their source.
+package contracts
+
+import (
+	_ "embed"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"strconv"
+
+	"neo_zk_starter/circuits"
+)
+
+//go:embed mimc/mimc.go
+var mimcSource []byte
+
+//go:embed items/items.go
+var itemsSource []byte
+
+// MiMC returns the mimc library as contract methods, its exported functions
+// Hash, HashPair and Modulus become safe methods of the contract.
+func MiMC() circuits.ContractMethods {
+	m, err := library(mimcSource)
+	if err != nil {
+		panic(fmt.Sprintf("mimc library: %v", err))
+	}
+	m.SafeMethods = []string{"hash", "hashPair", "modulus"}
+	return m
+}
+
+// Items returns the storage helpers of the items library, itemKey and getInt.
+func Items() circuits.ContractMethods {
+	m, err := library(itemsSource)
+	if err != nil {
+		panic(fmt.Sprintf("items library: %v", err))
+	}
+	return m
+}
+
+// library returns the declarations and imports of the library source, without
+// its package clause.
+func library(src []byte) (circuits.ContractMethods, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		return circuits.ContractMethods{}, err
+	}
+
+	var m circuits.ContractMethods
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return circuits.ContractMethods{}, err
+		}
+		m.Imports = append(m.Imports, path)
+	}
+	end := f.Name.End()
+	for _, decl := range f.Decls {
+		end = decl.End()
+	}
+	m.Source = string(src[fset.Position(end).Offset:])
+	return m, nil
+}
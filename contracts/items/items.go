@@ -0,0 +1,24 @@
+// Package items holds the storage helpers of the generated contracts, lists
+// of items under a key prefix and integer values. contracts.Items appends the
+// source to a contract, the names are unexported so that they do not become
+// contract methods.
+package items
+
+import (
+	"github.com/nspcc-dev/neo-go/pkg/interop/convert"
+	"github.com/nspcc-dev/neo-go/pkg/interop/storage"
+)
+
+// itemKey returns the storage key of the item i of a list.
+func itemKey(prefix string, i int) string {
+	return prefix + convert.ToString(i)
+}
+
+// getInt returns the integer stored under the key, zero when absent.
+func getInt(ctx storage.Context, key string) int {
+	v := storage.Get(ctx, key)
+	if v == nil {
+		return 0
+	}
+	return convert.ToInteger(v)
+}
@@ -0,0 +1,186 @@
+// Package mimc is a Neo contract library hashing with the MiMC of gnark over
+// the BLS12-381 scalar field, the hash of util.HashInputsToString and the MiMC
+// circuits. Import it from contracts compiled with neo-go, field elements are
+// NeoVM integers. Generated contracts get the source appended instead, see
+// contracts.MiMC, so every unexported name starts with mimc.
+package mimc
+
+import (
+	"github.com/nspcc-dev/neo-go/pkg/interop/convert"
+	"github.com/nspcc-dev/neo-go/pkg/interop/math"
+)
+
+// mimcRounds is the number of encryption rounds of the BLS12-381 MiMC.
+const mimcRounds = 111
+
+// mimcFieldOrder is the BLS12-381 scalar field order, 32 bytes little endian.
+var mimcFieldOrder = []byte{
+	0x01, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff, 0xfe, 0x5b, 0xfe, 0xff, 0x02, 0xa4, 0xbd, 0x53, 0x05, 0xd8, 0xa1, 0x09, 0x08, 0xd8, 0x39, 0x33, 0x48, 0x7d, 0x9d, 0x29, 0x53, 0xa7, 0xed, 0x73,
+}
+
+// mimcRoundConstants are the round constants of gnark-crypto, 32 bytes
+// little endian each. Integer literals of NeoVM size do not compile in Go.
+var mimcRoundConstants = []byte{
+	0x1a, 0xb0, 0x91, 0x4a, 0xdb, 0x9a, 0x1f, 0xf8, 0x60, 0x23, 0xa4, 0x4d, 0xbc, 0x1e, 0xd0, 0x2b, 0x69, 0xc6, 0x7b, 0xf3, 0x22, 0x14, 0x70, 0x15, 0x7d, 0xca, 0x69, 0x3d, 0x76, 0xc7, 0xbf, 0x1d,
+	0x4a, 0x34, 0xdd, 0xc2, 0x8e, 0x5d, 0x09, 0x17, 0x8b, 0x89, 0xfa, 0xec, 0x75, 0x41, 0x8d, 0xe1, 0xa0, 0x25, 0x0f, 0x29, 0x1c, 0x16, 0x05, 0x40, 0x1c, 0xab, 0x4d, 0x33, 0xdd, 0xcd, 0xd2, 0x4f,
+	0x92, 0xf0, 0x75, 0x50, 0x68, 0xe1, 0x13, 0x90, 0x8d, 0x20, 0x7c, 0x68, 0x3d, 0x1f, 0x09, 0xaf, 0x34, 0xd8, 0x50, 0x89, 0x50, 0xcb, 0xac, 0xb0, 0x49, 0xa6, 0x7b, 0xb3, 0xe9, 0x76, 0xcc, 0x09,
+	0xe1, 0xdd, 0x26, 0xcf, 0xfb, 0x57, 0x8b, 0x51, 0xd5, 0x41, 0x98, 0x5e, 0x97, 0x14, 0x14, 0x80, 0xe4, 0xe8, 0xa4, 0x28, 0xc4, 0x47, 0xd0, 0xfa, 0xa0, 0xbb, 0x5f, 0x92, 0x2e, 0x2c, 0x47, 0x16,
+	0x43, 0x42, 0x6e, 0xfc, 0x7a, 0xe4, 0x9a, 0x56, 0x0d, 0xaf, 0x37, 0x78, 0x49, 0xa4, 0xa4, 0xbf, 0x49, 0xb3, 0x45, 0x28, 0xe7, 0xbe, 0x48, 0xb7, 0x01, 0xa2, 0x0e, 0xc4, 0x48, 0xe1, 0xc2, 0x01,
+	0xaf, 0xdb, 0x25, 0x78, 0x40, 0x81, 0x58, 0x03, 0x34, 0x22, 0xc5, 0x7d, 0x22, 0x32, 0xbe, 0xad, 0xb3, 0xe7, 0x22, 0x03, 0x5d, 0xdc, 0xd3, 0xf4, 0xda, 0xb5, 0xbc, 0x5c, 0x62, 0xa7, 0x5e, 0x70,
+	0xea, 0xc1, 0xde, 0x8b, 0x4a, 0x70, 0x4d, 0x8f, 0xda, 0x7d, 0x33, 0x57, 0xce, 0x8f, 0x29, 0x4e, 0xbf, 0xe2, 0x9e, 0x92, 0x54, 0x25, 0x71, 0x41, 0xc9, 0xd0, 0xfd, 0x88, 0xa0, 0xef, 0x72, 0x12,
+	0x2a, 0xd7, 0x14, 0xbe, 0x56, 0xa6, 0xa1, 0x3e, 0xa0, 0xe8, 0x3b, 0x08, 0x9f, 0x80, 0xff, 0xec, 0xfb, 0xb1, 0x05, 0x97, 0xb9, 0xb7, 0xfa, 0x8a, 0x83, 0x2d, 0x6b, 0x06, 0x7b, 0x6f, 0x96, 0x4f,
+	0x03, 0x4c, 0xb0, 0x21, 0xc2, 0x28, 0xa9, 0x11, 0x25, 0x30, 0x0b, 0x7f, 0x55, 0x55, 0x91, 0xb7, 0x1c, 0x76, 0x16, 0x58, 0x03, 0xee, 0x80, 0x96, 0xfc, 0x98, 0x5c, 0x14, 0xb9, 0x92, 0x33, 0x28,
+	0xf4, 0xef, 0xae, 0x50, 0xcf, 0x41, 0xce, 0xe6, 0x4d, 0xb7, 0x6e, 0x69, 0x1d, 0xa2, 0xfb, 0x7a, 0x7f, 0x21, 0x1a, 0x29, 0xe3, 0x7e, 0x08, 0xda, 0xe4, 0x6e, 0x0d, 0x11, 0xa5, 0x47, 0x0a, 0x43,
+	0xef, 0x40, 0x38, 0xf6, 0xaf, 0xe4, 0xf8, 0x60, 0x82, 0xc6, 0xb7, 0xb2, 0xe7, 0x3d, 0xfa, 0xb6, 0x10, 0xa3, 0x52, 0xb4, 0xdf, 0xa8, 0xaa, 0xb9, 0xb4, 0xf5, 0x15, 0x5b, 0x2d, 0xbf, 0x9f, 0x1c,
+	0x7d, 0xaf, 0x12, 0xd4, 0xde, 0xda, 0xf4, 0x4e, 0x02, 0x17, 0x40, 0xdd, 0xbc, 0x78, 0xf6, 0x9d, 0xda, 0x5f, 0x9f, 0xa1, 0xdb, 0x11, 0x28, 0x53, 0x1f, 0x81, 0xbf, 0x5b, 0xd1, 0x56, 0xc7, 0x49,
+	0xb8, 0x9b, 0x7a, 0xdf, 0x4a, 0x3f, 0x42, 0x30, 0xd4, 0xe5, 0xa2, 0xe9, 0x8c, 0x69, 0x60, 0xa9, 0xf9, 0x85, 0x9a, 0xbe, 0xe4, 0x92, 0x92, 0x97, 0x41, 0xf9, 0x2d, 0x30, 0xa3, 0x63, 0x6d, 0x3d,
+	0x0a, 0x2b, 0x79, 0xf1, 0xae, 0xd3, 0xf5, 0xc3, 0x79, 0x56, 0xce, 0x19, 0xe6, 0x33, 0x5b, 0x95, 0x65, 0x99, 0x21, 0xfc, 0x3a, 0x76, 0xc7, 0xaa, 0x6e, 0x09, 0x38, 0xc7, 0xa1, 0x2d, 0x6c, 0x5f,
+	0xaa, 0xb5, 0xbd, 0x68, 0x50, 0xce, 0x2c, 0x65, 0xfb, 0xda, 0x86, 0x9c, 0x87, 0x64, 0x68, 0xb2, 0xb1, 0x8c, 0xa0, 0x77, 0x05, 0x17, 0x68, 0x89, 0x4f, 0xbf, 0x47, 0x8e, 0x53, 0x30, 0xd6, 0x32,
+	0x96, 0x5b, 0x00, 0x0a, 0xd3, 0x46, 0x41, 0x2d, 0xa1, 0xcc, 0xf7, 0x78, 0xf3, 0x04, 0x27, 0x11, 0x1e, 0x44, 0x39, 0x43, 0x37, 0x38, 0x77, 0x77, 0xef, 0xfd, 0x93, 0x55, 0x2a, 0x8b, 0xeb, 0x02,
+	0x28, 0xce, 0x16, 0x22, 0xf4, 0x60, 0xec, 0xf4, 0x5d, 0x8f, 0x0c, 0x38, 0xc4, 0x7c, 0xd6, 0x4c, 0x24, 0xe4, 0xf8, 0x10, 0xb1, 0x86, 0x05, 0xc4, 0x93, 0x32, 0x61, 0xed, 0xcc, 0x13, 0x33, 0x12,
+	0x7c, 0x03, 0xca, 0x81, 0x43, 0xac, 0x85, 0x77, 0x0d, 0x46, 0xf2, 0x85, 0x09, 0x16, 0x19, 0x38, 0x90, 0x46, 0xb1, 0x4e, 0xe2, 0x0e, 0x17, 0xfa, 0x9a, 0x5f, 0xb3, 0xcc, 0x9d, 0xc9, 0xd1, 0x42,
+	0x26, 0x49, 0x8c, 0xce, 0x3d, 0xc2, 0x36, 0x5b, 0x90, 0x7b, 0x38, 0x28, 0xc0, 0x2d, 0x11, 0xd2, 0x8b, 0xa1, 0x67, 0x6c, 0x28, 0xd9, 0x0e, 0x6a, 0xa3, 0xeb, 0xbb, 0xde, 0xf9, 0x5c, 0x37, 0x35,
+	0xc3, 0xbc, 0x18, 0x3c, 0x47, 0xa0, 0x1b, 0x1d, 0x94, 0x80, 0xe0, 0x27, 0x45, 0xed, 0x63, 0x0f, 0xed, 0x71, 0x1d, 0x48, 0xf9, 0xd5, 0x03, 0xfa, 0x16, 0x0e, 0x0e, 0xf5, 0xad, 0x93, 0xe6, 0x67,
+	0x58, 0x72, 0x6c, 0x9b, 0x68, 0x28, 0xff, 0x49, 0x48, 0x2a, 0xc9, 0x35, 0x36, 0x92, 0xd7, 0xea, 0x28, 0x71, 0x04, 0x90, 0x5f, 0x5b, 0xff, 0x7f, 0x8c, 0xdf, 0x99, 0xf6, 0x82, 0x6a, 0x5f, 0x1d,
+	0x44, 0x01, 0x92, 0xee, 0xb9, 0xcf, 0x0f, 0x5a, 0xa4, 0xcf, 0xc5, 0xe0, 0x90, 0xf7, 0xd5, 0xbd, 0x3d, 0xd2, 0xbc, 0x56, 0x09, 0x68, 0xca, 0x43, 0x94, 0xae, 0x3a, 0xe7, 0x44, 0x3d, 0x2a, 0x37,
+	0xf5, 0x22, 0x24, 0xc1, 0x76, 0x2f, 0x4a, 0x00, 0x26, 0xf0, 0x04, 0x5e, 0x54, 0x6e, 0x74, 0x22, 0x93, 0xe5, 0x92, 0xae, 0x45, 0x8d, 0x3e, 0x96, 0x17, 0x64, 0xda, 0x09, 0x90, 0x2c, 0x0b, 0x63,
+	0xc4, 0x5a, 0x7a, 0x89, 0x2f, 0x13, 0x6b, 0x6e, 0xa4, 0xf2, 0x48, 0xbe, 0xc1, 0x62, 0xd2, 0x61, 0x33, 0xa1, 0x11, 0x9b, 0x89, 0x17, 0xa2, 0x74, 0x28, 0x0a, 0x0f, 0x4d, 0xbd, 0x9e, 0x26, 0x33,
+	0xea, 0x3e, 0x8a, 0x4d, 0x15, 0x76, 0xd9, 0x72, 0x00, 0x2a, 0xcd, 0xb6, 0x57, 0xcb, 0x72, 0x50, 0x73, 0x6d, 0x91, 0xdd, 0x84, 0xc2, 0x25, 0x83, 0x56, 0xdb, 0x15, 0x06, 0xf6, 0x93, 0x4d, 0x39,
+	0x91, 0x05, 0xaa, 0x45, 0xa9, 0x92, 0x1e, 0x76, 0xf0, 0xaf, 0x59, 0xe1, 0x06, 0x42, 0xc4, 0xc1, 0x57, 0x41, 0xcc, 0x9e, 0x11, 0xe0, 0xe6, 0x8b, 0xfc, 0xef, 0x31, 0x2e, 0xe4, 0x3c, 0xe8, 0x14,
+	0x55, 0xda, 0xc6, 0x07, 0xb9, 0x22, 0xf6, 0xc9, 0x8a, 0x37, 0x86, 0x47, 0xa1, 0xb3, 0xd7, 0x2f, 0x99, 0x59, 0xae, 0x02, 0x18, 0x2e, 0xef, 0x59, 0x50, 0xa3, 0xe2, 0xab, 0x9d, 0x91, 0x95, 0x34,
+	0x41, 0xdf, 0x38, 0x32, 0x01, 0x14, 0x8d, 0x09, 0xad, 0x5b, 0xc8, 0x71, 0x52, 0x4f, 0xf4, 0x5c, 0x17, 0xa2, 0x65, 0x18, 0x7e, 0x0d, 0xc0, 0x97, 0xcd, 0x26, 0xc5, 0x96, 0x93, 0xdf, 0x88, 0x5e,
+	0x19, 0x40, 0x5c, 0x46, 0x5a, 0xca, 0xab, 0x7f, 0xb4, 0x0b, 0x5b, 0x71, 0xd7, 0x20, 0x3c, 0x53, 0x41, 0x8e, 0xbd, 0x57, 0xed, 0x74, 0x6b, 0x9f, 0x7b, 0x1d, 0x08, 0x8a, 0x6e, 0x66, 0x25, 0x09,
+	0x56, 0x5f, 0xbb, 0x5e, 0x5a, 0x60, 0x71, 0x0e, 0x94, 0x35, 0x5b, 0x6b, 0xf2, 0x05, 0xa3, 0x6b, 0x00, 0xb1, 0x34, 0x19, 0x55, 0xef, 0xf9, 0xaa, 0xaf, 0xd1, 0xe5, 0xf2, 0x5f, 0x4e, 0x2e, 0x33,
+	0x76, 0x35, 0xa4, 0x9a, 0x3c, 0x1c, 0x18, 0x87, 0xc3, 0x00, 0x1f, 0x3d, 0xc1, 0x32, 0x69, 0x65, 0x84, 0xa9, 0xac, 0xab, 0x46, 0x10, 0xbb, 0xf9, 0xdd, 0x90, 0x1f, 0xe8, 0x30, 0xe7, 0x62, 0x34,
+	0x69, 0x5a, 0x35, 0x32, 0x12, 0x7e, 0x5a, 0xde, 0x5e, 0x16, 0xb4, 0x50, 0x63, 0x98, 0x26, 0x5e, 0x22, 0x41, 0x55, 0x4f, 0x69, 0xbf, 0x1b, 0xe5, 0x55, 0x9d, 0x26, 0xfa, 0x01, 0xd1, 0xba, 0x65,
+	0x02, 0x75, 0x12, 0x6d, 0x09, 0x04, 0x83, 0x54, 0x29, 0xf5, 0x3a, 0x3b, 0xe3, 0xa7, 0x0d, 0x6f, 0x07, 0x63, 0x2c, 0x4c, 0x0d, 0x3b, 0x2d, 0x93, 0xe6, 0xcd, 0x19, 0x19, 0x02, 0xec, 0x66, 0x6e,
+	0x94, 0x7b, 0x9f, 0xe2, 0xbe, 0xc1, 0xdf, 0x7d, 0x33, 0xc3, 0x9f, 0x92, 0x69, 0xbb, 0xa8, 0xfa, 0xd4, 0xfd, 0x45, 0x7d, 0x6d, 0x8d, 0x3b, 0xd4, 0x50, 0xda, 0xc5, 0x1e, 0x94, 0x09, 0xc6, 0x04,
+	0x3d, 0xe4, 0x59, 0x2a, 0xe8, 0xcc, 0x81, 0xfa, 0xd0, 0xcf, 0x2e, 0x0a, 0x31, 0x18, 0xeb, 0x13, 0x4a, 0x09, 0x8e, 0x53, 0x4c, 0x30, 0x0d, 0xee, 0x16, 0xa4, 0xb1, 0x0e, 0x06, 0x12, 0x7f, 0x12,
+	0x99, 0xa3, 0xc2, 0xe9, 0x3c, 0x12, 0xf8, 0xa3, 0x2e, 0x5d, 0xf9, 0x3e, 0x85, 0x58, 0xfd, 0xf6, 0x72, 0xa1, 0x51, 0x8e, 0x95, 0xc6, 0x22, 0x90, 0xf1, 0x37, 0x34, 0xa3, 0x06, 0x78, 0x24, 0x0e,
+	0x7b, 0x5b, 0x2d, 0xb1, 0xfb, 0x23, 0x60, 0x7d, 0x2c, 0x76, 0x2e, 0xb6, 0xd5, 0x6a, 0x35, 0x61, 0x15, 0x47, 0x53, 0xbd, 0xd9, 0xaf, 0xc3, 0xfd, 0x08, 0xea, 0x1c, 0xf1, 0x4a, 0x56, 0x61, 0x03,
+	0x8b, 0x60, 0x65, 0xa8, 0xc9, 0x35, 0x08, 0x33, 0xd3, 0x9c, 0xcc, 0xdf, 0x5b, 0x7a, 0x8e, 0x6d, 0xe5, 0xa8, 0xf8, 0x5d, 0x3d, 0xa2, 0x56, 0x79, 0x57, 0x52, 0x2d, 0x2d, 0x8c, 0x93, 0x12, 0x6e,
+	0xdd, 0x8e, 0x88, 0xb8, 0x98, 0xcf, 0x3a, 0x6c, 0x25, 0x6f, 0x68, 0x1e, 0x48, 0x37, 0x21, 0x69, 0xc6, 0x28, 0x33, 0x65, 0x4d, 0x50, 0x22, 0x33, 0x32, 0x05, 0x41, 0xca, 0x8f, 0x5d, 0xc6, 0x02,
+	0xfc, 0x14, 0x75, 0xdf, 0x55, 0x62, 0x18, 0xa9, 0x3d, 0xa2, 0x22, 0x55, 0x37, 0xe8, 0x25, 0x15, 0xbf, 0xda, 0x78, 0x46, 0x02, 0x40, 0xd9, 0x65, 0x70, 0x05, 0x30, 0xb9, 0xf2, 0x51, 0x74, 0x21,
+	0x4b, 0x04, 0x2b, 0xa1, 0x8b, 0xc7, 0x8c, 0x9b, 0x70, 0xb4, 0x0f, 0x54, 0x65, 0x3b, 0xef, 0xf8, 0xec, 0x9b, 0x08, 0xbc, 0xc7, 0x2c, 0x63, 0xd3, 0xc7, 0x67, 0x47, 0x99, 0xc8, 0xdd, 0x2d, 0x0b,
+	0x3f, 0xff, 0x0f, 0xc9, 0xf0, 0xc2, 0x16, 0xf5, 0x34, 0x77, 0x82, 0xbc, 0x70, 0x04, 0x04, 0xc1, 0x25, 0xfb, 0xf8, 0x2b, 0xe6, 0x62, 0xcd, 0x86, 0x58, 0x55, 0x52, 0xa2, 0x6d, 0x12, 0x31, 0x53,
+	0x56, 0x8c, 0x7d, 0x4b, 0x03, 0x6b, 0x0f, 0x7e, 0xe2, 0x2d, 0xcb, 0x2a, 0x0d, 0x90, 0x29, 0x27, 0xb0, 0xc1, 0x43, 0xdc, 0x0a, 0x58, 0xe8, 0x08, 0x1f, 0x95, 0x91, 0x60, 0x1c, 0x58, 0x7a, 0x31,
+	0xa9, 0x3b, 0x89, 0xc0, 0xbc, 0xa8, 0x39, 0x97, 0x31, 0x3c, 0x8d, 0x78, 0xc0, 0xe9, 0xa3, 0x5e, 0x86, 0x37, 0x70, 0x66, 0xac, 0xd8, 0xed, 0x90, 0xbc, 0xd5, 0xb1, 0x3e, 0x99, 0x41, 0x67, 0x6c,
+	0xb2, 0xf3, 0xf4, 0xc9, 0x46, 0x9a, 0xba, 0xfc, 0x48, 0x7f, 0xbe, 0x06, 0x53, 0x44, 0x39, 0xbc, 0x31, 0x5b, 0x31, 0x17, 0x38, 0xc0, 0x79, 0xc2, 0x45, 0x81, 0xfa, 0x89, 0x84, 0x0b, 0x42, 0x54,
+	0x06, 0xd6, 0x1d, 0xd2, 0xf7, 0x49, 0x10, 0x75, 0x60, 0xe0, 0xaa, 0xa3, 0x99, 0xf8, 0x1b, 0x81, 0xfb, 0x4a, 0x1e, 0xf6, 0xe9, 0xaa, 0x7c, 0x68, 0x44, 0x5f, 0xb2, 0xbb, 0x76, 0xda, 0x59, 0x20,
+	0x70, 0x35, 0x57, 0x6d, 0x68, 0xa1, 0x70, 0xac, 0x2b, 0x0a, 0x5a, 0x38, 0x27, 0xff, 0x12, 0x85, 0x44, 0x57, 0xec, 0x33, 0xab, 0x42, 0xc7, 0x76, 0x1e, 0xfe, 0xc8, 0x2e, 0x45, 0xab, 0x30, 0x6a,
+	0xa1, 0x02, 0xec, 0xef, 0x26, 0xf3, 0x98, 0x4a, 0xba, 0xf0, 0xd3, 0xa7, 0xae, 0x6c, 0x78, 0x74, 0xe3, 0xbd, 0x8d, 0x9b, 0x4a, 0xf0, 0x81, 0x74, 0xaa, 0x15, 0xc8, 0x7e, 0xb6, 0xc3, 0x16, 0x54,
+	0x4b, 0x3f, 0xab, 0x5f, 0xe7, 0xa3, 0x46, 0x43, 0x9c, 0x13, 0x69, 0x4b, 0x64, 0xc3, 0xc5, 0xcc, 0x08, 0xb0, 0x09, 0xec, 0xac, 0x3b, 0xcc, 0xf2, 0xf6, 0xfd, 0xf4, 0x1d, 0xc0, 0xa6, 0x32, 0x4b,
+	0x9e, 0x59, 0xf0, 0x9e, 0xcc, 0x23, 0xd8, 0x97, 0xc0, 0xa9, 0xfd, 0xc0, 0x95, 0x7d, 0x8b, 0xbd, 0xae, 0x62, 0xb8, 0xb5, 0x43, 0x0b, 0x56, 0x97, 0xaf, 0x6b, 0x3a, 0xa1, 0x72, 0x15, 0xe9, 0x02,
+	0x7b, 0x28, 0xc0, 0xb5, 0xa8, 0x88, 0xa5, 0xcf, 0xe7, 0x9b, 0x3e, 0x37, 0x3d, 0x3b, 0x6a, 0xd3, 0xb1, 0xc8, 0x34, 0x28, 0x32, 0x89, 0xed, 0x96, 0x0d, 0xa7, 0xdf, 0xbb, 0x6b, 0xe2, 0xd4, 0x18,
+	0x65, 0xd1, 0x76, 0xb9, 0x08, 0x26, 0xf4, 0x86, 0x0b, 0x8b, 0xf5, 0x96, 0xca, 0x8e, 0x29, 0xe8, 0x12, 0xe1, 0xfb, 0x5d, 0x12, 0xef, 0xe9, 0x1f, 0x2d, 0xcf, 0x0f, 0xd1, 0x8c, 0x81, 0x3f, 0x3d,
+	0x4f, 0xa2, 0x2d, 0x94, 0x1f, 0x44, 0x0b, 0xf4, 0xe4, 0xd7, 0x51, 0xc5, 0x12, 0xd3, 0x71, 0xe2, 0xc1, 0x19, 0x2e, 0x83, 0x72, 0xdb, 0x7a, 0x06, 0x31, 0x71, 0x29, 0x92, 0x43, 0x07, 0xe7, 0x31,
+	0x81, 0x62, 0xa8, 0x50, 0xd0, 0x05, 0x25, 0xf8, 0x25, 0x45, 0x0a, 0xe2, 0x3a, 0xbb, 0x66, 0x78, 0x7f, 0xa5, 0x79, 0x75, 0xe6, 0x71, 0x41, 0x0d, 0x41, 0x31, 0x20, 0xe5, 0x2d, 0xdf, 0x5d, 0x24,
+	0x36, 0x6a, 0x28, 0xe9, 0x3f, 0x8e, 0x99, 0xba, 0x75, 0xc3, 0xf6, 0x5e, 0xa4, 0x24, 0x3d, 0x38, 0x2e, 0x4b, 0x62, 0x9e, 0x17, 0xf7, 0xe3, 0x6c, 0x26, 0x13, 0xef, 0x2b, 0xcb, 0x73, 0x96, 0x28,
+	0x32, 0xbd, 0xb2, 0xa7, 0x6b, 0x24, 0x07, 0x27, 0x89, 0x99, 0x49, 0x73, 0x1a, 0x71, 0xd0, 0x26, 0xe7, 0x32, 0x0c, 0x28, 0xb0, 0xd9, 0x76, 0x13, 0x94, 0x11, 0x63, 0x0c, 0xba, 0x9f, 0xc5, 0x03,
+	0xb1, 0xca, 0x94, 0x32, 0xa3, 0xa1, 0x22, 0x98, 0x3b, 0x92, 0x7d, 0x2b, 0x50, 0x1f, 0xd6, 0x50, 0x01, 0x4c, 0x65, 0x20, 0xf5, 0x4e, 0x35, 0x0f, 0x0c, 0x1c, 0xb4, 0x1a, 0x62, 0x00, 0x4c, 0x14,
+	0xaf, 0xcb, 0x15, 0xf5, 0x6c, 0xdb, 0xc6, 0x17, 0x73, 0x99, 0x08, 0xc7, 0xec, 0x48, 0x24, 0x07, 0x6d, 0x60, 0xd7, 0x87, 0x03, 0xc3, 0x72, 0xff, 0x64, 0xed, 0xf6, 0x68, 0x55, 0xa9, 0xc6, 0x68,
+	0x3c, 0xed, 0x72, 0x5f, 0x59, 0x45, 0x83, 0x35, 0x6e, 0x29, 0x3a, 0x1d, 0x93, 0x00, 0xff, 0x35, 0xd6, 0xa3, 0x6f, 0x28, 0xc9, 0xa8, 0xa0, 0x50, 0xf3, 0x61, 0x74, 0xdc, 0x52, 0xe1, 0x2f, 0x66,
+	0x21, 0x43, 0xe5, 0x73, 0xd1, 0x86, 0xde, 0x23, 0x58, 0xb8, 0x97, 0x62, 0x11, 0xcd, 0x1c, 0x8d, 0x1a, 0x57, 0x2f, 0x0a, 0xdc, 0x70, 0x92, 0x7e, 0x8a, 0x05, 0xd7, 0xad, 0x3a, 0x04, 0x7b, 0x21,
+	0x38, 0x4d, 0x97, 0xd4, 0xdf, 0x99, 0x59, 0xfb, 0xd1, 0xf8, 0xb0, 0xe9, 0x38, 0x54, 0x07, 0xf1, 0xf8, 0x32, 0x64, 0xa3, 0x6e, 0xb3, 0x5d, 0x79, 0xa4, 0xe3, 0x91, 0x76, 0x3e, 0x30, 0xd4, 0x68,
+	0x10, 0xd2, 0x34, 0xe2, 0xf3, 0x54, 0x0a, 0x79, 0x01, 0x5a, 0x30, 0xda, 0x7d, 0xd7, 0x92, 0x57, 0xce, 0x6f, 0x8f, 0xcd, 0x07, 0x41, 0xb8, 0x66, 0xf0, 0xc4, 0xe4, 0xa5, 0x5e, 0x11, 0x26, 0x1e,
+	0x53, 0x77, 0xa6, 0x63, 0xc5, 0xb4, 0x9a, 0xe2, 0xcf, 0xea, 0xf9, 0x1d, 0xf2, 0x27, 0x05, 0xde, 0x75, 0xad, 0xb1, 0x43, 0x98, 0x60, 0xc3, 0x79, 0xcc, 0x0c, 0xf0, 0x27, 0x81, 0xd5, 0x1b, 0x6e,
+	0x92, 0x4c, 0x95, 0x10, 0x2b, 0xfa, 0x3e, 0xc8, 0x51, 0x58, 0xfe, 0x26, 0x2b, 0x70, 0x18, 0x1c, 0x6d, 0x2a, 0xa6, 0x9f, 0x5b, 0x77, 0x70, 0x1f, 0x11, 0x75, 0xfd, 0x66, 0x39, 0xad, 0x4a, 0x5a,
+	0xda, 0xa7, 0x2a, 0xfc, 0x18, 0x10, 0x40, 0xe2, 0x1e, 0x30, 0x70, 0x73, 0x93, 0x0d, 0x8f, 0x00, 0x80, 0x99, 0xb2, 0x17, 0x70, 0x24, 0xad, 0xac, 0xbe, 0x33, 0x15, 0xec, 0xfa, 0x59, 0xc0, 0x71,
+	0x96, 0xe8, 0x44, 0x20, 0x01, 0x82, 0xdf, 0xd5, 0xc6, 0x71, 0x07, 0xb5, 0x62, 0x59, 0x85, 0x9d, 0x1d, 0xd8, 0x48, 0x3d, 0xec, 0xba, 0x33, 0x32, 0x60, 0xd4, 0x40, 0xf5, 0x82, 0x5e, 0x62, 0x2b,
+	0x8b, 0x26, 0x11, 0xb5, 0x62, 0x1c, 0x88, 0x95, 0xeb, 0xed, 0x1a, 0x6f, 0x33, 0xe6, 0x96, 0x66, 0x64, 0x9c, 0x73, 0x3c, 0xff, 0x62, 0xe2, 0x26, 0x16, 0xcd, 0x21, 0xd9, 0x67, 0x2b, 0xe2, 0x47,
+	0x4e, 0x83, 0x49, 0x14, 0x0d, 0x20, 0xb8, 0x26, 0x91, 0x2c, 0x05, 0x00, 0x3c, 0xdc, 0x76, 0xdd, 0x5f, 0xb2, 0xb1, 0x83, 0x3e, 0xa5, 0x01, 0xd1, 0xa9, 0xa4, 0x3a, 0x6b, 0xb9, 0x09, 0x65, 0x28,
+	0x35, 0x61, 0x61, 0xb2, 0x1d, 0x21, 0x0d, 0x83, 0x46, 0x7f, 0x7f, 0x2e, 0x68, 0x89, 0xc0, 0x65, 0x16, 0xa6, 0xe8, 0x38, 0x4b, 0xad, 0x9e, 0x38, 0x38, 0x3a, 0x5e, 0x61, 0x9f, 0xb1, 0x6b, 0x47,
+	0x92, 0xa1, 0x99, 0xce, 0x2d, 0x2b, 0x7d, 0xf6, 0x36, 0x0d, 0x3a, 0xbb, 0x43, 0x68, 0x73, 0x0b, 0x10, 0xc5, 0xdb, 0x93, 0x24, 0x0f, 0xe8, 0x81, 0xed, 0x28, 0x16, 0x6c, 0x03, 0xf1, 0xa0, 0x67,
+	0x7c, 0xe0, 0x81, 0x83, 0x3c, 0xf7, 0x98, 0x16, 0x96, 0x1b, 0xa1, 0x75, 0xe7, 0xf7, 0xb3, 0xdd, 0x94, 0xb8, 0x8a, 0xd8, 0x3a, 0x5a, 0xc4, 0x8c, 0xb9, 0x1b, 0x3f, 0xce, 0xdc, 0xee, 0x56, 0x09,
+	0xbf, 0xe7, 0x7e, 0x63, 0x85, 0xd0, 0x02, 0x59, 0x4d, 0x7d, 0x18, 0xe7, 0x3e, 0xf1, 0xe7, 0x7b, 0xd0, 0x51, 0x83, 0x95, 0x89, 0x43, 0xff, 0xcf, 0x47, 0xa3, 0x7b, 0x13, 0x15, 0x85, 0xb4, 0x63,
+	0xc2, 0xc6, 0x7e, 0xa7, 0x00, 0x6c, 0xb4, 0xe4, 0xa9, 0xac, 0x62, 0x55, 0xf7, 0x81, 0xf4, 0xa4, 0x68, 0xc2, 0x5f, 0x46, 0xc1, 0xad, 0x81, 0x5b, 0x18, 0xfa, 0x6d, 0x60, 0x69, 0x58, 0xf3, 0x3f,
+	0x86, 0xaa, 0xcc, 0x5b, 0x51, 0x65, 0x21, 0xbd, 0x85, 0x10, 0xd7, 0x1f, 0xf3, 0xac, 0x16, 0x29, 0xa5, 0xc6, 0x18, 0x30, 0xb8, 0xeb, 0xdd, 0x05, 0x63, 0x76, 0xbd, 0x05, 0xbf, 0x58, 0x95, 0x72,
+	0xb3, 0x61, 0x0c, 0x14, 0x1e, 0x76, 0x35, 0x79, 0xa6, 0xfc, 0x33, 0xa7, 0x5c, 0x23, 0x7e, 0x05, 0xec, 0x7c, 0xb4, 0xfb, 0xb4, 0x8b, 0x2b, 0x1a, 0x24, 0xea, 0xee, 0x6b, 0x38, 0xce, 0x7f, 0x55,
+	0x45, 0x0f, 0xdf, 0x40, 0x45, 0x4c, 0x92, 0xd2, 0xfd, 0x6a, 0x29, 0x3b, 0x00, 0x4c, 0xc1, 0xb8, 0xaf, 0x5b, 0xc5, 0xe7, 0xb8, 0x26, 0x90, 0x64, 0xf4, 0x12, 0x39, 0x79, 0x6d, 0xaf, 0xf5, 0x07,
+	0xfb, 0xf2, 0x2a, 0x4f, 0x9b, 0x25, 0x00, 0x88, 0x8f, 0x63, 0x66, 0x14, 0x2c, 0x7f, 0x8a, 0xc4, 0x47, 0xaf, 0xe7, 0x9e, 0x04, 0x80, 0x20, 0x84, 0x66, 0xf8, 0x88, 0xd9, 0xf5, 0x79, 0xae, 0x34,
+	0x98, 0x46, 0x1d, 0x4f, 0x53, 0x06, 0xd8, 0xb8, 0x40, 0x44, 0x08, 0x4f, 0x33, 0x6f, 0x17, 0x3d, 0x67, 0xf2, 0x68, 0xbb, 0x60, 0x98, 0x46, 0x31, 0xac, 0xae, 0xb4, 0xae, 0x50, 0xfd, 0xff, 0x27,
+	0x1f, 0xd8, 0xea, 0xf1, 0x60, 0xd2, 0x33, 0x03, 0x48, 0x88, 0x29, 0x54, 0xcf, 0xb1, 0xdf, 0x20, 0x38, 0x66, 0x60, 0xb4, 0x15, 0x1e, 0xdc, 0xd3, 0xd7, 0x6f, 0x46, 0xe1, 0x7e, 0x07, 0x4f, 0x12,
+	0x9f, 0x35, 0x3e, 0x31, 0x20, 0xbc, 0x1c, 0xfa, 0xbe, 0xb2, 0x30, 0xc0, 0x00, 0xc4, 0x73, 0xad, 0x89, 0xa4, 0xe4, 0xe7, 0x7e, 0xee, 0x3a, 0x08, 0x56, 0x3a, 0x3b, 0x91, 0x35, 0x6f, 0xad, 0x12,
+	0xa0, 0x6d, 0x20, 0xf0, 0xd6, 0x9b, 0x26, 0x52, 0x12, 0x61, 0x43, 0x64, 0x44, 0x7b, 0x7d, 0xc8, 0xe0, 0x29, 0x81, 0xd3, 0x6c, 0x37, 0x5a, 0xf0, 0xeb, 0x4d, 0x8f, 0x0b, 0x3a, 0xad, 0xef, 0x3b,
+	0x3d, 0x34, 0xfd, 0xbf, 0xe0, 0x7b, 0xd6, 0x1e, 0x66, 0x5a, 0x97, 0xd5, 0x3c, 0xf6, 0xd7, 0xee, 0x3f, 0x84, 0xd7, 0x91, 0xf7, 0x08, 0x54, 0xc3, 0x5d, 0x7c, 0xb8, 0xe0, 0x21, 0x47, 0x97, 0x60,
+	0x1c, 0xbc, 0x4a, 0xeb, 0x61, 0xa2, 0x31, 0x18, 0xdf, 0x4b, 0xbe, 0x37, 0x16, 0x73, 0x96, 0x80, 0xd1, 0x03, 0xd1, 0x42, 0x31, 0xa0, 0x5d, 0xba, 0x1d, 0x98, 0xb9, 0xff, 0x87, 0x08, 0xb0, 0x4d,
+	0xcb, 0x0d, 0x02, 0x1c, 0xc9, 0x3b, 0x0c, 0x24, 0x70, 0x77, 0x3d, 0x1a, 0x57, 0xdb, 0xc4, 0xbb, 0x1b, 0x70, 0x05, 0x1d, 0x77, 0xd5, 0x19, 0x47, 0xc1, 0x46, 0x3a, 0x44, 0x4e, 0xca, 0x49, 0x34,
+	0x6f, 0x55, 0x44, 0x84, 0x4c, 0x04, 0x20, 0x71, 0xea, 0x26, 0x38, 0x61, 0x7f, 0x80, 0xcf, 0xed, 0xe3, 0xd5, 0x95, 0x7b, 0x56, 0x73, 0x13, 0x5f, 0x4e, 0x33, 0x3f, 0xf7, 0x09, 0xe7, 0xe2, 0x10,
+	0x49, 0x8a, 0xe5, 0x9e, 0x53, 0xb8, 0xfd, 0x08, 0xe0, 0xcd, 0x46, 0x5c, 0x29, 0x2a, 0xe5, 0x0b, 0x19, 0x12, 0x42, 0xc7, 0x4a, 0xf2, 0x88, 0xb3, 0xa0, 0xc8, 0x16, 0x71, 0xc6, 0x67, 0xd7, 0x05,
+	0x5d, 0x03, 0xbc, 0xa2, 0x63, 0x78, 0x72, 0x95, 0xcc, 0xf3, 0x3f, 0x1d, 0xbc, 0x25, 0xb5, 0xc6, 0x84, 0xb6, 0xa1, 0x9d, 0x47, 0xcc, 0xec, 0x92, 0x18, 0x42, 0xf6, 0xf5, 0x15, 0x61, 0x61, 0x24,
+	0x12, 0xc3, 0x6d, 0xfa, 0xcd, 0xbc, 0x86, 0xe0, 0x2e, 0xc3, 0x04, 0xcb, 0xc4, 0x5d, 0xf1, 0x9d, 0x21, 0x73, 0xa2, 0x30, 0x49, 0xe4, 0x02, 0x3e, 0xcf, 0x25, 0xf4, 0x80, 0x3d, 0x97, 0xf3, 0x01,
+	0xe0, 0x53, 0x63, 0xb1, 0x2d, 0x92, 0x6a, 0xfb, 0x41, 0x01, 0x6c, 0x69, 0xee, 0xe9, 0x14, 0x81, 0xa6, 0x64, 0x8e, 0x7d, 0x7f, 0xc9, 0x60, 0x75, 0xde, 0x72, 0xef, 0x90, 0xeb, 0x00, 0x89, 0x33,
+	0xc1, 0x7f, 0x38, 0xc3, 0xfe, 0x65, 0xf1, 0xba, 0x7e, 0x51, 0x9d, 0x7c, 0x7a, 0x5e, 0xb9, 0x8e, 0xcb, 0xb4, 0x8c, 0x04, 0xcf, 0xe9, 0x60, 0xad, 0x2c, 0x8b, 0xce, 0x8e, 0x00, 0x21, 0xa6, 0x08,
+	0xa9, 0x82, 0x1a, 0xa7, 0x89, 0xe7, 0xe9, 0x28, 0xe6, 0xc5, 0xa3, 0xff, 0x59, 0x8b, 0xd0, 0xbc, 0x8e, 0x47, 0x2a, 0xd2, 0x2f, 0xe4, 0x58, 0x97, 0xbd, 0x8a, 0x72, 0x0d, 0xa8, 0x72, 0x34, 0x3b,
+	0x12, 0x18, 0xfc, 0x1c, 0x24, 0x74, 0xc7, 0x34, 0xba, 0x7b, 0x43, 0xcc, 0x34, 0x15, 0x61, 0x9f, 0x82, 0x12, 0x3e, 0xa9, 0x24, 0xdf, 0xaf, 0x56, 0xc2, 0xca, 0xdd, 0x8d, 0xc5, 0x48, 0x78, 0x16,
+	0x7d, 0xb7, 0x4d, 0xb7, 0xb0, 0x36, 0xb1, 0xb4, 0x2c, 0x1b, 0x02, 0xdc, 0x2c, 0x32, 0xbb, 0x61, 0x37, 0x72, 0x33, 0x2e, 0x57, 0x6a, 0x2f, 0x26, 0x2f, 0x8f, 0x9e, 0x2f, 0x47, 0x63, 0xc2, 0x18,
+	0x69, 0xb6, 0x47, 0x53, 0xb5, 0x4e, 0x44, 0x6c, 0xa4, 0x15, 0x23, 0x98, 0x1d, 0x03, 0x51, 0x69, 0x0b, 0x4b, 0x62, 0x1c, 0xb6, 0x62, 0x2e, 0xc5, 0x7e, 0x3d, 0x8e, 0xf6, 0x0d, 0xa0, 0x54, 0x1a,
+	0x32, 0x62, 0xe1, 0x3e, 0x91, 0xac, 0x5c, 0x15, 0x47, 0x74, 0x81, 0xf4, 0x3a, 0x55, 0xc5, 0xf3, 0x4b, 0xc6, 0x7b, 0x03, 0x6c, 0x32, 0x3c, 0x3d, 0x0d, 0x08, 0x92, 0xf0, 0x3f, 0xe7, 0x23, 0x03,
+	0xb4, 0xc9, 0x82, 0x43, 0x04, 0xf8, 0x48, 0x58, 0x5a, 0x6a, 0x4b, 0x04, 0xc8, 0x91, 0x58, 0x75, 0xe1, 0x7c, 0xac, 0x0c, 0x6c, 0xbd, 0x83, 0x24, 0x4e, 0x21, 0x36, 0x86, 0x91, 0xf8, 0x16, 0x4b,
+	0xd8, 0xab, 0xac, 0x81, 0xe3, 0xca, 0xa7, 0xcc, 0x69, 0xd2, 0x88, 0x9f, 0xc3, 0x25, 0x12, 0x2b, 0x14, 0x15, 0x2c, 0x75, 0x5b, 0xd9, 0x6d, 0xc3, 0x86, 0x63, 0x41, 0x81, 0x7e, 0x1b, 0xda, 0x55,
+	0x16, 0xeb, 0x64, 0x2b, 0xb0, 0xa8, 0x43, 0x6c, 0x16, 0xc2, 0x9c, 0x4a, 0x88, 0xaf, 0x00, 0x38, 0x2e, 0x86, 0x2b, 0xd9, 0x9a, 0xf6, 0x26, 0x28, 0x79, 0xc1, 0x41, 0x98, 0xf1, 0xbb, 0xbf, 0x0d,
+	0x0a, 0x53, 0x5b, 0xc6, 0x9c, 0x0a, 0x84, 0x8b, 0xe5, 0xde, 0x8b, 0x48, 0x4d, 0x92, 0x60, 0x03, 0xe2, 0x03, 0x1e, 0x10, 0x76, 0x57, 0x4d, 0xe1, 0x0e, 0x6b, 0x05, 0x73, 0x19, 0x21, 0xaf, 0x37,
+	0xeb, 0x8c, 0x8d, 0xdd, 0x8b, 0x2b, 0xe5, 0xe4, 0xf8, 0x83, 0x9b, 0xb9, 0x4e, 0xeb, 0xf6, 0x5f, 0x32, 0x59, 0xad, 0x5e, 0x9b, 0xd2, 0x9b, 0x52, 0xc0, 0xab, 0xba, 0x0b, 0x48, 0xcf, 0xa2, 0x33,
+	0xcb, 0x1f, 0x29, 0x54, 0x5c, 0xe2, 0xc6, 0x4c, 0x52, 0xbf, 0x4c, 0xd1, 0xc7, 0xcb, 0xe1, 0x7e, 0xc8, 0xd0, 0xf1, 0x40, 0x04, 0xc9, 0x3b, 0x9e, 0xe9, 0x59, 0x45, 0xd6, 0x17, 0x51, 0xfe, 0x10,
+	0x49, 0xee, 0x4c, 0x44, 0xf1, 0x9c, 0x19, 0xfb, 0x07, 0xad, 0x57, 0xe1, 0xf8, 0xab, 0x76, 0x5d, 0x75, 0xf0, 0x86, 0x35, 0xff, 0x9e, 0x88, 0x7a, 0x72, 0x0a, 0xaa, 0xcd, 0x45, 0x81, 0x40, 0x71,
+	0xe1, 0x1d, 0x42, 0x4d, 0xfb, 0x53, 0x9d, 0x67, 0xa6, 0x24, 0xe7, 0x4c, 0xe2, 0xe9, 0x52, 0xa5, 0x39, 0x6f, 0xa9, 0x3c, 0xcd, 0x1b, 0xcd, 0x65, 0xe8, 0x16, 0x3b, 0x13, 0x92, 0x58, 0x5f, 0x30,
+	0x4d, 0x8f, 0x53, 0x13, 0xd4, 0x39, 0xc7, 0xa5, 0xcd, 0xe8, 0x4c, 0xed, 0x64, 0xa3, 0x67, 0x57, 0x97, 0xc4, 0x09, 0x6d, 0x02, 0x65, 0x97, 0xbd, 0x10, 0xa4, 0x22, 0xab, 0x49, 0xc2, 0x92, 0x37,
+	0x22, 0x9c, 0x03, 0x96, 0xef, 0x68, 0xbf, 0xf8, 0x76, 0x2e, 0xa3, 0x24, 0xde, 0x2a, 0xb2, 0x11, 0xcc, 0xed, 0xad, 0x30, 0xa0, 0x92, 0x25, 0xf6, 0xee, 0x77, 0x45, 0x8e, 0xb1, 0x1f, 0x05, 0x54,
+	0x24, 0xf5, 0xd0, 0x73, 0xa1, 0x52, 0xb7, 0xe3, 0xe5, 0x11, 0xd3, 0x96, 0x54, 0xde, 0x88, 0x39, 0x3c, 0x32, 0x1e, 0xe6, 0x1e, 0x46, 0x2d, 0x72, 0x65, 0x48, 0xd1, 0x22, 0x83, 0x91, 0x62, 0x35,
+	0xee, 0xc8, 0x9c, 0x8b, 0x56, 0xa1, 0x55, 0xa4, 0x9f, 0xee, 0x21, 0x70, 0xaa, 0x66, 0xd4, 0xfe, 0x6c, 0x59, 0x77, 0x19, 0xcb, 0x2a, 0x30, 0x08, 0x72, 0xf7, 0x26, 0x8e, 0x08, 0xed, 0x66, 0x49,
+	0x8b, 0x71, 0x33, 0x6e, 0x9d, 0xdb, 0x6e, 0x47, 0xa3, 0xc2, 0x20, 0xc2, 0x0d, 0xb0, 0x12, 0x1a, 0x6f, 0x22, 0xf1, 0x39, 0x52, 0x52, 0xc3, 0xa4, 0xc9, 0x15, 0x00, 0xd6, 0x7a, 0xe5, 0x17, 0x02,
+	0x1c, 0xad, 0xad, 0x8d, 0xe2, 0x5d, 0x9d, 0x44, 0x83, 0x09, 0xff, 0x68, 0x0f, 0x08, 0xbe, 0xc3, 0x54, 0xca, 0xc4, 0x48, 0xd7, 0x90, 0x14, 0x0b, 0x08, 0xdd, 0xe6, 0x34, 0xc7, 0x67, 0x5f, 0x06,
+	0x64, 0x22, 0x3a, 0x7e, 0x3e, 0xfe, 0x78, 0xb3, 0x49, 0x4b, 0xf4, 0x87, 0xbb, 0x01, 0x93, 0xf1, 0x5e, 0x5c, 0x1e, 0x7c, 0x1b, 0xd4, 0x8a, 0x47, 0xe1, 0xf8, 0xf2, 0xd2, 0x8f, 0xcc, 0xb4, 0x3d,
+	0xc6, 0xaf, 0x94, 0xd1, 0x5a, 0x48, 0x57, 0xdf, 0xf6, 0x8f, 0x6d, 0xe8, 0x70, 0x3f, 0x23, 0x95, 0xd8, 0x3a, 0x51, 0x77, 0xcc, 0x98, 0xbc, 0x32, 0x0c, 0x4b, 0x03, 0x17, 0xcb, 0xa8, 0x61, 0x32,
+}
+
+// Modulus returns the order of the BLS12-381 scalar field.
+func Modulus() int {
+	return convert.ToInteger(mimcFieldOrder)
+}
+
+// Hash returns the MiMC hash of the elements like blsMimc writing one element
+// per block. Elements must not be negative, they are reduced modulo the field
+// order first.
+func Hash(elements []int) int {
+	r := Modulus()
+	h := 0
+	for _, m := range elements {
+		h = mimcCompress(h, m%r, r)
+	}
+	return h
+}
+
+// HashPair returns the hash of two elements, the node hash of MiMC Merkle
+// trees.
+func HashPair(left, right int) int {
+	return Hash([]int{left, right})
+}
+
+// mimcCompress absorbs the element into the running hash h as
+// h + m + encrypt(m, h) in the Miyaguchi-Preneel mode of gnark.
+func mimcCompress(h, m, r int) int {
+	return mimcAddMod(mimcAddMod(mimcEncrypt(m, h, r), h, r), m, r)
+}
+
+// mimcEncrypt runs the rounds m = (m + key + c)^5 and adds the key. The power
+// is three MODMUL, they cost less GAS than one MODPOW.
+func mimcEncrypt(m, key, r int) int {
+	for i := 0; i < mimcRounds; i++ {
+		c := convert.ToInteger(mimcRoundConstants[32*i : 32*i+32])
+		t := mimcAddMod(mimcAddMod(m, key, r), c, r)
+		t2 := math.ModMul(t, t, r)
+		t4 := math.ModMul(t2, t2, r)
+		m = math.ModMul(t4, t, r)
+	}
+	return mimcAddMod(m, key, r)
+}
+
+// mimcAddMod returns a + b modulo r for reduced a and b. The sum itself may
+// exceed the 256 bit NeoVM integers, the order is above 2^254.
+func mimcAddMod(a, b, r int) int {
+	if a >= r-b {
+		return a - (r - b)
+	}
+	return a + b
+}
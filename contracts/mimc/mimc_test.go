@@ -0,0 +1,92 @@
+package mimc
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"neo_zk_starter/internal/util"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	blsMimc "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/mimc"
+	"github.com/nspcc-dev/neo-go/pkg/neotest"
+	"github.com/nspcc-dev/neo-go/pkg/neotest/chain"
+)
+
+// littleEndian returns the 32 byte little endian encoding of x.
+func littleEndian(x *big.Int) []byte {
+	b := x.FillBytes(make([]byte, 32))
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
+
+func TestConstants(t *testing.T) {
+	if got := littleEndian(fr.Modulus()); string(got) != string(mimcFieldOrder) {
+		t.Fatal("modulus is not the BLS12-381 scalar field order")
+	}
+
+	expected := blsMimc.GetConstants()
+	if len(expected) != mimcRounds || len(mimcRoundConstants) != 32*mimcRounds {
+		t.Fatalf("expected %d constants, got %d", len(expected), len(mimcRoundConstants)/32)
+	}
+	for i := range expected {
+		if string(littleEndian(&expected[i])) != string(mimcRoundConstants[32*i:32*i+32]) {
+			t.Fatalf("round constant %d differs from gnark-crypto", i)
+		}
+	}
+}
+
+// TestContract compares the hashes of the contract library with
+// util.HashInputsToString and logs the GAS they cost.
+func TestContract(t *testing.T) {
+	bc, committee := chain.NewSingle(t)
+	e := neotest.NewExecutor(t, bc, committee, committee)
+	c := neotest.CompileFile(t, e.Validator.ScriptHash(), "testdata/hasher.go", "testdata/hasher.yml")
+	e.DeployContract(t, c, nil)
+	inv := e.CommitteeInvoker(c.Hash)
+
+	r := fr.Modulus()
+	rMinus1 := new(big.Int).Sub(r, big.NewInt(1))
+	rnd := rand.New(rand.NewSource(1))
+	random := func() *big.Int {
+		return new(big.Int).Rand(rnd, r)
+	}
+
+	cases := [][]interface{}{
+		{},
+		{uint64(0)},
+		{uint64(42)},
+		{rMinus1},
+		{new(big.Int).Add(r, big.NewInt(5))}, // reduced like fr.Element.SetBigInt
+		{uint64(1), uint64(2)},
+		{random(), random()},
+		{random(), random(), random(), random()},
+	}
+	for _, inputs := range cases {
+		expected := util.StringToBigInt(util.HashInputsToString(inputs), 10)
+
+		args := make([]any, len(inputs))
+		for i, in := range inputs {
+			switch v := in.(type) {
+			case uint64:
+				args[i] = new(big.Int).SetUint64(v)
+			case *big.Int:
+				args[i] = v
+			}
+		}
+		h := inv.Invoke(t, expected, "hash", args)
+		t.Logf("%d elements: %s GAS", len(inputs), formatGAS(e.GetTxExecResult(t, h).GasConsumed))
+	}
+
+	left, right := random(), random()
+	expected := util.StringToBigInt(util.HashInputsToString([]interface{}{left, right}), 10)
+	h := inv.Invoke(t, expected, "hashPair", left, right)
+	t.Logf("hashPair: %s GAS", formatGAS(e.GetTxExecResult(t, h).GasConsumed))
+}
+
+// formatGAS formats an amount of the 8 decimal GAS.
+func formatGAS(amount int64) string {
+	return new(big.Rat).SetFrac64(amount, 100_000_000).FloatString(8)
+}
@@ -0,0 +1,14 @@
+// Package hasher exposes the mimc library for the differential tests.
+package hasher
+
+import "neo_zk_starter/contracts/mimc"
+
+// Hash returns the MiMC hash of the elements.
+func Hash(elements []int) int {
+	return mimc.Hash(elements)
+}
+
+// HashPair returns the MiMC hash of two elements.
+func HashPair(left, right int) int {
+	return mimc.HashPair(left, right)
+}
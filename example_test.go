@@ -5,11 +5,13 @@ import (
 
 	"neo_zk_starter/api"
 	_ "neo_zk_starter/circuits/all" // Important: register all circuits
+	"neo_zk_starter/store"
 )
 
 func TestProofGeneration(t *testing.T) {
-	// 1. Generate a hash commitment proof
-	result, err := api.HashCommitProof(42)
+	// 1. Generate a hash commitment proof, keys are kept in memory for the test
+	s := store.NewMemoryStore()
+	result, err := api.HashCommitProof(s, 42)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -23,7 +25,7 @@ func TestProofGeneration(t *testing.T) {
 	t.Logf("Public Witnesses: %v", verifyArgs.PublicWitnesses)
 
 	// 3. Optional: Verify locally before sending to chain
-	verified, err := api.VerifyProof(result.Proof, result.VerifyingKey, result.PublicWitness)
+	verified, err := api.VerifyGroth16(result.Proof, result.VerifyingKey, result.PublicWitness)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -6,7 +6,9 @@ require (
 	github.com/consensys/gnark v0.11.0
 	github.com/consensys/gnark-crypto v0.14.0
 	github.com/nspcc-dev/neo-go v0.107.3-0.20250203190037-267d7dca78a3
+	github.com/nspcc-dev/neo-go/pkg/interop v0.0.0-20241223145456-80e18222bca2
 	github.com/urfave/cli v1.22.16
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -69,6 +71,5 @@ require (
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250124145028-65684f501c47 // indirect
 	google.golang.org/grpc v1.70.0 // indirect
 	google.golang.org/protobuf v1.36.4 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 	rsc.io/tmplfunc v0.0.3 // indirect
 )
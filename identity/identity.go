@@ -0,0 +1,157 @@
+// Package identity generates Semaphore identities and keeps the group trees
+// their commitments are members of, producing the inputs of the semaphore
+// circuit.
+package identity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"neo_zk_starter/circuits/nullifier"
+	"neo_zk_starter/circuits/semaphore"
+	"neo_zk_starter/merkle"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// Identity is a Semaphore identity. Both values are secret, the commitment
+// is what a group publishes.
+type Identity struct {
+	Nullifier *big.Int
+	Trapdoor  *big.Int
+}
+
+// New returns a random identity, reading from crypto/rand when r is nil.
+func New(r io.Reader) (*Identity, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	order := ecc.BLS12_381.ScalarField()
+	nullifier, err := rand.Int(r, order)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate identity nullifier: %w", err)
+	}
+	trapdoor, err := rand.Int(r, order)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate identity trapdoor: %w", err)
+	}
+	return &Identity{Nullifier: nullifier, Trapdoor: trapdoor}, nil
+}
+
+// Commitment returns the identity commitment, the leaf of the identity in a
+// group.
+func (id *Identity) Commitment() *big.Int {
+	return semaphore.ComputeIdentityCommitment(id.Nullifier, id.Trapdoor)
+}
+
+// NullifierHash returns the nullifier hash a proof of the identity reveals for
+// the external nullifier.
+func (id *Identity) NullifierHash(externalNullifier *big.Int) *big.Int {
+	return nullifier.Compute(id.Nullifier, externalNullifier)
+}
+
+// HashBytes maps bytes to a field element, the SHA-256 digest shifted right by
+// 8 bits. Signals and external nullifiers like topics are hashed with it.
+func HashBytes(b []byte) *big.Int {
+	digest := sha256.Sum256(b)
+	return new(big.Int).Rsh(new(big.Int).SetBytes(digest[:]), 8)
+}
+
+// Group is a group tree of identity commitments. Removed members leave a zero
+// leaf, so the indices of the others stay the same.
+type Group struct {
+	tree    *merkle.Tree
+	members map[string]uint64 // commitment to index
+}
+
+// NewGroup returns an empty group of the given depth, it holds up to 2^depth
+// members.
+func NewGroup(depth int) (*Group, error) {
+	if depth < semaphore.MinDepth || depth > semaphore.MaxDepth {
+		return nil, fmt.Errorf("depth must be between %d and %d, got %d", semaphore.MinDepth, semaphore.MaxDepth, depth)
+	}
+	tree, err := merkle.New(depth)
+	if err != nil {
+		return nil, err
+	}
+	return &Group{tree: tree, members: make(map[string]uint64)}, nil
+}
+
+// Depth returns the depth of the group tree.
+func (g *Group) Depth() int {
+	return g.tree.Depth()
+}
+
+// Len returns the number of members, removed ones included.
+func (g *Group) Len() uint64 {
+	return g.tree.Len()
+}
+
+// Root returns the root of the group tree.
+func (g *Group) Root() *big.Int {
+	return g.tree.Root()
+}
+
+// Add adds the identity commitment to the group and returns its index.
+func (g *Group) Add(commitment *big.Int) (uint64, error) {
+	if commitment == nil || commitment.Sign() == 0 {
+		return 0, errors.New("commitment must not be zero")
+	}
+	if _, ok := g.members[commitment.String()]; ok {
+		return 0, errors.New("commitment is already a member")
+	}
+	index, err := g.tree.Append(commitment)
+	if err != nil {
+		return 0, err
+	}
+	g.members[commitment.String()] = index
+	return index, nil
+}
+
+// Remove removes the identity commitment from the group.
+func (g *Group) Remove(commitment *big.Int) error {
+	index, ok := g.IndexOf(commitment)
+	if !ok {
+		return errors.New("commitment is not a member")
+	}
+	if err := g.tree.Update(index, new(big.Int)); err != nil {
+		return err
+	}
+	delete(g.members, commitment.String())
+	return nil
+}
+
+// IndexOf returns the index of the identity commitment.
+func (g *Group) IndexOf(commitment *big.Int) (uint64, bool) {
+	if commitment == nil {
+		return 0, false
+	}
+	index, ok := g.members[commitment.String()]
+	return index, ok
+}
+
+// Proof returns the input of the semaphore circuit signalling the signal hash
+// for the external nullifier as the identity, which must be a member.
+func (g *Group) Proof(id *Identity, externalNullifier, signalHash *big.Int) (semaphore.Input, error) {
+	index, ok := g.IndexOf(id.Commitment())
+	if !ok {
+		return semaphore.Input{}, errors.New("identity is not a member of the group")
+	}
+	siblings, err := g.tree.Path(index)
+	if err != nil {
+		return semaphore.Input{}, err
+	}
+	return semaphore.Input{
+		IdentityNullifier: id.Nullifier,
+		IdentityTrapdoor:  id.Trapdoor,
+		Index:             index,
+		Siblings:          siblings,
+		Root:              g.Root(),
+		SignalHash:        signalHash,
+		ExternalNullifier: externalNullifier,
+	}, nil
+}
@@ -0,0 +1,102 @@
+package identity
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"neo_zk_starter/circuits/semaphore"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/test"
+)
+
+func TestGroup(t *testing.T) {
+	const depth = 4
+	circuit, err := semaphore.New(depth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := NewGroup(depth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]*Identity, 3)
+	for i := range ids {
+		if ids[i], err = New(bytes.NewReader(bytes.Repeat([]byte{byte(i + 1)}, 128))); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := group.Add(ids[i].Commitment()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := group.Add(ids[0].Commitment()); err == nil {
+		t.Fatal("duplicate member accepted")
+	}
+
+	topic := HashBytes([]byte("topic"))
+	signal := HashBytes([]byte("hello"))
+	solve := func(id *Identity) error {
+		in, err := group.Proof(id, topic, signal)
+		if err != nil {
+			return err
+		}
+		assignment, public, err := circuit.PrepareInput(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if public[1] != id.NullifierHash(topic).String() {
+			t.Fatalf("unexpected nullifier hash %s", public[1])
+		}
+		return test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField())
+	}
+	for i, id := range ids {
+		if err := solve(id); err != nil {
+			t.Fatalf("member %d: %v", i, err)
+		}
+	}
+
+	// Removed members can no longer prove, the others keep their index
+	if err := group.Remove(ids[1].Commitment()); err != nil {
+		t.Fatal(err)
+	}
+	if err := solve(ids[1]); err == nil {
+		t.Fatal("removed member proved membership")
+	}
+	if index, ok := group.IndexOf(ids[2].Commitment()); !ok || index != 2 {
+		t.Fatalf("unexpected index %d", index)
+	}
+	if err := solve(ids[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Identities outside the group get no input
+	outsider, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := group.Proof(outsider, topic, signal); err == nil {
+		t.Fatal("proof input for an outsider")
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	a, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Commitment().Cmp(b.Commitment()) == 0 {
+		t.Fatal("random identities share the commitment")
+	}
+	if a.NullifierHash(big.NewInt(1)).Cmp(a.NullifierHash(big.NewInt(2))) == 0 {
+		t.Fatal("external nullifiers share the nullifier hash")
+	}
+	if HashBytes([]byte("a")).BitLen() > 248 {
+		t.Fatal("hashed bytes exceed 248 bits")
+	}
+}
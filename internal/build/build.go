@@ -1,13 +1,15 @@
 package build
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
 
 	"neo_zk_starter/circuits"
+	"neo_zk_starter/internal/setup"
 	"neo_zk_starter/internal/util"
+	"neo_zk_starter/store"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/groth16"
@@ -18,109 +20,172 @@ import (
 	"github.com/nspcc-dev/neo-go/pkg/smartcontract/zkpbinding"
 )
 
-func createKeysAndCircuit(circuitName string, circ circuits.Circuit) (constraint.ConstraintSystem, groth16.ProvingKey, groth16.VerifyingKey) {
+// Compile compiles the circuit into an R1CS over the BLS12-381 scalar field
+func Compile(circ circuits.Circuit) (constraint.ConstraintSystem, error) {
+	return frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, circ)
+}
+
+// createKeysAndCircuit compiles the circuit and generates its keys. Without a
+// Phase 1 response file the keys come from an insecure local setup and must
+// only be used for development.
+func createKeysAndCircuit(s store.ArtifactStore, circuitName string, circ circuits.Circuit, phase1 *setup.Phase1Source) (constraint.ConstraintSystem, groth16.ProvingKey, groth16.VerifyingKey, error) {
 	println("Creating new proving/verifying keys and circuit")
-	ccs, err := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, circ)
+	ccs, err := Compile(circ)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to compile circuit: %v", err))
+		return nil, nil, nil, fmt.Errorf("failed to compile circuit: %w", err)
+	}
+
+	var (
+		pk        groth16.ProvingKey
+		vk        groth16.VerifyingKey
+		setupKind string
+	)
+	if phase1 != nil {
+		println("Using Phase 1 response file", phase1.Path)
+		pk, vk, err = setup.Setup(ccs, phase1.Path, phase1.Power)
+		setupKind = SetupPhase1
+	} else {
+		println("No Phase 1 response file given, keys are insecure and for development only")
+		pk, vk, err = groth16.Setup(ccs)
+		setupKind = SetupDevelopment
 	}
-	pk, vk, err := groth16.Setup(ccs)
-	// pk, vk, err := .Setup(ccs, "data/response21", 21) // use a real response file for production
 	if err != nil {
-		panic(fmt.Sprintf("Failed to setup keys: %v", err))
+		return nil, nil, nil, fmt.Errorf("failed to setup keys: %w", err)
 	}
 
-	util.WriteDataToFile(circuitName, "prover_key", pk)
-	util.WriteDataToFile(circuitName, "verifier_key", vk)
-	util.WriteDataToFile(circuitName, "r1cs", ccs)
+	if err := util.WriteData(s, circuitName, "prover_key", pk); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to write prover key: %w", err)
+	}
+	if err := util.WriteData(s, circuitName, "verifier_key", vk); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to write verifier key: %w", err)
+	}
+	if err := util.WriteData(s, circuitName, "r1cs", ccs); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to write r1cs: %w", err)
+	}
+	if err := WriteFingerprint(s, circuitName, ccs, setupKind); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to write circuit fingerprint: %w", err)
+	}
 
 	println("Created key and circuit files")
 
-	return ccs, pk, vk
+	return ccs, pk, vk, nil
 }
 
-func Init(circuitName string, rebuild bool) (circuits.Circuit, constraint.ConstraintSystem, groth16.ProvingKey, groth16.VerifyingKey) {
+// Init loads the compiled circuit and keys from the store, creating
+// them when they are missing or rebuild is set. phase1 is only used when keys
+// are created and may be nil.
+//
+// Existing keys are checked against the fingerprint of the freshly compiled
+// circuit. Stale development keys, or any stale keys when phase1 is given, are
+// regenerated. Stale keys from a real setup are refused with the difference.
+// Keys without a fingerprint may come from a real setup as well, they are
+// refused until rebuild is set.
+func Init(s store.ArtifactStore, circuitName string, rebuild bool, phase1 *setup.Phase1Source) (circuits.Circuit, constraint.ConstraintSystem, groth16.ProvingKey, groth16.VerifyingKey, error) {
 	circ, exists := circuits.Get(circuitName)
 	if !exists {
-		panic("Circuit not found")
+		return nil, nil, nil, nil, fmt.Errorf("circuit not found: %s", circuitName)
 	}
 
-	var pk groth16.ProvingKey
-	var vk groth16.VerifyingKey
-	var ccs constraint.ConstraintSystem
-
 	// Check if the 'prover-key' file exists
-	if rebuild {
-		println("Rebuilding proving/verifying keys and circuit")
-		ccs, pk, vk = createKeysAndCircuit(circuitName, circ)
-	} else if _, err := os.Stat(filepath.Join("data", fmt.Sprintf("%s_prover_key", circuitName))); errors.Is(err, os.ErrNotExist) {
-		ccs, pk, vk = createKeysAndCircuit(circuitName, circ)
-	} else {
-		println("Files exist, loading existing proving/verifying keys and circuit")
-
-		pk, _ = util.ReadProvingKeyFromFile(circuitName)
-		vk, _ = util.ReadVerifyingKeyFromFile(circuitName)
-		ccs, _ = util.ReadConstraintSystemFromFile(circuitName)
+	exists, err := s.Exists(store.Data, circuitName, "prover_key")
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if rebuild || !exists {
+		if rebuild {
+			println("Rebuilding proving/verifying keys and circuit")
+		}
+		ccs, pk, vk, err := createKeysAndCircuit(s, circuitName, circ, phase1)
+		return circ, ccs, pk, vk, err
 	}
 
-	return circ, ccs, pk, vk
-}
+	println("Files exist, loading existing proving/verifying keys and circuit")
 
-func PrepareWitness(assignment circuits.Circuit) (witness.Witness, witness.Witness) {
+	ccs, err := Compile(circ)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to compile circuit: %w", err)
+	}
+	current, err := NewFingerprint(ccs, "")
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to fingerprint circuit: %w", err)
+	}
 
-	// define the witness
-	witness, _ := frontend.NewWitness(assignment, ecc.BLS12_381.ScalarField())
-	publicWitness, _ := witness.Public()
+	stored, err := ReadFingerprint(s, circuitName)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil, nil, nil, fmt.Errorf("keys of circuit %s have no fingerprint, they may come from a Phase 1 file or a ceremony: "+
+			"rebuild to replace them", circuitName)
+	}
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to read fingerprint: %w", err)
+	}
+	diff := stored.Diff(current)
 
-	return witness, publicWitness
+	switch {
+	case len(diff) == 0:
+		pk, err := util.ReadProvingKey(s, circuitName)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		vk, err := util.ReadVerifyingKey(s, circuitName)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		return circ, ccs, pk, vk, nil
+	case stored.Setup == SetupDevelopment || phase1 != nil:
+		println("Circuit changed since the keys were generated, rebuilding:\n" + formatDiff(diff))
+		ccs, pk, vk, err := createKeysAndCircuit(s, circuitName, circ, phase1)
+		return circ, ccs, pk, vk, err
+	default:
+		return nil, nil, nil, nil, fmt.Errorf("keys of circuit %s were generated by a %s setup for a different circuit:\n%s\n"+
+			"run a new ceremony or rebuild with a Phase 1 response file", circuitName, stored.Setup, formatDiff(diff))
+	}
 }
 
-func Build(circuitName string, rebuild bool) (string, string, *zkpbinding.VerifyProofArgs) {
-	circuit, exists := circuits.Get(circuitName)
-	if !exists {
-		panic(fmt.Sprintf("Circuit not found: %s", circuitName))
-	}
+func PrepareWitness(assignment circuits.Circuit) (witness.Witness, witness.Witness, error) {
+	return circuits.PrepareWitness(assignment)
+}
 
+// Build creates the keys of the circuit, proves and verifies its ValidInput and
+// generates the verifier contract into the contract namespace of the store.
+func Build(s store.ArtifactStore, circuitName string, rebuild bool, phase1 *setup.Phase1Source) (*zkpbinding.VerifyProofArgs, error) {
 	// Step 1: compile circuit code into R1CS and setup keys
-	_, ccs, pk, vk := Init(circuitName, rebuild)
+	circuit, ccs, pk, vk, err := Init(s, circuitName, rebuild, phase1)
+	if err != nil {
+		return nil, err
+	}
 
 	// Step 2: prepare inputs
 	assignment := circuit.ValidInput()
 
 	// Step 3: setup test witness
-	witness, publicWitness := circuits.PrepareWitness(assignment)
+	witness, publicWitness, err := circuits.PrepareWitness(assignment)
+	if err != nil {
+		return nil, err
+	}
 
 	// Step 4: create groth16 proof
-	proof, _ := groth16.Prove(ccs, pk, witness)
+	proof, err := groth16.Prove(ccs, pk, witness)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate proof: %w", err)
+	}
 
 	// Step 5: verify proof
-	_ = groth16.Verify(proof, vk, publicWitness)
+	if err := groth16.Verify(proof, vk, publicWitness); err != nil {
+		return nil, fmt.Errorf("failed to verify proof: %w", err)
+	}
 
 	// Step 6: export verifier smart contract
+	if err := util.CheckVerifierLimits(circuitName, vk); err != nil {
+		return nil, err
+	}
+	if err := generateVerifier(s, circuitName, circuit, vk); err != nil {
+		return nil, err
+	}
 
-	// Create contract file.
-	_ = os.Mkdir("contract/", os.ModePerm)
-	srcPath := filepath.Join("contract/", circuitName+"-verifier.go")
-	f, _ := os.Create(srcPath)
-
-	// Create contract configuration file.
-	cfgPath := filepath.Join("contract/", circuitName+"-verifier.yml")
-	fCfg, _ := os.Create(cfgPath)
-
-	// Create contract go.mod and go.sum files.
-	fMod, _ := os.Create(filepath.Join("contract/", "go.mod"))
-	fSum, _ := os.Create(filepath.Join("contract/", "go.sum"))
-
-	// Generate Verifier contract itself.
-	_ = zkpbinding.GenerateVerifier(zkpbinding.Config{
-		VerifyingKey: vk,
-		Output:       f,
-		CfgOutput:    fCfg,
-		GomodOutput:  fMod,
-		GosumOutput:  fSum,
-	})
-
-	args, _ := zkpbinding.GetVerifyProofArgs(proof, publicWitness)
+	args, err := zkpbinding.GetVerifyProofArgs(proof, publicWitness)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get verify proof args: %w", err)
+	}
 	println("argA: ", formatByteSlice(args.A))
 	println("argB: ", formatByteSlice(args.B))
 	println("argC: ", formatByteSlice(args.C))
@@ -128,7 +193,74 @@ func Build(circuitName string, rebuild bool) (string, string, *zkpbinding.Verify
 		println("publicWitness[", i, "]: ", formatByteSlice(v.([]byte)))
 	}
 
-	return srcPath, cfgPath, args
+	return args, nil
+}
+
+// generateVerifier writes the verifier contract source, configuration, go.mod
+// and go.sum files of the circuit to the store. The methods of circuits
+// implementing circuits.ContractExtension or circuits.NullifierCircuit are
+// added to the contract.
+func generateVerifier(s store.ArtifactStore, circuitName string, circ circuits.Circuit, vk groth16.VerifyingKey) (err error) {
+	var writers []io.WriteCloser
+	create := func(circuitName, name string) io.Writer {
+		if err != nil {
+			return nil
+		}
+		var w io.WriteCloser
+		w, err = s.Create(store.Contract, circuitName, name)
+		if err != nil {
+			err = fmt.Errorf("failed to create %s: %w", name, err)
+			return nil
+		}
+		writers = append(writers, w)
+		return w
+	}
+	defer func() {
+		for _, w := range writers {
+			if cerr := w.Close(); err == nil && cerr != nil {
+				err = fmt.Errorf("failed to write contract: %w", cerr)
+			}
+		}
+	}()
+
+	cfg := zkpbinding.Config{
+		VerifyingKey: vk,
+		Output:       create(circuitName, util.VerifierSource),
+		CfgOutput:    create(circuitName, util.VerifierConfig),
+		GomodOutput:  create("", util.ContractGoMod),
+		GosumOutput:  create("", util.ContractGoSum),
+	}
+	if err != nil {
+		return err
+	}
+
+	// Extended contracts are generated into buffers first.
+	methods, extended := contractMethods(circ)
+	output, cfgOutput := cfg.Output, cfg.CfgOutput
+	var src, conf bytes.Buffer
+	if extended {
+		cfg.Output, cfg.CfgOutput = &src, &conf
+	}
+
+	// Generate Verifier contract itself.
+	if err := zkpbinding.GenerateVerifier(cfg); err != nil {
+		return fmt.Errorf("failed to generate verifier contract: %w", err)
+	}
+	if !extended {
+		return nil
+	}
+
+	srcData, confData, err := extendVerifier(src.Bytes(), conf.Bytes(), methods)
+	if err != nil {
+		return err
+	}
+	if _, err := output.Write(srcData); err != nil {
+		return fmt.Errorf("failed to write %s: %w", util.VerifierSource, err)
+	}
+	if _, err := cfgOutput.Write(confData); err != nil {
+		return fmt.Errorf("failed to write %s: %w", util.VerifierConfig, err)
+	}
+	return nil
 }
 
 // Format the byte slice as a Go byte array
@@ -1,24 +1,53 @@
 package build
 
 import (
+	"bytes"
 	"encoding/base64"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math/big"
+	"os"
+	"strings"
 	"testing"
 
+	"neo_zk_starter/circuits"
 	_ "neo_zk_starter/circuits/all"
-	_ "neo_zk_starter/internal/test_init"
+	"neo_zk_starter/circuits/hash_commit"
+	"neo_zk_starter/circuits/merkle_membership"
+	"neo_zk_starter/circuits/nullifier"
+	"neo_zk_starter/circuits/private_vote"
+	"neo_zk_starter/circuits/sealed_bid"
+	"neo_zk_starter/circuits/semaphore"
+	"neo_zk_starter/identity"
+	"neo_zk_starter/internal/util"
+	"neo_zk_starter/merkle"
+	"neo_zk_starter/store"
 
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/nspcc-dev/neo-go/pkg/core/native/nativehashes"
 	"github.com/nspcc-dev/neo-go/pkg/neotest"
 	"github.com/nspcc-dev/neo-go/pkg/neotest/chain"
+	"github.com/nspcc-dev/neo-go/pkg/smartcontract/zkpbinding"
+	"github.com/nspcc-dev/neo-go/pkg/vm/stackitem"
+	"gopkg.in/yaml.v3"
 )
 
 // More about circuit testing using gnark/test package: https://pkg.go.dev/github.com/consensys/gnark/test@v0.7.0
 func TestBuild(t *testing.T) {
-	circuitNames := []string{"hash_commit", "merkle_verify"} // TODO: Fix p256_verify // Add more circuits if you want to test build them
+	circuitNames := []string{"hash_commit", "merkle_verify", "merkle_membership", "smt_verify", "p256_verify"} // Add more circuits if you want to test build them
 
 	for _, circuitName := range circuitNames {
 		t.Run(circuitName, func(t *testing.T) {
 			// Run the build path
-			srcPath, cfgPath, args := Build(circuitName, false)
+			s := store.NewFileStore(t.TempDir())
+			args, err := Build(s, circuitName, false, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			srcPath := s.Path(store.Contract, circuitName, util.VerifierSource)
+			cfgPath := s.Path(store.Contract, circuitName, util.VerifierConfig)
 
 			// Create testing chain and deploy contract onto it.
 			bc, committee := chain.NewSingle(t)
@@ -43,3 +72,774 @@ func TestBuild(t *testing.T) {
 		})
 	}
 }
+
+func TestFingerprint(t *testing.T) {
+	compile := func(name string) *Fingerprint {
+		circ, _ := circuits.Get(name)
+		ccs, err := Compile(circ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fp, err := NewFingerprint(ccs, SetupDevelopment)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The fingerprint must survive serialization of the constraint system.
+		var buf bytes.Buffer
+		if _, err := ccs.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		decoded := groth16.NewCS(ecc.BLS12_381)
+		if _, err := decoded.ReadFrom(&buf); err != nil {
+			t.Fatal(err)
+		}
+		fpDecoded, err := NewFingerprint(decoded, SetupDevelopment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := fp.Diff(fpDecoded); len(diff) != 0 {
+			t.Fatalf("fingerprint changed after serialization: %v", diff)
+		}
+		return fp
+	}
+
+	hashCommit := compile("hash_commit")
+	if diff := hashCommit.Diff(compile("hash_commit")); len(diff) != 0 {
+		t.Fatalf("same circuit must have the same fingerprint: %v", diff)
+	}
+
+	// Round trip through the stored format.
+	var buf bytes.Buffer
+	if _, err := hashCommit.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	stored := &Fingerprint{}
+	if _, err := stored.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if diff := stored.Diff(hashCommit); len(diff) != 0 || stored.Setup != SetupDevelopment {
+		t.Fatalf("stored fingerprint differs: %v", diff)
+	}
+
+	if diff := hashCommit.Diff(compile("merkle_verify")); len(diff) == 0 {
+		t.Fatal("different circuits must have different fingerprints")
+	}
+}
+
+// TestInitWithoutFingerprint checks that keys without a fingerprint, or with
+// a corrupt one, are kept until a rebuild is forced.
+func TestInitWithoutFingerprint(t *testing.T) {
+	const circuitName = "hash_commit"
+
+	s := store.NewFileStore(t.TempDir())
+	if _, _, _, _, err := Init(s, circuitName, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(s.Path(store.Data, circuitName, "fingerprint")); err != nil {
+		t.Fatal(err)
+	}
+	key, err := os.ReadFile(s.Path(store.Data, circuitName, "prover_key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, _, _, err := Init(s, circuitName, false, nil); err == nil || !strings.Contains(err.Error(), "no fingerprint") {
+		t.Fatalf("keys without a fingerprint must be refused, got %v", err)
+	}
+	kept, err := os.ReadFile(s.Path(store.Data, circuitName, "prover_key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, kept) {
+		t.Fatal("keys without a fingerprint must not be regenerated")
+	}
+
+	// A fingerprint that cannot be read is reported as such
+	if err := os.WriteFile(s.Path(store.Data, circuitName, "fingerprint"), []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, _, err := Init(s, circuitName, false, nil); err == nil || strings.Contains(err.Error(), "no fingerprint") {
+		t.Fatalf("a corrupt fingerprint must not be reported as missing, got %v", err)
+	}
+
+	if _, _, _, _, err := Init(s, circuitName, true, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFingerprint(s, circuitName); err != nil {
+		t.Fatalf("rebuild must write the fingerprint: %v", err)
+	}
+}
+
+// TestDigestCommitment commits to a preimage with a proof and reveals it to the
+// companion methods of the hash_commit_cryptolib verifier.
+func TestDigestCommitment(t *testing.T) {
+	const circuitName = "hash_commit_cryptolib"
+
+	s := store.NewFileStore(t.TempDir())
+	args, err := Build(s, circuitName, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := s.Path(store.Contract, circuitName, util.VerifierSource)
+	cfgPath := s.Path(store.Contract, circuitName, util.VerifierConfig)
+
+	bc, committee := chain.NewSingle(t)
+	e := neotest.NewExecutor(t, bc, committee, committee)
+	c := neotest.CompileFile(t, e.Validator.ScriptHash(), srcPath, cfgPath)
+	e.DeployContract(t, c, nil)
+	inv := e.CommitteeInvoker(c.Hash)
+	committer := e.Committee.ScriptHash()
+	other := e.NewAccount(t)
+
+	// Committers must sign
+	inv.Invoke(t, false, "commitWithProof", other.ScriptHash(), args.A, args.B, args.C, args.PublicWitnesses)
+
+	// ValidInput commits to 42 with salt 123456789, a copy of the proof
+	// commits nothing new
+	inv.Invoke(t, true, "commitWithProof", committer, args.A, args.B, args.C, args.PublicWitnesses)
+	inv.Invoke(t, false, "commitWithProof", committer, args.A, args.B, args.C, args.PublicWitnesses)
+
+	salt := big.NewInt(123456789)
+	inv.Invoke(t, false, "reveal", committer, hash_commit.SHA256Preimage(43, salt))
+	inv.Invoke(t, false, "reveal", committer, hash_commit.SHA256Preimage(42, big.NewInt(1)))
+
+	// Only the committer reveals
+	otherInv := e.NewInvoker(c.Hash, other)
+	otherInv.Invoke(t, false, "reveal", other.ScriptHash(), hash_commit.SHA256Preimage(42, salt))
+	inv.Invoke(t, false, "reveal", other.ScriptHash(), hash_commit.SHA256Preimage(42, salt))
+
+	inv.Invoke(t, true, "reveal", committer, hash_commit.SHA256Preimage(42, salt))
+	inv.Invoke(t, false, "reveal", committer, hash_commit.SHA256Preimage(42, salt))
+}
+
+func TestExtendVerifier(t *testing.T) {
+	src := []byte(`package main
+
+import (
+	"github.com/nspcc-dev/neo-go/pkg/interop/native/crypto"
+)
+
+func VerifyProof(a []byte, b []byte, c []byte, publicInput [][]byte) bool {
+	return crypto.Bls12381Equal(nil, nil)
+}
+
+`)
+	cfg := []byte(`name: "Groth-16 Verifier contract"
+sourceurl: https://github.com/nspcc-dev/neo-go/
+safemethods: ["verifyProof"]
+permissions:
+  - hash: 726cb6e0cd8628a1350a611384688911ab75f51b
+    methods: ["bls12381Deserialize", "bls12381Equal"]
+`)
+	circ, _ := circuits.Get("hash_commit_cryptolib")
+	methods := circ.(circuits.ContractExtension).ContractMethods()
+
+	src, cfg, err := extendVerifier(src, cfg, methods)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatalf("extended source does not parse: %v\n%s", err, src)
+	}
+	if len(f.Imports) != 4 {
+		t.Fatalf("expected 4 imports, got %d:\n%s", len(f.Imports), src)
+	}
+	for _, name := range []string{"VerifyProof", "CommitWithProof", "Reveal"} {
+		if f.Scope.Lookup(name) == nil {
+			t.Fatalf("%s missing from the extended source", name)
+		}
+	}
+
+	var conf struct {
+		SafeMethods []string `yaml:"safemethods"`
+		Permissions []struct {
+			Hash    string   `yaml:"hash"`
+			Methods []string `yaml:"methods"`
+		} `yaml:"permissions"`
+	}
+	if err := yaml.Unmarshal(cfg, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.SafeMethods) != 1 || len(conf.Permissions) != 1 {
+		t.Fatalf("unexpected config:\n%s", cfg)
+	}
+	if methods := conf.Permissions[0].Methods; len(methods) != 3 || methods[2] != "sha256" {
+		t.Fatalf("sha256 not merged into the CryptoLib permission:\n%s", cfg)
+	}
+
+	// Permissions that are already there are kept as they are
+	cfg2, err := extendConfig(cfg, methods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(cfg, cfg2) {
+		t.Fatalf("repeated extension changed the config:\n%s", cfg2)
+	}
+}
+
+// TestContractExtensions adds the methods of every registered circuit to a
+// verifier and checks that the result is valid Go without duplicate
+// functions. Nullifier circuits with an extension must not get the public
+// VerifyProofOnce, it would burn nullifiers past the checks of the extension.
+func TestContractExtensions(t *testing.T) {
+	src := []byte(`package main
+
+import "github.com/nspcc-dev/neo-go/pkg/interop/native/crypto"
+
+func VerifyProof(a []byte, b []byte, c []byte, publicInput [][]byte) bool {
+	return crypto.Bls12381Equal(nil, nil)
+}
+`)
+	cfg := []byte(`name: "Groth-16 Verifier contract"
+safemethods: ["verifyProof"]
+`)
+	for _, name := range circuits.ListCircuits() {
+		circ, _ := circuits.Get(name)
+		methods, ok := contractMethods(circ)
+		if !ok {
+			continue
+		}
+		src, _, err := extendVerifier(src, cfg, methods)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.AllErrors)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		declared := make(map[string]bool)
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok {
+				if declared[fn.Name.Name] {
+					t.Fatalf("%s: %s declared twice", name, fn.Name.Name)
+				}
+				declared[fn.Name.Name] = true
+			}
+		}
+		_, nullified := circ.(circuits.NullifierCircuit)
+		_, wrapped := circ.(circuits.ContractExtension)
+		if nullified && declared["VerifyProofOnce"] == wrapped {
+			t.Fatalf("%s: VerifyProofOnce declared: %v, contract extension: %v", name, !wrapped, wrapped)
+		}
+	}
+}
+
+// TestMerkleTreeContract deposits leaves into the tree of the
+// merkle_membership_tree verifier and proves membership against its recent
+// roots.
+func TestMerkleTreeContract(t *testing.T) {
+	const circuitName = "merkle_membership_tree"
+
+	// The plain verifier stays a verifier
+	plain, _ := circuits.Get("merkle_membership")
+	if _, ok := contractMethods(plain); ok {
+		t.Fatal("merkle_membership must not get the tree methods")
+	}
+
+	s := store.NewFileStore(t.TempDir())
+	if _, err := Build(s, circuitName, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	srcPath := s.Path(store.Contract, circuitName, util.VerifierSource)
+	cfgPath := s.Path(store.Contract, circuitName, util.VerifierConfig)
+
+	bc, committee := chain.NewSingle(t)
+	e := neotest.NewExecutor(t, bc, committee, committee)
+	c := neotest.CompileFile(t, e.Validator.ScriptHash(), srcPath, cfgPath)
+	e.DeployContract(t, c, e.Committee.ScriptHash())
+	inv := e.CommitteeInvoker(c.Hash)
+
+	// The contract tree follows a native one
+	tree, err := merkle.New(merkle_membership.DefaultDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv.Invoke(t, tree.Root(), "getLastRoot")
+	var leaves []*big.Int
+	deposit := func(i int) {
+		leaf := merkle.HashElements(big.NewInt(int64(i)))
+		leaves = append(leaves, leaf)
+		index, err := tree.Append(leaf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := inv.Invoke(t, int64(index), "deposit", leaf)
+		if i == 0 {
+			t.Logf("deposit: %d GAS", e.GetTxExecResult(t, h).GasConsumed)
+		}
+		inv.Invoke(t, tree.Root(), "getLastRoot")
+	}
+	for i := 0; i < 3; i++ {
+		deposit(i)
+	}
+	inv.Invoke(t, int64(3), "getNextIndex")
+
+	input, err := tree.Proof(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := proveArgs(t, s, circuitName, input)
+	inv.Invoke(t, true, "checkMembership", args.A, args.B, args.C, args.PublicWitnesses)
+
+	// The next empty slot holds a zero leaf under the same root, it is no member
+	padded, _ := merkle.FromLeaves(merkle_membership.DefaultDepth, append(leaves, big.NewInt(0)))
+	emptyInput, _ := padded.Proof(3)
+	emptyArgs := proveArgs(t, s, circuitName, emptyInput)
+	inv.Invoke(t, false, "checkMembership", emptyArgs.A, emptyArgs.B, emptyArgs.C, emptyArgs.PublicWitnesses)
+
+	// A valid proof for a root the contract never had is refused
+	other, _ := merkle.FromLeaves(merkle_membership.DefaultDepth, []*big.Int{big.NewInt(7)})
+	otherInput, _ := other.Proof(0)
+	otherArgs := proveArgs(t, s, circuitName, otherInput)
+	inv.Invoke(t, false, "checkMembership", otherArgs.A, otherArgs.B, otherArgs.C, otherArgs.PublicWitnesses)
+
+	// The root stays known until RootHistory more deposits replaced it
+	for i := 3; i < 3+merkle_membership.RootHistory-1; i++ {
+		deposit(i)
+	}
+	inv.Invoke(t, true, "checkMembership", args.A, args.B, args.C, args.PublicWitnesses)
+	deposit(3 + merkle_membership.RootHistory)
+	inv.Invoke(t, false, "checkMembership", args.A, args.B, args.C, args.PublicWitnesses)
+
+	// Leaves must be non-zero field elements
+	inv.InvokeFail(t, "leaf is not a non-zero field element", "deposit", ecc.BLS12_381.ScalarField())
+	inv.InvokeFail(t, "leaf is not a non-zero field element", "deposit", 0)
+
+	// Only the admin deposits
+	inv.Invoke(t, e.Committee.ScriptHash(), "getAdmin")
+	e.NewInvoker(c.Hash, e.NewAccount(t)).InvokeFail(t, "only the admin can deposit", "deposit", leaves[0])
+}
+
+// TestNullifierReplay verifies a proof once with the nullifier verifier and
+// replays it, the verifier is deployed for external nullifier 1.
+func TestNullifierReplay(t *testing.T) {
+	const circuitName = "nullifier"
+
+	s := store.NewFileStore(t.TempDir())
+	args, err := Build(s, circuitName, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := s.Path(store.Contract, circuitName, util.VerifierSource)
+	cfgPath := s.Path(store.Contract, circuitName, util.VerifierConfig)
+
+	bc, committee := chain.NewSingle(t)
+	e := neotest.NewExecutor(t, bc, committee, committee)
+	c := neotest.CompileFile(t, e.Validator.ScriptHash(), srcPath, cfgPath)
+	e.DeployContract(t, c, int64(1))
+	inv := e.CommitteeInvoker(c.Hash)
+
+	used := args.PublicWitnesses[2]
+	inv.Invoke(t, false, "isNullified", used)
+	inv.Invoke(t, true, "verifyProofOnce", args.A, args.B, args.C, args.PublicWitnesses)
+	inv.Invoke(t, true, "isNullified", used)
+
+	// The replay is refused, VerifyProof alone still accepts the proof
+	inv.Invoke(t, false, "verifyProofOnce", args.A, args.B, args.C, args.PublicWitnesses)
+	inv.Invoke(t, true, "verifyProof", args.A, args.B, args.C, args.PublicWitnesses)
+
+	// A fresh proof of the same statement has the same nullifier
+	again := proveArgs(t, s, circuitName, nullifier.Input{Secret: big.NewInt(1337), ExternalNullifier: big.NewInt(1)})
+	inv.Invoke(t, false, "verifyProofOnce", again.A, again.B, again.C, again.PublicWitnesses)
+
+	// The same secret gets a new nullifier for another external nullifier, the
+	// verifier refuses proofs outside of its own
+	next := proveArgs(t, s, circuitName, nullifier.Input{Secret: big.NewInt(1337), ExternalNullifier: big.NewInt(2)})
+	inv.Invoke(t, false, "verifyProofOnce", next.A, next.B, next.C, next.PublicWitnesses)
+	inv.Invoke(t, false, "isNullified", next.PublicWitnesses[2])
+}
+
+// TestSemaphoreContract adds members to the group of the semaphore verifier
+// and lets them signal once per external nullifier against recent roots.
+func TestSemaphoreContract(t *testing.T) {
+	const circuitName = "semaphore"
+
+	s := store.NewFileStore(t.TempDir())
+	if _, err := Build(s, circuitName, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	srcPath := s.Path(store.Contract, circuitName, util.VerifierSource)
+	cfgPath := s.Path(store.Contract, circuitName, util.VerifierConfig)
+
+	bc, committee := chain.NewSingle(t)
+	e := neotest.NewExecutor(t, bc, committee, committee)
+	c := neotest.CompileFile(t, e.Validator.ScriptHash(), srcPath, cfgPath)
+	e.DeployContract(t, c, e.Committee.ScriptHash())
+	inv := e.CommitteeInvoker(c.Hash)
+
+	group, err := identity.NewGroup(semaphore.DefaultDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := make([]*identity.Identity, 3)
+	for i := range members {
+		if members[i], err = identity.New(bytes.NewReader(bytes.Repeat([]byte{byte(i + 1)}, 128))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range members[:2] {
+		if _, err := group.Add(members[i].Commitment()); err != nil {
+			t.Fatal(err)
+		}
+		inv.Invoke(t, int64(i), "deposit", members[i].Commitment())
+	}
+	inv.Invoke(t, group.Root(), "getLastRoot")
+
+	// Only the admin adds members, anyone else could join the group
+	e.NewInvoker(c.Hash, e.NewAccount(t)).InvokeFail(t, "only the admin can deposit", "deposit", members[2].Commitment())
+	inv.Invoke(t, int64(2), "getNextIndex")
+
+	topic := identity.HashBytes([]byte("poll"))
+	signal := func(id *identity.Identity, g *identity.Group, externalNullifier *big.Int) []any {
+		input, err := g.Proof(id, externalNullifier, identity.HashBytes([]byte("yes")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := proveArgs(t, s, circuitName, input)
+		return []any{args.A, args.B, args.C, args.PublicWitnesses}
+	}
+
+	// A member signals once per external nullifier, for the one passed only
+	first := signal(members[0], group, topic)
+	inv.Invoke(t, false, "verifySignal", append([]any{big.NewInt(1)}, first...)...)
+	inv.Invoke(t, true, "verifySignal", append([]any{topic}, first...)...)
+	inv.Invoke(t, false, "verifySignal", append([]any{topic}, signal(members[0], group, topic)...)...)
+	other := identity.HashBytes([]byte("other poll"))
+	inv.Invoke(t, true, "verifySignal", append([]any{other}, signal(members[0], group, other)...)...)
+
+	// A valid proof for a group root the contract never had is refused
+	outsiders, _ := identity.NewGroup(semaphore.DefaultDepth)
+	if _, err := outsiders.Add(members[2].Commitment()); err != nil {
+		t.Fatal(err)
+	}
+	inv.Invoke(t, false, "verifySignal", append([]any{topic}, signal(members[2], outsiders, topic)...)...)
+
+	// The proof of the second member stays valid after a deposit
+	second := signal(members[1], group, topic)
+	inv.Invoke(t, int64(2), "deposit", members[2].Commitment())
+	inv.Invoke(t, true, "verifySignal", append([]any{topic}, second...)...)
+}
+
+// TestBallot runs an election with the private_vote ballot contract: eligible
+// voters cast one ballot each, reveal it once voting has ended and the tally
+// counts the revealed choices.
+func TestBallot(t *testing.T) {
+	const (
+		circuitName = "private_vote"
+		choices     = 3
+	)
+
+	s := store.NewFileStore(t.TempDir())
+	if _, err := Build(s, circuitName, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	srcPath := s.Path(store.Contract, circuitName, util.VerifierSource)
+	cfgPath := s.Path(store.Contract, circuitName, util.VerifierConfig)
+
+	group, err := identity.NewGroup(private_vote.DefaultDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	voters := make([]*identity.Identity, 4)
+	for i := range voters {
+		if voters[i], err = identity.New(bytes.NewReader(bytes.Repeat([]byte{byte(i + 1)}, 128))); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := group.Add(voters[i].Commitment()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	electionID := identity.HashBytes([]byte("election"))
+
+	bc, committee := chain.NewSingle(t)
+	e := neotest.NewExecutor(t, bc, committee, committee)
+	c := neotest.CompileFile(t, e.Validator.ScriptHash(), srcPath, cfgPath)
+	e.DeployContract(t, c, []any{e.Committee.ScriptHash(), electionID, group.Root(), int64(choices)})
+	inv := e.CommitteeInvoker(c.Hash)
+	inv.Invoke(t, []any{electionID, group.Root(), int64(choices)}, "getElection")
+	inv.Invoke(t, int64(private_vote.PhaseVoting), "getPhase")
+
+	ballot := func(id *identity.Identity, election *big.Int, choice uint64, salt int64) []any {
+		member, err := group.Proof(id, election, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := proveArgs(t, s, circuitName, private_vote.Input{
+			Member:  member,
+			Choices: choices,
+			Choice:  choice,
+			Salt:    big.NewInt(salt),
+		})
+		return []any{args.A, args.B, args.C, args.PublicWitnesses}
+	}
+
+	// Three voters cast ballots, the salt of voter i is 100+i
+	votes := []uint64{2, 0, 2}
+	for i, choice := range votes {
+		h := inv.Invoke(t, true, "vote", ballot(voters[i], electionID, choice, int64(100+i))...)
+		if i == 0 {
+			t.Logf("vote: %d GAS", e.GetTxExecResult(t, h).GasConsumed)
+		}
+		inv.Invoke(t, int64(1), "getPending", private_vote.VoteCommitment(choice, big.NewInt(int64(100+i))))
+	}
+
+	// A seen ballot cannot be replayed past the checks of vote to burn the
+	// nullifier of a voter
+	pending := ballot(voters[3], electionID, 1, 7)
+	inv.InvokeFail(t, "method not found", "verifyProofOnce", pending...)
+
+	// A second ballot of a voter is refused, so is a ballot for another election
+	inv.Invoke(t, false, "vote", ballot(voters[0], electionID, 1, 7)...)
+	inv.Invoke(t, false, "vote", ballot(voters[3], identity.HashBytes([]byte("other")), 1, 7)...)
+
+	// Only the owner ends voting, nothing is revealed before
+	inv.InvokeFail(t, "not revealing", "reveal", int64(2), int64(100))
+	e.NewInvoker(c.Hash, e.NewAccount(t)).InvokeFail(t, "only the owner", "endVoting")
+	inv.Invoke(t, stackitem.Null{}, "endVoting")
+	inv.Invoke(t, int64(private_vote.PhaseReveal), "getPhase")
+	inv.InvokeFail(t, "voting has ended", "vote", pending...)
+
+	// Every ballot is revealed once, with its own choice and salt
+	inv.Invoke(t, false, "reveal", int64(2), int64(101))
+	inv.Invoke(t, false, "reveal", int64(choices), int64(100))
+	for i, choice := range votes {
+		inv.Invoke(t, true, "reveal", int64(choice), int64(100+i))
+	}
+	inv.Invoke(t, false, "reveal", int64(2), int64(100))
+
+	inv.Invoke(t, stackitem.Null{}, "endReveal")
+	inv.Invoke(t, int64(private_vote.PhaseFinished), "getPhase")
+	inv.InvokeFail(t, "not revealing", "reveal", int64(1), int64(7))
+	for choice, tally := range []int64{1, 0, 2} {
+		inv.Invoke(t, tally, "getTally", int64(choice))
+	}
+}
+
+// TestAuction runs a second price auction with the sealed_bid auction
+// contract: bidders pay the deposit, commit range proven bids and reveal them,
+// the highest bid wins at the second highest one and pays it to the owner, and
+// the deposits of bidders that do not reveal are forfeited.
+func TestAuction(t *testing.T) {
+	s := store.NewFileStore(t.TempDir())
+	if _, err := Build(s, "sealed_bid", false, nil); err != nil {
+		t.Fatal(err)
+	}
+	const period = 20
+	a := deployAuction(t, s, period)
+	a.owner.Invoke(t, []any{int64(auctionReserve), int64(auctionMaxBid), int64(auctionDeposit), int64(period)}, "getAuction")
+
+	alice, bob, carol, dave := a.newBidder(t, 3_0000_0000, 11), a.newBidder(t, 5_0000_0000, 22),
+		a.newBidder(t, 4_5000_0000, 33), a.newBidder(t, 9_0000_0000, 44)
+
+	// Bidders pay the deposit once before they commit
+	alice.inv.InvokeFail(t, "bidder has not paid the deposit", "commit", a.commitArgs(t, alice, auctionReserve)...)
+	alice.gas.InvokeFail(t, "wrong deposit", "transfer", a.pay(alice, auctionDeposit-1)...)
+	for _, b := range []*auctionBidder{alice, bob, carol, dave} {
+		b.gas.Invoke(t, true, "transfer", a.pay(b, auctionDeposit)...)
+		a.owner.Invoke(t, true, "hasDeposit", b.signer.ScriptHash())
+	}
+	alice.gas.InvokeFail(t, "bidder has paid the deposit", "transfer", a.pay(alice, auctionDeposit)...)
+	a.e.CheckGASBalance(t, a.c.Hash, big.NewInt(4*auctionDeposit))
+
+	// Every bid is committed with a proof that it is in range, once
+	for _, b := range []*auctionBidder{alice, bob, carol, dave} {
+		h := b.inv.Invoke(t, true, "commit", a.commitArgs(t, b, auctionReserve)...)
+		if b == alice {
+			t.Logf("commit: %d GAS", a.e.GetTxExecResult(t, h).GasConsumed)
+		}
+		a.owner.Invoke(t, sealed_bid.Commitment(b.bid, b.salt, b.signer.ScriptHash()), "getCommitment", b.signer.ScriptHash())
+	}
+	alice.inv.InvokeFail(t, "bidder has committed", "commit", a.commitArgs(t, alice, auctionReserve)...)
+	alice.inv.InvokeFail(t, "bidder did not sign", "commit", a.commitArgs(t, bob, auctionReserve)...)
+
+	// Proofs are bound to their bidder, nobody commits a copy of a bid. A
+	// proof for another reserve is refused too
+	copycat := a.newBidder(t, 5000_0000, 55)
+	copycat.gas.Invoke(t, true, "transfer", a.pay(copycat, auctionDeposit)...)
+	copied := a.commitArgs(t, bob, auctionReserve)
+	copycat.inv.Invoke(t, false, "commit", append([]any{copycat.signer.ScriptHash()}, copied[1:]...)...)
+	copycat.inv.Invoke(t, false, "commit", a.commitArgs(t, copycat, 0)...)
+
+	// Once committing has ended the bids are revealed, dave does not reveal
+	alice.inv.InvokeFail(t, "not revealing", "reveal", alice.signer.ScriptHash(), int64(alice.bid), alice.salt)
+	alice.inv.InvokeFail(t, "only the owner", "endCommit")
+	a.owner.Invoke(t, stackitem.Null{}, "endCommit")
+	late := a.newBidder(t, 6_0000_0000, 66)
+	late.gas.InvokeFail(t, "commit phase has ended", "transfer", a.pay(late, auctionDeposit)...)
+	alice.inv.Invoke(t, false, "reveal", alice.signer.ScriptHash(), int64(alice.bid), big.NewInt(12))
+	carol.inv.Invoke(t, false, "reveal", bob.signer.ScriptHash(), int64(carol.bid), carol.salt)
+	for _, b := range []*auctionBidder{alice, bob, carol} {
+		b.inv.Invoke(t, true, "reveal", b.signer.ScriptHash(), int64(b.bid), b.salt)
+		a.owner.Invoke(t, true, "isRevealed", b.signer.ScriptHash())
+	}
+	alice.inv.Invoke(t, false, "reveal", alice.signer.ScriptHash(), int64(alice.bid), alice.salt)
+	a.owner.Invoke(t, int64(bob.bid), "getHighestBid")
+	a.owner.Invoke(t, int64(carol.bid), "getSecondBid")
+	alice.inv.InvokeFail(t, "auction is not closed", "refund", alice.signer.ScriptHash())
+
+	// Bob wins at the second price, the reveal phase is over
+	alice.inv.InvokeFail(t, "only the owner", "close")
+	a.owner.Invoke(t, stackitem.Null{}, "close")
+	a.owner.Invoke(t, int64(sealed_bid.PhaseClosed), "getPhase")
+	a.owner.Invoke(t, bob.signer.ScriptHash().BytesBE(), "getWinner")
+	a.owner.Invoke(t, int64(carol.bid), "getPrice")
+	a.owner.Invoke(t, false, "isPaid")
+	dave.inv.InvokeFail(t, "not revealing", "reveal", dave.signer.ScriptHash(), int64(dave.bid), dave.salt)
+
+	// Bob pays the price less his deposit, the owner gets the price
+	due := int64(carol.bid) - auctionDeposit
+	bob.inv.Invoke(t, false, "refund", bob.signer.ScriptHash())
+	alice.gas.InvokeFail(t, "only the winner pays", "transfer", a.pay(alice, due)...)
+	bob.gas.InvokeFail(t, "wrong payment", "transfer", a.pay(bob, due-1)...)
+	bob.gas.Invoke(t, true, "transfer", a.pay(bob, due)...)
+	bob.gas.InvokeFail(t, "price is paid", "transfer", a.pay(bob, due)...)
+	a.owner.Invoke(t, true, "isPaid")
+	a.e.CheckGASBalance(t, a.ownerAcc.ScriptHash(), big.NewInt(auctionOwnerGAS+int64(carol.bid)))
+	a.owner.Invoke(t, false, "forfeitWinner")
+
+	// Revealed bids get their deposit back, the deposit of bob is spent on the
+	// price, dave and the copycat forfeit theirs
+	a.owner.Invoke(t, int64(2*auctionDeposit), "getForfeited")
+	for _, b := range []*auctionBidder{alice, carol} {
+		b.inv.Invoke(t, true, "refund", b.signer.ScriptHash())
+		b.inv.Invoke(t, false, "refund", b.signer.ScriptHash())
+	}
+	bob.inv.Invoke(t, false, "refund", bob.signer.ScriptHash())
+	dave.inv.Invoke(t, false, "refund", dave.signer.ScriptHash())
+	a.e.CheckGASBalance(t, a.c.Hash, big.NewInt(2*auctionDeposit))
+	alice.inv.InvokeFail(t, "only the owner", "collectForfeited")
+	a.owner.Invoke(t, true, "collectForfeited")
+	a.owner.Invoke(t, false, "collectForfeited")
+	a.e.CheckGASBalance(t, a.c.Hash, big.NewInt(0))
+}
+
+// TestAuctionDefault closes an auction whose winner does not pay, its deposit
+// is forfeited once the payment period has ended.
+func TestAuctionDefault(t *testing.T) {
+	const period = 3
+
+	s := store.NewFileStore(t.TempDir())
+	if _, err := Build(s, "sealed_bid", false, nil); err != nil {
+		t.Fatal(err)
+	}
+	a := deployAuction(t, s, period)
+	alice, bob := a.newBidder(t, 3_0000_0000, 11), a.newBidder(t, 5_0000_0000, 22)
+	for _, b := range []*auctionBidder{alice, bob} {
+		b.gas.Invoke(t, true, "transfer", a.pay(b, auctionDeposit)...)
+		b.inv.Invoke(t, true, "commit", a.commitArgs(t, b, auctionReserve)...)
+	}
+	a.owner.Invoke(t, stackitem.Null{}, "endCommit")
+	for _, b := range []*auctionBidder{alice, bob} {
+		b.inv.Invoke(t, true, "reveal", b.signer.ScriptHash(), int64(b.bid), b.salt)
+	}
+	a.owner.Invoke(t, stackitem.Null{}, "close")
+	a.owner.Invoke(t, int64(alice.bid), "getPrice")
+
+	// The deposit of bob is held until the payment period has ended
+	a.owner.Invoke(t, false, "forfeitWinner")
+	bob.inv.Invoke(t, false, "refund", bob.signer.ScriptHash())
+	for i := 0; i < period; i++ {
+		a.e.AddNewBlock(t)
+	}
+	bob.gas.InvokeFail(t, "payment period has ended", "transfer", a.pay(bob, int64(alice.bid)-auctionDeposit)...)
+	a.owner.Invoke(t, false, "isPaid")
+
+	// Anyone forfeits it then, the owner collects it
+	alice.inv.Invoke(t, true, "forfeitWinner")
+	alice.inv.Invoke(t, false, "forfeitWinner")
+	bob.inv.Invoke(t, false, "refund", bob.signer.ScriptHash())
+	alice.inv.Invoke(t, true, "refund", alice.signer.ScriptHash())
+	a.owner.Invoke(t, int64(auctionDeposit), "getForfeited")
+	a.owner.Invoke(t, true, "collectForfeited")
+	a.e.CheckGASBalance(t, a.c.Hash, big.NewInt(0))
+	a.e.CheckGASBalance(t, a.ownerAcc.ScriptHash(), big.NewInt(auctionOwnerGAS+auctionDeposit))
+}
+
+// Auctions of the tests, amounts are in GAS fractions.
+const (
+	auctionReserve  = 1_0000_0000
+	auctionMaxBid   = 10_0000_0000
+	auctionDeposit  = 1_0000_0000
+	auctionOwnerGAS = 100_0000_0000
+)
+
+// auction is a sealed_bid auction contract on a chain of its own.
+type auction struct {
+	s        store.ArtifactStore
+	e        *neotest.Executor
+	c        *neotest.Contract
+	ownerAcc neotest.Signer
+	owner    *neotest.ContractInvoker // signed by the owner, the committee pays the fees
+}
+
+// auctionBidder is a bidder of an auction, signing its contract and GAS calls.
+type auctionBidder struct {
+	signer neotest.Signer
+	inv    *neotest.ContractInvoker
+	gas    *neotest.ContractInvoker
+	bid    uint64
+	salt   *big.Int
+}
+
+// deployAuction deploys the sealed_bid auction contract built into the store
+// with the payment period in blocks.
+func deployAuction(t *testing.T, s *store.FileStore, period int64) *auction {
+	srcPath := s.Path(store.Contract, "sealed_bid", util.VerifierSource)
+	cfgPath := s.Path(store.Contract, "sealed_bid", util.VerifierConfig)
+
+	bc, committee := chain.NewSingle(t)
+	e := neotest.NewExecutor(t, bc, committee, committee)
+	c := neotest.CompileFile(t, e.Validator.ScriptHash(), srcPath, cfgPath)
+	ownerAcc := e.NewAccount(t, auctionOwnerGAS)
+	e.DeployContract(t, c, []any{ownerAcc.ScriptHash(), int64(auctionReserve), int64(auctionMaxBid), int64(auctionDeposit), period})
+	return &auction{s: s, e: e, c: c, ownerAcc: ownerAcc, owner: e.NewInvoker(c.Hash, e.Committee, ownerAcc)}
+}
+
+func (a *auction) newBidder(t *testing.T, bid uint64, salt int64) *auctionBidder {
+	signer := a.e.NewAccount(t)
+	return &auctionBidder{signer, a.e.NewInvoker(a.c.Hash, signer), a.e.NewInvoker(nativehashes.GAS, signer), bid, big.NewInt(salt)}
+}
+
+// commitArgs returns the commit arguments of the bid with a range proof from
+// low to the maximum bid.
+func (a *auction) commitArgs(t *testing.T, b *auctionBidder, low uint64) []any {
+	args := proveArgs(t, a.s, "sealed_bid", &sealed_bid.Input{
+		Bid: b.bid, Salt: b.salt, Mode: sealed_bid.ModeRange, Low: low, High: auctionMaxBid, Bidder: b.signer.ScriptHash(),
+	})
+	return []any{b.signer.ScriptHash(), args.A, args.B, args.C, args.PublicWitnesses}
+}
+
+// pay returns the GAS transfer arguments of a payment of the bidder to the
+// auction.
+func (a *auction) pay(b *auctionBidder, amount int64) []any {
+	return []any{b.signer.ScriptHash(), a.c.Hash, amount, nil}
+}
+
+// proveArgs proves the input with the keys in the store and returns the
+// arguments of verifyProof.
+func proveArgs(t *testing.T, s store.ArtifactStore, circuitName string, input interface{}) *zkpbinding.VerifyProofArgs {
+	circ, ccs, pk, _, err := Init(s, circuitName, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment, _, err := circ.PrepareInput(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness, publicWitness, err := PrepareWitness(assignment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ccs, pk, witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := zkpbinding.GetVerifyProofArgs(proof, publicWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return args
+}
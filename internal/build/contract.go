@@ -0,0 +1,315 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+
+	"neo_zk_starter/circuits"
+
+	"gopkg.in/yaml.v3"
+)
+
+// contractMethods returns the methods the verifier of the circuit gets besides
+// VerifyProof, false when there are none.
+func contractMethods(circ circuits.Circuit) (circuits.ContractMethods, bool) {
+	var (
+		methods  circuits.ContractMethods
+		extended bool
+	)
+	ext, hasExt := circ.(circuits.ContractExtension)
+	if n, ok := circ.(circuits.NullifierCircuit); ok {
+		methods = nullifierMethods(n.NullifierInput(), n.ExternalNullifierInput(), !hasExt)
+		extended = true
+	}
+	if hasExt {
+		methods = methods.Merge(ext.ContractMethods())
+		extended = true
+	}
+	return methods, extended
+}
+
+// nullifierMethods returns the methods recording the nullifier at the index of
+// the public inputs for the external nullifier at externalIndex. A standalone
+// verifier pins its external nullifier at deploy and gets VerifyProofOnce,
+// the methods of an extension pass theirs to verifyAndNullify.
+func nullifierMethods(index, externalIndex int, standalone bool) circuits.ContractMethods {
+	src := fmt.Sprintf(nullifierContractMethods, index, externalIndex)
+	if standalone {
+		src += nullifierStandaloneMethods
+	}
+	return circuits.ContractMethods{
+		Imports: []string{
+			"github.com/nspcc-dev/neo-go/pkg/interop/convert",
+			"github.com/nspcc-dev/neo-go/pkg/interop/runtime",
+			"github.com/nspcc-dev/neo-go/pkg/interop/storage",
+		},
+		Source:      src,
+		SafeMethods: []string{"isNullified"},
+		Events: []circuits.ContractEvent{{
+			Name:       "Nullified",
+			Parameters: []circuits.ContractEventParameter{{Name: "nullifier", Type: "ByteArray"}},
+		}},
+	}
+}
+
+const nullifierContractMethods = `// nullifierInput is the index of the nullifier in the public inputs.
+const nullifierInput = %d
+
+// externalNullifierInput is the index of the external nullifier in the public
+// inputs.
+const externalNullifierInput = %d
+
+// nullifierPrefix prefixes the storage keys of the used nullifiers.
+const nullifierPrefix = "u"
+
+// IsNullified reports whether a proof with the nullifier, 32 bytes little
+// endian like the public inputs, was verified.
+func IsNullified(nullifier []byte) bool {
+	return storage.Get(storage.GetReadOnlyContext(), nullifierPrefix+string(nullifier)) != nil
+}
+
+// verifyAndNullify verifies a proof for the external nullifier and records its
+// nullifier, methods that act on a proof call it to accept every nullifier
+// once. Public inputs have a fixed length, CryptoLib refuses non canonical
+// scalars, so every nullifier has a single encoding.
+func verifyAndNullify(externalNullifier int, a []byte, b []byte, c []byte, publicInput [][]byte) bool {
+	if len(publicInput) <= nullifierInput || len(publicInput[nullifierInput]) != 32 ||
+		len(publicInput) <= externalNullifierInput ||
+		convert.ToInteger(publicInput[externalNullifierInput]) != externalNullifier {
+		return false
+	}
+	nullifier := publicInput[nullifierInput]
+	ctx := storage.GetContext()
+	key := nullifierPrefix + string(nullifier)
+	if storage.Get(ctx, key) != nil || !VerifyProof(a, b, c, publicInput) {
+		return false
+	}
+	storage.Put(ctx, key, 1)
+	runtime.Notify("Nullified", nullifier)
+	return true
+}
+`
+
+const nullifierStandaloneMethods = `
+// externalNullifierKey stores the external nullifier of the accepted proofs.
+const externalNullifierKey = "x"
+
+// _deploy pins the external nullifier, the deploy data.
+func _deploy(data any, isUpdate bool) {
+	if isUpdate {
+		return
+	}
+	if data == nil {
+		panic("expected the external nullifier")
+	}
+	storage.Put(storage.GetContext(), externalNullifierKey, data.(int))
+}
+
+// VerifyProofOnce verifies the proof like VerifyProof and records its
+// nullifier. It returns false for a nullifier that was used before and for
+// proofs of another external nullifier than the deployed one.
+func VerifyProofOnce(a []byte, b []byte, c []byte, publicInput [][]byte) bool {
+	externalNullifier := convert.ToInteger(storage.Get(storage.GetReadOnlyContext(), externalNullifierKey))
+	return verifyAndNullify(externalNullifier, a, b, c, publicInput)
+}
+`
+
+// extendVerifier adds the companion methods to the generated verifier source
+// and its configuration.
+func extendVerifier(src, cfg []byte, methods circuits.ContractMethods) ([]byte, []byte, error) {
+	src, err := extendSource(src, methods)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extend verifier source: %w", err)
+	}
+	cfg, err = extendConfig(cfg, methods)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extend verifier config: %w", err)
+	}
+	return src, cfg, nil
+}
+
+// extendSource imports the packages the verifier lacks and appends the
+// methods source.
+func extendSource(src []byte, methods circuits.ContractMethods) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	imported := make(map[string]bool, len(f.Imports))
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		imported[path] = true
+	}
+	var imports strings.Builder
+	for _, path := range methods.Imports {
+		if !imported[path] {
+			imported[path] = true
+			fmt.Fprintf(&imports, "\n\nimport %q", path)
+		}
+	}
+
+	// New imports follow the existing ones, or the package clause
+	end := f.Name.End()
+	for _, decl := range f.Decls {
+		end = decl.End()
+	}
+	offset := fset.Position(end).Offset
+
+	var out bytes.Buffer
+	out.Write(src[:offset])
+	out.WriteString(imports.String())
+	out.Write(src[offset:])
+	out.WriteString("\n")
+	out.WriteString(methods.Source)
+	return format.Source(out.Bytes())
+}
+
+// extendConfig adds the safe methods, events and permissions to the contract
+// configuration. Methods of contracts the verifier already calls are merged
+// into their permission.
+func extendConfig(cfg []byte, methods circuits.ContractMethods) ([]byte, error) {
+	if len(methods.SafeMethods) == 0 && len(methods.Events) == 0 && len(methods.Permissions) == 0 {
+		return cfg, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(cfg, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("configuration is not a mapping")
+	}
+	root := doc.Content[0]
+
+	if len(methods.SafeMethods) != 0 {
+		safe, err := sequence(root, "safemethods", yaml.FlowStyle)
+		if err != nil {
+			return nil, err
+		}
+		appendNames(safe, methods.SafeMethods)
+	}
+
+	if len(methods.Events) != 0 {
+		events, err := sequence(root, "events", 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, ev := range methods.Events {
+			if slices.ContainsFunc(events.Content, func(n *yaml.Node) bool {
+				name := mappingValue(n, "name")
+				return name != nil && name.Value == ev.Name
+			}) {
+				continue
+			}
+			params := &yaml.Node{Kind: yaml.SequenceNode}
+			for _, p := range ev.Parameters {
+				params.Content = append(params.Content, &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+					scalarNode("name"), scalarNode(p.Name),
+					scalarNode("type"), scalarNode(p.Type),
+				}})
+			}
+			events.Content = append(events.Content, &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+				scalarNode("name"), scalarNode(ev.Name),
+				scalarNode("parameters"), params,
+			}})
+		}
+	}
+
+	if len(methods.Permissions) != 0 {
+		list, err := sequence(root, "permissions", 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range methods.Permissions {
+			hash := strings.TrimPrefix(p.Hash, "0x")
+
+			var perm *yaml.Node
+			for _, item := range list.Content {
+				if h := mappingValue(item, "hash"); h != nil && strings.TrimPrefix(h.Value, "0x") == hash {
+					perm = item
+					break
+				}
+			}
+			if perm == nil {
+				perm = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+					scalarNode("hash"), scalarNode(hash),
+					scalarNode("methods"), {Kind: yaml.SequenceNode, Style: yaml.FlowStyle},
+				}}
+				list.Content = append(list.Content, perm)
+			}
+
+			names := mappingValue(perm, "methods")
+			if names == nil || names.Kind != yaml.SequenceNode {
+				// Any other value is the '*' wildcard, every method is allowed
+				continue
+			}
+			appendNames(names, p.Methods)
+		}
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// sequence returns the list under key in the mapping node, it is added with
+// the style when absent.
+func sequence(n *yaml.Node, key string, style yaml.Style) (*yaml.Node, error) {
+	list := mappingValue(n, key)
+	if list == nil {
+		list = &yaml.Node{Kind: yaml.SequenceNode, Style: style}
+		n.Content = append(n.Content, scalarNode(key), list)
+	}
+	// An empty value like "events:" is an empty list
+	if list.Kind == yaml.ScalarNode && list.Tag == "!!null" {
+		*list = yaml.Node{Kind: yaml.SequenceNode, Style: style}
+	}
+	if list.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s are not a list", key)
+	}
+	return list, nil
+}
+
+// appendNames appends the names missing from the list.
+func appendNames(list *yaml.Node, names []string) {
+	for _, name := range names {
+		if !slices.ContainsFunc(list.Content, func(n *yaml.Node) bool { return n.Value == name }) {
+			list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name, Style: yaml.DoubleQuotedStyle})
+		}
+	}
+}
+
+// mappingValue returns the value of key in the mapping node, nil when absent.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
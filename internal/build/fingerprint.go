@@ -0,0 +1,156 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"neo_zk_starter/internal/util"
+	"neo_zk_starter/store"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	cs "github.com/consensys/gnark/constraint/bls12-381"
+)
+
+// Setup kinds recorded in the fingerprint, they tell whether keys of a stale
+// circuit can be regenerated without user involvement.
+const (
+	SetupDevelopment = "development"
+	SetupPhase1      = "phase1"
+	SetupCeremony    = "ceremony"
+)
+
+// Fingerprint identifies a compiled constraint system. Keys are only valid for
+// the circuit they were generated for, so the fingerprint is stored next to
+// them and compared with a freshly compiled circuit on load.
+type Fingerprint struct {
+	Curve           string   `json:"curve"`
+	NbConstraints   int      `json:"nbConstraints"`
+	NbInternal      int      `json:"nbInternal"`
+	PublicVariables []string `json:"publicVariables"`
+	SecretVariables []string `json:"secretVariables"`
+	Constraints     string   `json:"constraints"` // sha256 of the constraints and their coefficients
+	Setup           string   `json:"setup"`
+}
+
+// NewFingerprint computes the fingerprint of the compiled constraint system.
+func NewFingerprint(ccs constraint.ConstraintSystem, setupKind string) (*Fingerprint, error) {
+	r1cs, ok := ccs.(*cs.R1CS)
+	if !ok {
+		return nil, fmt.Errorf("unsupported constraint system %T", ccs)
+	}
+
+	h := sha256.New()
+	buf := make([]byte, 4)
+	writeTerms := func(l constraint.LinearExpression) {
+		binary.BigEndian.PutUint32(buf, uint32(len(l)))
+		h.Write(buf)
+		for _, t := range l {
+			binary.BigEndian.PutUint32(buf, t.VID)
+			h.Write(buf)
+			h.Write([]byte(r1cs.CoeffToString(int(t.CID))))
+			h.Write([]byte{0})
+		}
+	}
+	for _, c := range r1cs.GetR1Cs() {
+		writeTerms(c.L)
+		writeTerms(c.R)
+		writeTerms(c.O)
+	}
+
+	return &Fingerprint{
+		Curve:           curveOf(ccs),
+		NbConstraints:   ccs.GetNbConstraints(),
+		NbInternal:      ccs.GetNbInternalVariables(),
+		PublicVariables: slices.Clone(r1cs.Public),
+		SecretVariables: slices.Clone(r1cs.Secret),
+		Constraints:     hex.EncodeToString(h.Sum(nil)),
+		Setup:           setupKind,
+	}, nil
+}
+
+func curveOf(ccs constraint.ConstraintSystem) string {
+	for _, id := range ecc.Implemented() {
+		if id.ScalarField().Cmp(ccs.Field()) == 0 {
+			return id.String()
+		}
+	}
+	return "unknown"
+}
+
+// Diff lists the differences between the stored fingerprint f and the
+// fingerprint of the current circuit. An empty result means the keys match.
+func (f *Fingerprint) Diff(current *Fingerprint) []string {
+	var diff []string
+	add := func(field string, stored, now any) {
+		diff = append(diff, fmt.Sprintf("%s: keys have %v, circuit has %v", field, stored, now))
+	}
+
+	if f.Curve != current.Curve {
+		add("curve", f.Curve, current.Curve)
+	}
+	if f.NbConstraints != current.NbConstraints {
+		add("constraints", f.NbConstraints, current.NbConstraints)
+	}
+	if f.NbInternal != current.NbInternal {
+		add("internal variables", f.NbInternal, current.NbInternal)
+	}
+	if !slices.Equal(f.PublicVariables, current.PublicVariables) {
+		add("public variables", f.PublicVariables, current.PublicVariables)
+	}
+	if !slices.Equal(f.SecretVariables, current.SecretVariables) {
+		add("secret variables", f.SecretVariables, current.SecretVariables)
+	}
+	if f.Constraints != current.Constraints {
+		add("constraints hash", f.Constraints, current.Constraints)
+	}
+	return diff
+}
+
+// WriteTo implements io.WriterTo
+func (f *Fingerprint) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// ReadFrom implements io.ReaderFrom
+func (f *Fingerprint) ReadFrom(r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return int64(len(data)), err
+	}
+	return int64(len(data)), json.Unmarshal(data, f)
+}
+
+// WriteFingerprint stores the fingerprint of the compiled circuit next to its keys.
+func WriteFingerprint(s store.ArtifactStore, circuitName string, ccs constraint.ConstraintSystem, setupKind string) error {
+	fp, err := NewFingerprint(ccs, setupKind)
+	if err != nil {
+		return err
+	}
+	return util.WriteData(s, circuitName, "fingerprint", fp)
+}
+
+// ReadFingerprint loads the fingerprint stored next to the circuit keys.
+func ReadFingerprint(s store.ArtifactStore, circuitName string) (*Fingerprint, error) {
+	fp := &Fingerprint{}
+	if err := util.ReadData(s, circuitName, "fingerprint", fp); err != nil {
+		return nil, err
+	}
+	return fp, nil
+}
+
+// formatDiff renders the fingerprint differences for the user.
+func formatDiff(diff []string) string {
+	return "  - " + strings.Join(diff, "\n  - ")
+}
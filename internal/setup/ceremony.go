@@ -0,0 +1,224 @@
+package setup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"neo_zk_starter/internal/util"
+	"neo_zk_starter/store"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/groth16/bls12-381/mpcsetup"
+	"github.com/consensys/gnark/constraint"
+	cs "github.com/consensys/gnark/constraint/bls12-381"
+)
+
+// The Phase 2 ceremony keeps its state in the data namespace next to the keys:
+//   - <circuit>_r1cs         the compiled circuit the ceremony is run for
+//   - <circuit>_phase1       the Phase 1 SRS truncated to the circuit size
+//   - <circuit>_phase2_NNNN  the initial state (0000) and every contribution after it
+//   - <circuit>_phase2_challenge  the challenge hash of the initial state
+//
+// The parameters of the initial Phase 2 state are deterministic, so
+// verification recomputes them and checks the 0000 file against them before
+// walking the chain of contributions. Its challenge hash is random, the one
+// recorded at initialisation tells this ceremony apart from another one of
+// the same circuit.
+
+// ContributionName returns the data file name of the i-th Phase 2 contribution.
+func ContributionName(i int) string {
+	return fmt.Sprintf("phase2_%04d", i)
+}
+
+// InitCeremony prepares the Phase 2 ceremony for the compiled circuit from the
+// Phase 1 response file and writes the initial state to the store.
+func InitCeremony(s store.ArtifactStore, circuitName string, ccs constraint.ConstraintSystem, phase1ResponsePath string, inPow int) error {
+	r1cs, ok := ccs.(*cs.R1CS)
+	if !ok {
+		return errors.New("ceremony requires a BLS12-381 R1CS constraint system")
+	}
+
+	srs1, err := ReadPhase1(phase1ResponsePath, inPow, ccs.GetNbConstraints())
+	if err != nil {
+		return err
+	}
+	srs2, _ := mpcsetup.InitPhase2(r1cs, srs1)
+
+	if err := util.WriteData(s, circuitName, "r1cs", ccs); err != nil {
+		return err
+	}
+	if err := util.WriteData(s, circuitName, "phase1", srs1); err != nil {
+		return err
+	}
+	if err := util.WriteData(s, circuitName, challengeName, bytes.NewBuffer(srs2.Hash)); err != nil {
+		return err
+	}
+	return util.WriteData(s, circuitName, ContributionName(0), &srs2)
+}
+
+const challengeName = "phase2_challenge"
+
+// CeremonyChallenge returns the challenge hash of the initial Phase 2 state
+// recorded by InitCeremony. Publish it with the ceremony, the contributions
+// are verified against it.
+func CeremonyChallenge(s store.ArtifactStore, circuitName string) ([]byte, error) {
+	var challenge bytes.Buffer
+	if err := util.ReadData(s, circuitName, challengeName, &challenge); err != nil {
+		return nil, err
+	}
+	return challenge.Bytes(), nil
+}
+
+// Contribute reads a Phase 2 state, adds fresh randomness to it and writes the
+// result. This is the only step a participant runs, it needs neither the
+// circuit nor the Phase 1 files.
+func Contribute(in io.Reader, out io.Writer) error {
+	var srs2 mpcsetup.Phase2
+	if _, err := srs2.ReadFrom(in); err != nil {
+		return fmt.Errorf("failed to read contribution: %w", err)
+	}
+
+	srs2.Contribute()
+
+	if _, err := srs2.WriteTo(out); err != nil {
+		return fmt.Errorf("failed to write contribution: %w", err)
+	}
+	return nil
+}
+
+// LatestContribution returns the index of the most recent Phase 2 state stored
+// for the circuit, or an error if the ceremony was not initialised.
+func LatestContribution(s store.ArtifactStore, circuitName string) (int, error) {
+	i := 0
+	for ; ; i++ {
+		ok, err := s.Exists(store.Data, circuitName, ContributionName(i))
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("no ceremony found for circuit %s, run ceremony init first", circuitName)
+	}
+	return i - 1, nil
+}
+
+// VerifyCeremony checks the initial Phase 2 state and every stored contribution
+// against the previous one. It returns the number of verified contributions.
+func VerifyCeremony(s store.ArtifactStore, circuitName string) (int, error) {
+	c, err := loadCeremony(s, circuitName)
+	if err != nil {
+		return 0, err
+	}
+	return len(c.contributions) - 1, nil
+}
+
+// FinalizeCeremony verifies the ceremony and extracts the proving and verifying
+// keys from the last contribution.
+func FinalizeCeremony(s store.ArtifactStore, circuitName string) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	c, err := loadCeremony(s, circuitName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(c.contributions) < 2 {
+		return nil, nil, errors.New("ceremony has no contributions yet")
+	}
+
+	srs2 := c.contributions[len(c.contributions)-1]
+	pk, vk := mpcsetup.ExtractKeys(c.srs1, srs2, c.evals, c.ccs.GetNbConstraints())
+	return &pk, &vk, nil
+}
+
+type ceremony struct {
+	ccs   constraint.ConstraintSystem
+	srs1  *mpcsetup.Phase1
+	evals *mpcsetup.Phase2Evaluations
+	// contributions starts with the initial state
+	contributions []*mpcsetup.Phase2
+}
+
+// loadCeremony reads the ceremony state of the circuit and verifies the chain of
+// contributions.
+func loadCeremony(s store.ArtifactStore, circuitName string) (*ceremony, error) {
+	ccs, err := util.ReadConstraintSystem(s, circuitName)
+	if err != nil {
+		return nil, err
+	}
+	r1cs, ok := ccs.(*cs.R1CS)
+	if !ok {
+		return nil, errors.New("ceremony requires a BLS12-381 R1CS constraint system")
+	}
+
+	srs1 := &mpcsetup.Phase1{}
+	if err := util.ReadData(s, circuitName, "phase1", srs1); err != nil {
+		return nil, err
+	}
+
+	latest, err := LatestContribution(s, circuitName)
+	if err != nil {
+		return nil, err
+	}
+
+	initial := &mpcsetup.Phase2{}
+	if err := util.ReadData(s, circuitName, ContributionName(0), initial); err != nil {
+		return nil, err
+	}
+	challenge, err := CeremonyChallenge(s, circuitName)
+	if err != nil {
+		return nil, err
+	}
+	expected, evals := mpcsetup.InitPhase2(r1cs, srs1)
+	expected.Hash = challenge // the recomputed hash is random
+	if !sameParameters(initial, &expected) {
+		return nil, errors.New("initial Phase 2 state does not match the circuit, the Phase 1 SRS and the ceremony challenge")
+	}
+
+	contributions := []*mpcsetup.Phase2{initial}
+	for i := 1; i <= latest; i++ {
+		srs2 := &mpcsetup.Phase2{}
+		if err := util.ReadData(s, circuitName, ContributionName(i), srs2); err != nil {
+			return nil, err
+		}
+		if err := mpcsetup.VerifyPhase2(contributions[i-1], srs2); err != nil {
+			return nil, fmt.Errorf("contribution %d is invalid: %w", i, err)
+		}
+		contributions = append(contributions, srs2)
+	}
+
+	return &ceremony{
+		ccs:           ccs,
+		srs1:          srs1,
+		evals:         &evals,
+		contributions: contributions,
+	}, nil
+}
+
+// sameParameters reports whether the Phase 2 states have the same parameters
+// and challenge hash.
+func sameParameters(a, b *mpcsetup.Phase2) bool {
+	if !bytes.Equal(a.Hash, b.Hash) {
+		return false
+	}
+	pa, pb := a.Parameters, b.Parameters
+	if !pa.G1.Delta.Equal(&pb.G1.Delta) || !pa.G2.Delta.Equal(&pb.G2.Delta) {
+		return false
+	}
+	if len(pa.G1.L) != len(pb.G1.L) || len(pa.G1.Z) != len(pb.G1.Z) {
+		return false
+	}
+	for i := range pa.G1.L {
+		if !pa.G1.L[i].Equal(&pb.G1.L[i]) {
+			return false
+		}
+	}
+	for i := range pa.G1.Z {
+		if !pa.G1.Z[i].Equal(&pb.G1.Z[i]) {
+			return false
+		}
+	}
+	return true
+}
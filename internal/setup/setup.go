@@ -1,6 +1,7 @@
 package setup
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"os"
@@ -12,29 +13,128 @@ import (
 	cs "github.com/consensys/gnark/constraint/bls12-381"
 )
 
+// Phase1Source points to a response file from Phase 1 of the Powers of Tau
+// ceremony for the BLS12-381 curve. A zero Power is derived from the size of
+// the file, a non-zero one must match it.
+type Phase1Source struct {
+	Path  string
+	Power int
+}
+
 // setup generates proving and verifying keys for the given compiled constrained
 // system. It accepts path to the response file from Phase 1 of the Powers of Tau
-// ceremony for the BLS12-381 curve and the power of the ceremony.
+// ceremony for the BLS12-381 curve and the power of the ceremony. A zero power
+// is derived from the size of the file.
 // See the README.md for details on the Phase 1 response file. It makes
 // circuit-specific Phase 2 initialisation of the MPC ceremony and performs some
-// dummy contributions for Phase 2. In production environment, participant will
-// receive a []byte, deserialize it, add his contribution and send back to the
-// coordinator.
+// dummy contributions for Phase 2. For a real multi-party Phase 2 see the
+// ceremony functions in ceremony.go.
 func Setup(ccs constraint.ConstraintSystem, phase1ResponsePath string, inPow int) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	const nContributionsPhase2 = 3
+
+	srs1, err := ReadPhase1(phase1ResponsePath, inPow, ccs.GetNbConstraints())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Prepare for phase-2
+	var evals mpcsetup.Phase2Evaluations
+	r1cs, ok := ccs.(*cs.R1CS)
+	if !ok {
+		return nil, nil, errors.New("setup requires a BLS12-381 R1CS constraint system")
+	}
+	srs2, evals := mpcsetup.InitPhase2(r1cs, srs1)
+
+	// Make some dummy contributions for phase2. In practice, participant will
+	// receive a []byte, deserialize it, add his contribution and send back to
+	// coordinator, like it is done in https://github.com/bnb-chain/zkbnb-setup
+	// for BN254 elliptic curve.
+	for i := 0; i < nContributionsPhase2; i++ {
+		srs2.Contribute()
+	}
+
+	// Extract the proving and verifying keys
+	pk, vk := mpcsetup.ExtractKeys(srs1, &srs2, &evals, ccs.GetNbConstraints())
+	return &pk, &vk, nil
+}
+
+// RequiredPower returns the smallest power of the Phase 1 ceremony that
+// fits a circuit with nbConstraints constraints.
+func RequiredPower(nbConstraints int) int {
+	var pow int
+	for ; 1<<pow < nbConstraints; pow++ {
+	}
+	return pow
+}
+
+// Sizes of the sections of a response file.
+const (
+	blake2bHashSize = 64
+	// publicKeySize is the uncompressed public key of the contribution that
+	// follows the accumulator: 6 G1 and 3 G2 points.
+	publicKeySize = 6*2*curve.SizeOfG1AffineCompressed + 3*2*curve.SizeOfG2AffineCompressed
+	// maxPower is the largest power of the Powers of Tau ceremonies.
+	maxPower = 28
+)
+
+// responseSize returns the size of the accumulator of a response file of the
+// given power, all points in compressed form.
+func responseSize(pow int) int64 {
 	const (
-		nContributionsPhase2 = 3
-		blake2bHashSize      = 64
+		g1Size = curve.SizeOfG1AffineCompressed
+		g2Size = curve.SizeOfG2AffineCompressed
 	)
+	n := int64(1) << pow
+	return blake2bHashSize + (2*n-1)*g1Size + n*g2Size + 2*n*g1Size + g2Size
+}
 
+// ResponsePower derives the power of a response file from its size. The file
+// holds the compressed accumulator, followed by the public key of the
+// contribution or not. The sizes of power 2 with the key and of power 3
+// without it are the same, the file without the key wins.
+func ResponsePower(size int64) (int, error) {
+	for _, extra := range []int64{0, publicKeySize} {
+		for pow := 0; pow <= maxPower; pow++ {
+			if size == responseSize(pow)+extra {
+				return pow, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%d bytes is not the size of a compressed BLS12-381 response file", size)
+}
+
+// ReadPhase1 decodes the Phase 1 response file and truncates the SRS to the
+// smallest power of two that fits nbConstraints. The power of the file is
+// derived from its size, a non-zero inPow must match it.
+func ReadPhase1(phase1ResponsePath string, inPow int, nbConstraints int) (*mpcsetup.Phase1, error) {
 	f, err := os.Open(phase1ResponsePath)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	filePow, err := ResponsePower(info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("phase 1 response file %s: %w", phase1ResponsePath, err)
+	}
+	if inPow != 0 && inPow != filePow {
+		return nil, fmt.Errorf("phase 1 response file %s holds 2^%d powers, not 2^%d", phase1ResponsePath, filePow, inPow)
+	}
+	inPow = filePow
+
+	outPow := RequiredPower(nbConstraints)
+	if inPow < outPow {
+		return nil, fmt.Errorf("phase 1 power %d is too small: the circuit has %d constraints and needs at least power %d", inPow, nbConstraints, outPow)
 	}
 
 	// Skip hash of the previous contribution, don't need it for the MPC initialisation.
 	_, err = f.Seek(blake2bHashSize, 0)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to seek file: %w", err)
+		return nil, fmt.Errorf("failed to seek file: %w", err)
 	}
 	dec := curve.NewDecoder(f)
 
@@ -48,60 +148,43 @@ func Setup(ccs constraint.ConstraintSystem, phase1ResponsePath string, inPow int
 	// Accumulator serialization: https://github.com/filecoin-project/powersoftau/blob/ab8f85c28f04af5a99cfcc93a3b1f74c06f94105/src/accumulator.rs#L111
 	for i := range coef_g1 {
 		if err := dec.Decode(&coef_g1[i]); err != nil {
-			return nil, nil, fmt.Errorf("failed to decode coef_g1: %w", err)
+			return nil, fmt.Errorf("failed to decode coef_g1: %w", err)
 		}
 	}
 	for i := range coef_g2 {
 		if err := dec.Decode(&coef_g2[i]); err != nil {
-			return nil, nil, fmt.Errorf("failed to decode coef_g2: %w", err)
+			return nil, fmt.Errorf("failed to decode coef_g2: %w", err)
 		}
 	}
 	for i := range alpha_coef_g1 {
 		if err := dec.Decode(&alpha_coef_g1[i]); err != nil {
-			return nil, nil, fmt.Errorf("failed to decode alpha_coef_g1: %w", err)
+			return nil, fmt.Errorf("failed to decode alpha_coef_g1: %w", err)
 		}
 	}
 	for i := range beta_coef_g1 {
 		if err := dec.Decode(&beta_coef_g1[i]); err != nil {
-			return nil, nil, fmt.Errorf("failed to decode beta_coef_g1: %w", err)
+			return nil, fmt.Errorf("failed to decode beta_coef_g1: %w", err)
 		}
 	}
 	beta_g2 := &curve.G2Affine{}
 	if err := dec.Decode(beta_g2); err != nil {
-		return nil, nil, fmt.Errorf("failed to decode beta_g2: %w", err)
+		return nil, fmt.Errorf("failed to decode beta_g2: %w", err)
 	}
 
 	// Transform (take exactly those number of powers that needed for the given number of constraints).
-	var (
-		numConstraints = ccs.GetNbConstraints()
-		outPow         int
-	)
-	for ; 1<<outPow < numConstraints; outPow++ {
-	}
 	outN := int64(math.Pow(2, float64(outPow)))
 
 	// setup the SRS
-	srs1 := mpcsetup.Phase1{}
+	srs1 := &mpcsetup.Phase1{}
 	srs1.Parameters.G1.Tau = coef_g1[:2*outN-1]        // outN + (outN-1)
 	srs1.Parameters.G2.Tau = coef_g2[:outN]            // outN
 	srs1.Parameters.G1.AlphaTau = alpha_coef_g1[:outN] // outN
 	srs1.Parameters.G1.BetaTau = beta_coef_g1[:outN]   // outN
 	srs1.Parameters.G2.Beta = *beta_g2                 // 1
 
-	// Prepare for phase-2
-	var evals mpcsetup.Phase2Evaluations
-	r1cs := ccs.(*cs.R1CS)
-	srs2, evals := mpcsetup.InitPhase2(r1cs, &srs1)
+	// Phase 1 is not contributed to from here, the hash only has to be
+	// present so that the SRS can be serialized for the Phase 2 ceremony.
+	srs1.Hash = make([]byte, 32)
 
-	// Make some dummy contributions for phase2. In practice, participant will
-	// receive a []byte, deserialize it, add his contribution and send back to
-	// coordinator, like it is done in https://github.com/bnb-chain/zkbnb-setup
-	// for BN254 elliptic curve.
-	for i := 0; i < nContributionsPhase2; i++ {
-		srs2.Contribute()
-	}
-
-	// Extract the proving and verifying keys
-	pk, vk := mpcsetup.ExtractKeys(&srs1, &srs2, &evals, ccs.GetNbConstraints())
-	return &pk, &vk, nil
+	return srs1, nil
 }
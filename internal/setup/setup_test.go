@@ -0,0 +1,253 @@
+package setup
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"neo_zk_starter/store"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/groth16/bls12-381/mpcsetup"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+type cubeCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *cubeCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(c.Y, api.Mul(c.X, c.X, c.X))
+	return nil
+}
+
+// writeResponseFile writes a Phase 1 response file in the Powers of Tau
+// accumulator format from a locally generated Phase 1 and returns the Phase 1.
+func writeResponseFile(t *testing.T, path string, power int) *mpcsetup.Phase1 {
+	srs1 := mpcsetup.InitPhase1(power)
+	srs1.Contribute()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Hash of the previous contribution.
+	if _, err := f.Write(make([]byte, 64)); err != nil {
+		t.Fatal(err)
+	}
+
+	enc := curve.NewEncoder(f)
+	p := srs1.Parameters
+	for i := range p.G1.Tau {
+		if err := enc.Encode(&p.G1.Tau[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range p.G2.Tau {
+		if err := enc.Encode(&p.G2.Tau[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range p.G1.AlphaTau {
+		if err := enc.Encode(&p.G1.AlphaTau[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range p.G1.BetaTau {
+		if err := enc.Encode(&p.G1.BetaTau[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Encode(&p.G2.Beta); err != nil {
+		t.Fatal(err)
+	}
+	return &srs1
+}
+
+func TestCeremony(t *testing.T) {
+	const (
+		circuitName = "cube"
+		power       = 3
+	)
+
+	s := store.NewMemoryStore()
+	responsePath := filepath.Join(t.TempDir(), "response")
+	writeResponseFile(t, responsePath, power)
+
+	ccs, err := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, &cubeCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := InitCeremony(s, circuitName, ccs, responsePath, power); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := FinalizeCeremony(s, circuitName); err == nil {
+		t.Fatal("finalize must fail without contributions")
+	}
+
+	contribute := func(from, to int) {
+		r, err := s.Open(store.Data, circuitName, ContributionName(from))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		w, err := s.Create(store.Data, circuitName, ContributionName(to))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Contribute(r, w); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		contribute(i, i+1)
+	}
+
+	n, err := VerifyCeremony(s, circuitName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 verified contributions, got %d", n)
+	}
+
+	pk, vk, err := FinalizeCeremony(s, circuitName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := frontend.NewWitness(&cubeCircuit{X: 3, Y: 27}, ecc.BLS12_381.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw, _ := w.Public()
+	proof, err := groth16.Prove(ccs, pk, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, pw); err != nil {
+		t.Fatal(err)
+	}
+
+	// The initial state of another ceremony of the same circuit and Phase 1
+	// SRS has the same parameters but another challenge.
+	other := store.NewMemoryStore()
+	if err := InitCeremony(other, circuitName, ccs, responsePath, power); err != nil {
+		t.Fatal(err)
+	}
+	copyState := func(from, to store.ArtifactStore, name string) {
+		r, err := from.Open(store.Data, circuitName, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		w, err := to.Create(store.Data, circuitName, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	copyState(s, other, ContributionName(1))
+	copyState(s, other, ContributionName(2))
+	if _, err := VerifyCeremony(other, circuitName); err == nil {
+		t.Fatal("contributions of another ceremony must not verify")
+	}
+	copyState(s, other, ContributionName(0))
+	if _, err := VerifyCeremony(other, circuitName); err == nil {
+		t.Fatal("initial state of another ceremony must not verify")
+	}
+
+	// A contribution that skips the previous one must be rejected.
+	contribute(1, 3)
+	if _, err := VerifyCeremony(s, circuitName); err == nil {
+		t.Fatal("ceremony with an out of order contribution must not verify")
+	}
+}
+
+func TestReadPhase1Power(t *testing.T) {
+	responsePath := filepath.Join(t.TempDir(), "response")
+	writeResponseFile(t, responsePath, 2)
+
+	if RequiredPower(4) != 2 || RequiredPower(5) != 3 {
+		t.Fatal("unexpected required power")
+	}
+
+	// The power is derived from the size of the file.
+	srs1, err := ReadPhase1(responsePath, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(srs1.Parameters.G1.AlphaTau) != 4 {
+		t.Fatalf("expected 4 powers, got %d", len(srs1.Parameters.G1.AlphaTau))
+	}
+
+	// An explicit power that does not fit the circuit.
+	if _, err := ReadPhase1(responsePath, 2, 5); err == nil {
+		t.Fatal("power 2 must not fit 5 constraints")
+	}
+
+	// A response file with too few powers for the circuit.
+	if _, err := ReadPhase1(responsePath, 0, 5); err == nil {
+		t.Fatal("response file of power 2 must not fit 5 constraints")
+	}
+}
+
+// TestReadPhase1Larger reads a response file of a larger power than the
+// circuit needs, the SRS is its prefix.
+func TestReadPhase1Larger(t *testing.T) {
+	responsePath := filepath.Join(t.TempDir(), "response")
+	full := writeResponseFile(t, responsePath, 4)
+
+	if pow, err := ResponsePower(responseSize(4)); err != nil || pow != 4 {
+		t.Fatalf("expected power 4, got %d: %v", pow, err)
+	}
+	if pow, err := ResponsePower(responseSize(4) + publicKeySize); err != nil || pow != 4 {
+		t.Fatalf("expected power 4 with the public key, got %d: %v", pow, err)
+	}
+	if _, err := ResponsePower(responseSize(4) + 1); err == nil {
+		t.Fatal("unexpected size accepted")
+	}
+
+	srs1, err := ReadPhase1(responsePath, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, q := srs1.Parameters, full.Parameters
+	if len(p.G1.Tau) != 7 || len(p.G2.Tau) != 4 || len(p.G1.AlphaTau) != 4 || len(p.G1.BetaTau) != 4 {
+		t.Fatal("unexpected SRS size")
+	}
+	for i := range p.G1.Tau {
+		if !p.G1.Tau[i].Equal(&q.G1.Tau[i]) {
+			t.Fatalf("G1 tau %d differs", i)
+		}
+	}
+	for i := range p.G2.Tau {
+		if !p.G2.Tau[i].Equal(&q.G2.Tau[i]) || !p.G1.AlphaTau[i].Equal(&q.G1.AlphaTau[i]) || !p.G1.BetaTau[i].Equal(&q.G1.BetaTau[i]) {
+			t.Fatalf("power %d differs", i)
+		}
+	}
+	if !p.G2.Beta.Equal(&q.G2.Beta) {
+		t.Fatal("beta differs")
+	}
+
+	// An explicit power must match the file.
+	if _, err := ReadPhase1(responsePath, 2, 3); err == nil {
+		t.Fatal("power 2 must not match a file of power 4")
+	}
+}
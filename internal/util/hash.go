@@ -0,0 +1,61 @@
+package util
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	blsMimc "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/mimc"
+)
+
+// Hash is a hash function of BLS12-381 scalar field elements to a field
+// element. The circuits compute the same hashes with circuits/hasher.
+type Hash string
+
+const (
+	// MiMC is the MiMC hash of gnark, the default of all circuits.
+	MiMC Hash = "mimc"
+	// SHA256 hashes the 32 byte big endian encodings of the elements, the
+	// digest is reduced into the field.
+	SHA256 Hash = "sha256"
+)
+
+// Hashes lists the supported hash functions.
+var Hashes = []Hash{MiMC, SHA256}
+
+// HashInputs hashes the inputs with h, the empty hash stands for MiMC. Like
+// HashInputsToString it accepts inputs as uint64 or *big.Int, every input is
+// a field element.
+func HashInputs(h Hash, inputs []interface{}) *big.Int {
+	elements := make([]fr.Element, len(inputs))
+	for i, input := range inputs {
+		switch v := input.(type) {
+		case uint64:
+			elements[i].SetUint64(v)
+		case *big.Int:
+			elements[i].SetBigInt(v)
+		default:
+			panic("Unsupported type")
+		}
+	}
+
+	switch h {
+	case MiMC, "":
+		hasher := blsMimc.NewMiMC()
+		for i := range elements {
+			hasher.Write(elements[i].Marshal())
+		}
+		return new(big.Int).SetBytes(hasher.Sum(nil))
+	case SHA256:
+		hasher := sha256.New()
+		for i := range elements {
+			hasher.Write(elements[i].Marshal())
+		}
+		var out fr.Element
+		out.SetBigInt(new(big.Int).SetBytes(hasher.Sum(nil)))
+		return out.BigInt(new(big.Int))
+	default:
+		panic(fmt.Sprintf("unsupported hash %q", h))
+	}
+}
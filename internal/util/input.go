@@ -0,0 +1,80 @@
+package util
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// Number is a JSON input value given either as a number or as a decimal or 0x
+// prefixed hex string. Strings allow values beyond the float precision of most
+// JSON encoders.
+type Number string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (n *Number) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*n = Number(s)
+		return nil
+	}
+	// Anything else is kept as is and rejected when parsed, the parse error
+	// names the input field which the decoder does not do for this method.
+	*n = Number(data)
+	return nil
+}
+
+// DecodeInput decodes the JSON input of a circuit into v. Unknown fields are
+// rejected, so typos in field names do not silently produce zero values.
+func DecodeInput(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return fmt.Errorf("%s: unexpected JSON %s", typeErr.Field, typeErr.Value)
+		}
+		return fmt.Errorf("invalid input: %w", err)
+	}
+	return nil
+}
+
+// ParseFieldElement parses the named input field as an element of the
+// BLS12-381 scalar field.
+func ParseFieldElement(field string, n Number) (*big.Int, error) {
+	if n == "" {
+		return nil, fmt.Errorf("%s: is required", field)
+	}
+	v, ok := new(big.Int).SetString(string(n), 0)
+	if !ok {
+		return nil, fmt.Errorf("%s: invalid number %q", field, n)
+	}
+	if v.Sign() < 0 {
+		return nil, fmt.Errorf("%s: must not be negative", field)
+	}
+	if v.Cmp(ecc.BLS12_381.ScalarField()) >= 0 {
+		return nil, fmt.Errorf("%s: exceeds the scalar field", field)
+	}
+	return v, nil
+}
+
+// ParseHex decodes the named hex input field, the 0x prefix is optional.
+func ParseHex(field, s string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("%s: is required", field)
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid hex: %w", field, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,45 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/nspcc-dev/neo-go/pkg/core/transaction"
+	"github.com/nspcc-dev/neo-go/pkg/smartcontract/manifest"
+)
+
+// verifierOverhead is an upper bound of the verifier contract script without
+// the embedded verifying key, the deploy transaction adds the manifest and
+// the transaction fields to it.
+const verifierOverhead = 4096
+
+// CheckVerifierLimits estimates the size of the verifier contract generated for
+// the verifying key and reports an error when it cannot be deployed. The
+// contract embeds the key with one G1 point per public input.
+func CheckVerifierLimits(circuitName string, vk groth16.VerifyingKey) error {
+	nbPublic := vk.NbPublicWitness()
+	// alpha in G1, beta, gamma and delta in G2, the IC points in G1
+	size := 48 + 3*96 + 48*(nbPublic+1) + verifierOverhead
+	if size > transaction.MaxTransactionSize {
+		return fmt.Errorf("verifier contract of %s needs about %d bytes for %d public inputs, "+
+			"more than the %d bytes a deploy transaction may have: reduce the number of public inputs, "+
+			"e.g. by hashing them into a single public commitment",
+			circuitName, size, nbPublic, transaction.MaxTransactionSize)
+	}
+	return nil
+}
+
+// CheckContractLimits reports an error when the compiled contract cannot be
+// deployed because of the transaction or manifest size limits.
+func CheckContractLimits(circuitName string, nefData, manifestData []byte) error {
+	if len(manifestData) > manifest.MaxManifestSize {
+		return fmt.Errorf("manifest of the %s verifier contract is %d bytes, the limit is %d",
+			circuitName, len(manifestData), manifest.MaxManifestSize)
+	}
+	if size := len(nefData) + len(manifestData); size > transaction.MaxTransactionSize {
+		return fmt.Errorf("verifier contract of %s is %d bytes with its manifest, "+
+			"more than the %d bytes a deploy transaction may have",
+			circuitName, size, transaction.MaxTransactionSize)
+	}
+	return nil
+}
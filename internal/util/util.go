@@ -1,6 +1,7 @@
 package util
 
 import (
+	"bytes"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -10,9 +11,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"neo_zk_starter/store"
+
 	"github.com/consensys/gnark-crypto/ecc"
-	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
-	blsMimc "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/mimc"
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/constraint"
 	sc "github.com/nspcc-dev/neo-go/cli/smartcontract"
@@ -20,76 +21,65 @@ import (
 	"github.com/nspcc-dev/neo-go/pkg/smartcontract/manifest"
 )
 
-func ensureDataDir() error {
-	dataDir := filepath.Join("data")
-	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
-		return os.MkdirAll(dataDir, 0755)
-	}
-	return nil
+// WriteData stores data as an artifact of the circuit in the data namespace.
+func WriteData(s store.ArtifactStore, circuitName, name string, data io.WriterTo) error {
+	return writeArtifact(s, store.Data, circuitName, name, data)
 }
 
-func WriteDataToFile(circuitName, filename string, data io.WriterTo) error {
-	if err := ensureDataDir(); err != nil {
-		return fmt.Errorf("failed to create data directory: %w", err)
-	}
-	file, err := os.Create(filepath.Join("data", fmt.Sprintf("%s_%s", circuitName, filename)))
+// ReadData reads an artifact of the circuit from the data namespace into data.
+func ReadData(s store.ArtifactStore, circuitName, name string, data io.ReaderFrom) error {
+	return readArtifact(s, store.Data, circuitName, name, data)
+}
+
+func writeArtifact(s store.ArtifactStore, ns store.Namespace, circuitName, name string, data io.WriterTo) error {
+	w, err := s.Create(ns, circuitName, name)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return err
 	}
-	defer file.Close()
 
-	_, err = data.WriteTo(file)
+	_, err = data.WriteTo(w)
 	if err != nil {
+		w.Close()
 		return fmt.Errorf("failed to write data: %w", err)
 	}
-	return nil
+	return w.Close()
 }
 
-func ReadProvingKeyFromFile(circuitName string) (groth16.ProvingKey, error) {
-	file, err := os.Open(filepath.Join("data", fmt.Sprintf("%s_prover_key", circuitName)))
+func readArtifact(s store.ArtifactStore, ns store.Namespace, circuitName, name string, data io.ReaderFrom) error {
+	r, err := s.Open(ns, circuitName, name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open prover key file: %w", err)
+		return err
 	}
-	defer file.Close()
+	defer r.Close()
 
-	pk := groth16.NewProvingKey(ecc.BLS12_381)
-	_, err = pk.ReadFrom(file)
+	_, err = data.ReadFrom(r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read prover key: %w", err)
+		return fmt.Errorf("failed to read data: %w", err)
 	}
-
-	return pk, nil
+	return nil
 }
 
-func ReadVerifyingKeyFromFile(circuitName string) (groth16.VerifyingKey, error) {
-	file, err := os.Open(filepath.Join("data", fmt.Sprintf("%s_verifier_key", circuitName)))
-	if err != nil {
-		return nil, fmt.Errorf("failed to open verifier key file: %w", err)
+func ReadProvingKey(s store.ArtifactStore, circuitName string) (groth16.ProvingKey, error) {
+	pk := groth16.NewProvingKey(ecc.BLS12_381)
+	if err := ReadData(s, circuitName, "prover_key", pk); err != nil {
+		return nil, fmt.Errorf("failed to read prover key: %w", err)
 	}
-	defer file.Close()
+	return pk, nil
+}
 
+func ReadVerifyingKey(s store.ArtifactStore, circuitName string) (groth16.VerifyingKey, error) {
 	vk := groth16.NewVerifyingKey(ecc.BLS12_381)
-	_, err = vk.ReadFrom(file)
-	if err != nil {
+	if err := ReadData(s, circuitName, "verifier_key", vk); err != nil {
 		return nil, fmt.Errorf("failed to read verifier key: %w", err)
 	}
-
 	return vk, nil
 }
 
-func ReadConstraintSystemFromFile(circuitName string) (constraint.ConstraintSystem, error) {
-	file, err := os.Open(filepath.Join("data", fmt.Sprintf("%s_r1cs", circuitName)))
-	if err != nil {
-		return nil, fmt.Errorf("failed to open r1cs file: %w", err)
-	}
-	defer file.Close()
-
+func ReadConstraintSystem(s store.ArtifactStore, circuitName string) (constraint.ConstraintSystem, error) {
 	ccs := groth16.NewCS(ecc.BLS12_381)
-	_, err = ccs.ReadFrom(file)
-	if err != nil {
+	if err := ReadData(s, circuitName, "r1cs", ccs); err != nil {
 		return nil, fmt.Errorf("failed to read r1cs: %w", err)
 	}
-
 	return ccs, nil
 }
 
@@ -109,43 +99,67 @@ func StringToBigInt(str string, base int) *big.Int {
 // Have to instantiate inputs as field elements to make sure
 // that hash is computed using the same bytes chunks as the snark field.
 // Accepts inputs as uint64 or *big.Int.
+// Other hash functions are computed with HashInputs.
 func HashInputsToString(inputs []interface{}) string {
-	var buf fr.Element
-	hasher := blsMimc.NewMiMC()
-
-	for _, input := range inputs {
-		switch v := input.(type) {
-		case uint64:
-			buf.SetUint64(v)
-			hasher.Write(buf.Marshal())
-		case *big.Int:
-			buf.SetBigInt(v)
-			hasher.Write(buf.Marshal())
-		default:
-			panic("Unsupported type")
-		}
-	}
-	hash := new(big.Int).SetBytes(hasher.Sum(nil)).String()
-	return hash
+	return HashInputs(MiMC, inputs).String()
 }
 
+// Verifier contract artifacts, stored in the contract namespace. go.mod and
+// go.sum are shared by all circuits.
+const (
+	VerifierSource   = "verifier.go"
+	VerifierConfig   = "verifier.yml"
+	VerifierNEF      = "verifier.nef"
+	VerifierManifest = "verifier.manifest.json"
+	VerifierDebug    = "verifier.dbginfo"
+	VerifierBindings = "verifier.bindings.yml"
+	ContractGoMod    = "go.mod"
+	ContractGoSum    = "go.sum"
+)
+
 // A method to compile the verifier contract. Most of
 // the code is the same as the NeoGo CLI compile command.
-func CompileContract(circuitName string) error {
-	src := fmt.Sprintf("contract/%s-verifier.go", circuitName)
-	if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+// Stores that do not keep plain files get the contract
+// compiled in a temporary directory.
+func CompileContract(s store.ArtifactStore, circuitName string) error {
+	if ok, err := s.Exists(store.Contract, circuitName, VerifierSource); err != nil {
+		return err
+	} else if !ok {
 		println("Verifier contract not found. Run the build command first.")
 		return nil
 	}
 
+	var dir string
+	if loc, ok := s.(store.Locator); ok {
+		dir = filepath.Dir(loc.Path(store.Contract, circuitName, VerifierSource))
+	} else {
+		tmp, err := os.MkdirTemp("", "contract")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+
+		if err := copyFromStore(s, dir, circuitName, VerifierSource, VerifierConfig); err != nil {
+			return err
+		}
+		if err := copyFromStore(s, dir, "", ContractGoMod, ContractGoSum); err != nil {
+			return err
+		}
+	}
+	path := func(name string) string {
+		return filepath.Join(dir, store.FileName(store.Contract, circuitName, name))
+	}
+
+	src := path(VerifierSource)
 	if len(src) == 0 {
 		return fmt.Errorf("no input file was found")
 	}
-	manifestFile := fmt.Sprintf("contract/%s-verifier.manifest.json", circuitName)
-	confFile := fmt.Sprintf("contract/%s-verifier.yml", circuitName)
-	debugFile := fmt.Sprintf("contract/%s-verifier.dbginfo", circuitName)
-	out := fmt.Sprintf("contract/%s-verifier.nef", circuitName)
-	bindings := fmt.Sprintf("contract/%s-verifier.bindings.yml", circuitName)
+	manifestFile := path(VerifierManifest)
+	confFile := path(VerifierConfig)
+	debugFile := path(VerifierDebug)
+	out := path(VerifierNEF)
+	bindings := path(VerifierBindings)
 	if len(confFile) == 0 && (len(manifestFile) != 0 || len(debugFile) != 0 || len(bindings) != 0) {
 		return fmt.Errorf("no config file was found")
 	}
@@ -210,7 +224,59 @@ func CompileContract(circuitName string) error {
 	if err != nil {
 		return fmt.Errorf("failed to compile: %w", err)
 	}
+	nefData, err := os.ReadFile(out)
+	if err != nil {
+		return err
+	}
+	manifestData, err := os.ReadFile(manifestFile)
+	if err != nil {
+		return err
+	}
+	if err := CheckContractLimits(circuitName, nefData, manifestData); err != nil {
+		return err
+	}
+	if _, ok := s.(store.Locator); !ok {
+		if err := copyToStore(s, dir, circuitName, VerifierNEF, VerifierManifest, VerifierDebug, VerifierBindings); err != nil {
+			return err
+		}
+	}
 	println("Contract compiled successfully:")
 	println(hex.EncodeToString(result))
 	return nil
 }
+
+// copyFromStore writes the contract artifacts of the circuit into dir.
+func copyFromStore(s store.ArtifactStore, dir, circuitName string, names ...string) error {
+	for _, name := range names {
+		r, err := s.Open(store.Contract, circuitName, name)
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, store.FileName(store.Contract, circuitName, name)), data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// copyToStore stores the contract files of the circuit found in dir.
+func copyToStore(s store.ArtifactStore, dir, circuitName string, names ...string) error {
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, store.FileName(store.Contract, circuitName, name)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if err := writeArtifact(s, store.Contract, circuitName, name, bytes.NewReader(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
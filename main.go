@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 
@@ -9,8 +10,12 @@ import (
 	"neo_zk_starter/circuits"
 	_ "neo_zk_starter/circuits/all"
 	"neo_zk_starter/internal/build"
+	"neo_zk_starter/internal/setup"
 	"neo_zk_starter/internal/util"
+	"neo_zk_starter/store"
 
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
 	"github.com/urfave/cli"
 )
 
@@ -18,6 +23,17 @@ func main() {
 	app := &cli.App{
 		Name:  "zk circuit verifier",
 		Usage: "build zk circuits, generate keys, prove and verify computations, compile and deploy verifier contracts",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "root",
+				Value: ".",
+				Usage: "Directory holding the data/ and contract/ artifacts",
+			},
+			cli.BoolFlag{
+				Name:  "content-addressed",
+				Usage: "Store artifacts by content hash under the root directory",
+			},
+		},
 		Commands: []cli.Command{
 			{
 				Name:    "build",
@@ -26,8 +42,16 @@ func main() {
 				Action: func(ctx *cli.Context) error {
 					circuitName := ctx.String("circuit")
 					rebuild := ctx.Bool("rebuild")
-					build.Build(circuitName, rebuild)
-					return nil
+
+					var phase1 *setup.Phase1Source
+					if path := ctx.String("phase1"); path != "" {
+						phase1 = &setup.Phase1Source{Path: path, Power: ctx.Int("power")}
+					} else if ctx.IsSet("power") {
+						return fmt.Errorf("--power requires --phase1")
+					}
+
+					_, err := build.Build(openStore(ctx), circuitName, rebuild, phase1)
+					return err
 				},
 				Flags: []cli.Flag{
 					cli.StringFlag{
@@ -39,25 +63,52 @@ func main() {
 						Name:  "rebuild, r",
 						Usage: "Force rebuild of the circuit",
 					},
+					cli.StringFlag{
+						Name:  "phase1",
+						Usage: "Path to the Phase 1 (Powers of Tau) response file, keys are insecure without it",
+					},
+					cli.IntFlag{
+						Name:  "power",
+						Usage: "Power of the Phase 1 response file, checked against its size (default: derived from the size)",
+					},
 				},
 			},
 			{
 				Name:    "prove",
 				Aliases: []string{"p"},
-				Usage:   "Generate and verify a proof using test inputs",
+				Usage:   "Generate and verify a proof from a JSON input, or the circuit test input",
 				Action: func(ctx *cli.Context) error {
 					circuitName := ctx.String("circuit")
 
-					// Get circuit and its test input
+					// Check the flags before the proof is spent
+					format := api.FormatJSON
+					switch ctx.String("format") {
+					case "json":
+					case "binary":
+						format = api.FormatBinary
+					default:
+						return fmt.Errorf("unknown format %s, use json or binary", ctx.String("format"))
+					}
+
+					// Get circuit and its input, the test input unless one is given
 					circuit, exists := circuits.Get(circuitName)
 					if !exists {
 						return fmt.Errorf("circuit not found: %s", circuitName)
 					}
 
-					input := circuit.ValidInput()
+					var input interface{} = circuit.ValidInput()
+					if inputPath := ctx.String("input"); inputPath != "" {
+						data, err := os.ReadFile(inputPath)
+						if err != nil {
+							return err
+						}
+						if input, err = circuits.ParseInput(circuit, data); err != nil {
+							return fmt.Errorf("invalid input %s: %w", inputPath, err)
+						}
+					}
 
 					// Generate proof
-					result, err := api.GenerateProof(circuitName, input)
+					result, err := api.GenerateProof(openStore(ctx), circuitName, input)
 					if err != nil {
 						return fmt.Errorf("failed to generate proof: %v", err)
 					}
@@ -68,12 +119,28 @@ func main() {
 					}
 
 					// Verify the proof
-					verified, err := api.VerifyProof(result.Proof, result.VerifyingKey, result.PublicWitness)
+					envelope, err := result.Envelope()
+					if err != nil {
+						return err
+					}
+					verified, err := api.VerifyProof(envelope, result.VerifyingKey)
 					if err != nil {
 						return fmt.Errorf("failed to verify proof: %v", err)
 					}
 
 					fmt.Printf("Proof generated and verified: %v\n", verified)
+
+					// Write the proof, public witness and verify arguments
+					if outPath := ctx.String("out"); outPath != "" {
+						data, err := api.Marshal(envelope, format)
+						if err != nil {
+							return err
+						}
+						if err := os.WriteFile(outPath, data, 0644); err != nil {
+							return err
+						}
+						fmt.Println("Proof written to", outPath)
+					}
 					return nil
 				},
 				Flags: []cli.Flag{
@@ -82,6 +149,116 @@ func main() {
 						Value: "hash_commit",
 						Usage: fmt.Sprintf("Name of the circuit to prove. Available: %v", circuits.ListCircuits()),
 					},
+					cli.StringFlag{
+						Name:  "input, i",
+						Usage: "Path to the JSON input of the circuit (default: the circuit test input)",
+					},
+					cli.StringFlag{
+						Name:  "out, o",
+						Usage: "Path to write the proof envelope to",
+					},
+					cli.StringFlag{
+						Name:  "format",
+						Value: "json",
+						Usage: "Encoding of the proof envelope, json or binary",
+					},
+				},
+			},
+			{
+				Name:    "verify",
+				Aliases: []string{"v"},
+				Usage:   "Verify a proof envelope or a raw proof against a verifying key and public inputs",
+				Action: func(ctx *cli.Context) error {
+					proofPath := ctx.String("proof")
+					if proofPath == "" {
+						return fmt.Errorf("--proof is required")
+					}
+					if ctx.IsSet("public-witness") && ctx.IsSet("inputs") {
+						return fmt.Errorf("--public-witness and --inputs are mutually exclusive")
+					}
+
+					data, err := os.ReadFile(proofPath)
+					if err != nil {
+						return err
+					}
+					// A raw proof carries neither the circuit nor the public witness
+					circuitName := ctx.String("circuit")
+					envelope, err := api.Unmarshal(data)
+					var rawProof groth16.Proof
+					if err != nil {
+						var rawErr error
+						if rawProof, rawErr = api.DecodeRawProof(data); rawErr != nil {
+							return fmt.Errorf("failed to read proof %s, it is neither an envelope (%v) nor a raw proof (%v)", proofPath, err, rawErr)
+						}
+						if circuitName == "" && ctx.String("vk") == "" {
+							return fmt.Errorf("--circuit or --vk is required for a raw proof")
+						}
+						if !ctx.IsSet("public-witness") && !ctx.IsSet("inputs") {
+							return fmt.Errorf("--public-witness or --inputs is required for a raw proof")
+						}
+					} else if circuitName == "" {
+						circuitName = envelope.Circuit
+					}
+
+					// The circuit does not need to be registered, only its verifying key is used.
+					var vk groth16.VerifyingKey
+					if vkPath := ctx.String("vk"); vkPath != "" {
+						vk, err = readVerifyingKey(vkPath)
+					} else {
+						vk, err = util.ReadVerifyingKey(openStore(ctx), circuitName)
+					}
+					if err != nil {
+						return fmt.Errorf("failed to read verifying key of %s: %w", circuitName, err)
+					}
+
+					var publicWitness []byte
+					if path := ctx.String("public-witness"); path != "" {
+						if publicWitness, err = os.ReadFile(path); err != nil {
+							return err
+						}
+					} else if inputs := ctx.String("inputs"); inputs != "" {
+						publicWitness = []byte(inputs)
+					}
+					if publicWitness != nil {
+						w, err := api.ParsePublicWitness(publicWitness)
+						if err != nil {
+							return err
+						}
+						if rawProof != nil {
+							envelope, err = api.NewEnvelope(circuitName, rawProof, vk, w)
+						} else {
+							err = envelope.SetPublicWitness(w)
+						}
+						if err != nil {
+							return err
+						}
+					}
+
+					verified, err := api.VerifyProof(envelope, vk)
+					fmt.Printf("Proof of %s verified: %v\n", circuitName, verified)
+					return err
+				},
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "proof, p",
+						Usage: "Path to the proof envelope, JSON or binary, or to a raw gnark proof",
+					},
+					cli.StringFlag{
+						Name:  "circuit, c",
+						Usage: "Name of the circuit (default: the circuit named in the proof)",
+					},
+					cli.StringFlag{
+						Name:  "vk",
+						Usage: "Path to the verifying key (default: data/<circuit>_verifier_key in the store)",
+					},
+					cli.StringFlag{
+						Name:  "public-witness, w",
+						Usage: "Path to the public witness, binary or a JSON list of public inputs (default: the one in the proof)",
+					},
+					cli.StringFlag{
+						Name:  "inputs, i",
+						Usage: `Public inputs as a JSON list, e.g. '["42", "0x2a"]'`,
+					},
 				},
 			},
 			{
@@ -90,7 +267,7 @@ func main() {
 				Usage:   "Compile the Groth16 verifier contract, ready for deployment.",
 				Action: func(ctx *cli.Context) error {
 					circuitName := ctx.String("circuit")
-					err := util.CompileContract(circuitName)
+					err := util.CompileContract(openStore(ctx), circuitName)
 					if err != nil {
 						return err
 					}
@@ -104,6 +281,184 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:  "ceremony",
+				Usage: "Run a multi-party Phase 2 trusted setup ceremony for a circuit",
+				Subcommands: []cli.Command{
+					{
+						Name:  "init",
+						Usage: "Initialise Phase 2 from a Phase 1 response file",
+						Action: func(ctx *cli.Context) error {
+							circuitName := ctx.String("circuit")
+							phase1 := ctx.String("phase1")
+							power := ctx.Int("power")
+							if phase1 == "" {
+								return fmt.Errorf("--phase1 is required")
+							}
+
+							circuit, exists := circuits.Get(circuitName)
+							if !exists {
+								return fmt.Errorf("circuit not found: %s", circuitName)
+							}
+							ccs, err := build.Compile(circuit)
+							if err != nil {
+								return fmt.Errorf("failed to compile circuit: %w", err)
+							}
+
+							if err := setup.InitCeremony(openStore(ctx), circuitName, ccs, phase1, power); err != nil {
+								return fmt.Errorf("failed to initialise ceremony: %w", err)
+							}
+							fmt.Printf("Ceremony initialised, first contribution input: %s\n", store.FileName(store.Data, circuitName, setup.ContributionName(0)))
+							challenge, err := setup.CeremonyChallenge(openStore(ctx), circuitName)
+							if err != nil {
+								return err
+							}
+							fmt.Printf("Ceremony challenge, publish it with the ceremony: %x\n", challenge)
+							return nil
+						},
+						Flags: []cli.Flag{
+							cli.StringFlag{
+								Name:  "circuit, c",
+								Value: "hash_commit",
+								Usage: fmt.Sprintf("Name of the circuit. Available: %v", circuits.ListCircuits()),
+							},
+							cli.StringFlag{
+								Name:  "phase1",
+								Usage: "Path to the Phase 1 (Powers of Tau) response file",
+							},
+							cli.IntFlag{
+								Name:  "power",
+								Usage: "Power of the Phase 1 response file, checked against its size (default: derived from the size)",
+							},
+						},
+					},
+					{
+						Name:  "contribute",
+						Usage: "Add a contribution to the latest Phase 2 state",
+						Action: func(ctx *cli.Context) error {
+							circuitName := ctx.String("circuit")
+							in := ctx.String("in")
+							out := ctx.String("out")
+							s := openStore(ctx)
+
+							// Without explicit paths, contribute on top of the latest
+							// state in the store.
+							var latest int
+							if in == "" || out == "" {
+								var err error
+								latest, err = setup.LatestContribution(s, circuitName)
+								if err != nil {
+									return err
+								}
+							}
+
+							var (
+								r   io.ReadCloser
+								w   io.WriteCloser
+								err error
+							)
+							if in != "" {
+								r, err = os.Open(in)
+							} else {
+								r, err = s.Open(store.Data, circuitName, setup.ContributionName(latest))
+							}
+							if err != nil {
+								return err
+							}
+							defer r.Close()
+
+							if out != "" {
+								w, err = os.Create(out)
+							} else {
+								out = store.FileName(store.Data, circuitName, setup.ContributionName(latest+1))
+								w, err = s.Create(store.Data, circuitName, setup.ContributionName(latest+1))
+							}
+							if err != nil {
+								return err
+							}
+
+							if err := setup.Contribute(r, w); err != nil {
+								w.Close()
+								return fmt.Errorf("failed to contribute: %w", err)
+							}
+							if err := w.Close(); err != nil {
+								return err
+							}
+							fmt.Printf("Contribution written to %s\n", out)
+							return nil
+						},
+						Flags: []cli.Flag{
+							cli.StringFlag{
+								Name:  "circuit, c",
+								Value: "hash_commit",
+								Usage: fmt.Sprintf("Name of the circuit. Available: %v", circuits.ListCircuits()),
+							},
+							cli.StringFlag{
+								Name:  "in",
+								Usage: "Phase 2 state file to contribute to (default: latest in the store)",
+							},
+							cli.StringFlag{
+								Name:  "out",
+								Usage: "Output file of the contribution (default: next in the store)",
+							},
+						},
+					},
+					{
+						Name:  "verify",
+						Usage: "Verify every contribution against the previous one",
+						Action: func(ctx *cli.Context) error {
+							circuitName := ctx.String("circuit")
+							n, err := setup.VerifyCeremony(openStore(ctx), circuitName)
+							if err != nil {
+								return fmt.Errorf("ceremony verification failed: %w", err)
+							}
+							fmt.Printf("Verified %d contribution(s)\n", n)
+							return nil
+						},
+						Flags: []cli.Flag{
+							cli.StringFlag{
+								Name:  "circuit, c",
+								Value: "hash_commit",
+								Usage: fmt.Sprintf("Name of the circuit. Available: %v", circuits.ListCircuits()),
+							},
+						},
+					},
+					{
+						Name:  "finalize",
+						Usage: "Verify the ceremony and write the final proving/verifying keys",
+						Action: func(ctx *cli.Context) error {
+							circuitName := ctx.String("circuit")
+							s := openStore(ctx)
+							pk, vk, err := setup.FinalizeCeremony(s, circuitName)
+							if err != nil {
+								return fmt.Errorf("failed to finalize ceremony: %w", err)
+							}
+							if err := util.WriteData(s, circuitName, "prover_key", pk); err != nil {
+								return err
+							}
+							if err := util.WriteData(s, circuitName, "verifier_key", vk); err != nil {
+								return err
+							}
+							ccs, err := util.ReadConstraintSystem(s, circuitName)
+							if err != nil {
+								return err
+							}
+							if err := build.WriteFingerprint(s, circuitName, ccs, build.SetupCeremony); err != nil {
+								return err
+							}
+							fmt.Println("Ceremony finalized, keys written to the store")
+							return nil
+						},
+						Flags: []cli.Flag{
+							cli.StringFlag{
+								Name:  "circuit, c",
+								Value: "hash_commit",
+								Usage: fmt.Sprintf("Name of the circuit. Available: %v", circuits.ListCircuits()),
+							},
+						},
+					},
+				},
+			},
 		},
 	}
 
@@ -111,3 +466,26 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// openStore returns the artifact store selected by the global flags.
+func openStore(ctx *cli.Context) store.ArtifactStore {
+	if ctx.GlobalBool("content-addressed") {
+		return store.NewContentAddressedStore(ctx.GlobalString("root"))
+	}
+	return store.NewFileStore(ctx.GlobalString("root"))
+}
+
+// readVerifyingKey reads a BLS12-381 verifying key from a file.
+func readVerifyingKey(path string) (groth16.VerifyingKey, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vk := groth16.NewVerifyingKey(ecc.BLS12_381)
+	if _, err := vk.ReadFrom(f); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
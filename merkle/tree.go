@@ -0,0 +1,366 @@
+// Package merkle builds MiMC Merkle trees over the BLS12-381 scalar field that
+// hash exactly like the Merkle circuits, so their roots and paths can be used
+// as circuit inputs directly.
+package merkle
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	blsMimc "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/mimc"
+)
+
+// MaxDepth is the largest supported tree depth, leaf indices are uint64.
+const MaxDepth = 64
+
+// treeMagic starts every serialized tree.
+var treeMagic = []byte("NZKM")
+
+// Leaf is an account leaf of a rollup state tree.
+type Leaf struct {
+	SenderKey *big.Int
+	Balance   *big.Int
+	Nonce     *big.Int
+}
+
+// Hash returns the leaf hash, the MiMC hash of the leaf fields in order.
+func (l Leaf) Hash() *big.Int {
+	return HashElements(l.SenderKey, l.Balance, l.Nonce)
+}
+
+// HashElements returns the MiMC hash of the field elements, written one after
+// the other as the MiMC gadget of gnark does.
+func HashElements(elements ...*big.Int) *big.Int {
+	h := blsMimc.NewMiMC()
+	var buf fr.Element
+	for _, e := range elements {
+		buf.SetBigInt(e)
+		b := buf.Bytes()
+		h.Write(b[:])
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+func hashPair(left, right *fr.Element) fr.Element {
+	h := blsMimc.NewMiMC()
+	l, r := left.Bytes(), right.Bytes()
+	h.Write(l[:])
+	h.Write(r[:])
+
+	var out fr.Element
+	out.SetBytes(h.Sum(nil))
+	return out
+}
+
+// Tree is an append-only Merkle tree of fixed depth with updatable leaves.
+// Missing leaves are zero, the nodes above them are hashes of zero subtrees.
+// Only the nodes over existing leaves are kept, so deep trees stay small.
+type Tree struct {
+	depth int
+	// levels[0] are the leaves, levels[depth] holds the root once a leaf exists
+	levels [][]fr.Element
+	// zeros[i] is the root of an empty subtree of height i
+	zeros []fr.Element
+}
+
+// New returns an empty tree of the given depth, it holds up to 2^depth leaves.
+func New(depth int) (*Tree, error) {
+	if depth < 1 || depth > MaxDepth {
+		return nil, fmt.Errorf("depth must be between 1 and %d, got %d", MaxDepth, depth)
+	}
+
+	t := &Tree{
+		depth:  depth,
+		levels: make([][]fr.Element, depth+1),
+		zeros:  make([]fr.Element, depth+1),
+	}
+	for i := 1; i <= depth; i++ {
+		t.zeros[i] = hashPair(&t.zeros[i-1], &t.zeros[i-1])
+	}
+	return t, nil
+}
+
+// FromLeaves returns a tree of the given depth holding the leaf hashes.
+func FromLeaves(depth int, leafHashes []*big.Int) (*Tree, error) {
+	t, err := New(depth)
+	if err != nil {
+		return nil, err
+	}
+	for i, leaf := range leafHashes {
+		if _, err := t.Append(leaf); err != nil {
+			return nil, fmt.Errorf("leaf %d: %w", i, err)
+		}
+	}
+	return t, nil
+}
+
+// FromLeafStructs returns a tree of the given depth holding the hashes of the
+// leaves.
+func FromLeafStructs(depth int, leaves []Leaf) (*Tree, error) {
+	hashes := make([]*big.Int, len(leaves))
+	for i, leaf := range leaves {
+		hashes[i] = leaf.Hash()
+	}
+	return FromLeaves(depth, hashes)
+}
+
+// Depth returns the depth of the tree.
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// Len returns the number of leaves in the tree.
+func (t *Tree) Len() uint64 {
+	return uint64(len(t.levels[0]))
+}
+
+// Capacity returns the maximum number of leaves, zero stands for 2^64.
+func (t *Tree) Capacity() uint64 {
+	if t.depth == 64 {
+		return 0
+	}
+	return 1 << t.depth
+}
+
+// Root returns the root of the tree.
+func (t *Tree) Root() *big.Int {
+	if t.Len() == 0 {
+		return t.zeros[t.depth].BigInt(new(big.Int))
+	}
+	return t.levels[t.depth][0].BigInt(new(big.Int))
+}
+
+// Leaf returns the leaf hash at the index.
+func (t *Tree) Leaf(index uint64) (*big.Int, error) {
+	if index >= t.Len() {
+		return nil, fmt.Errorf("leaf %d does not exist, the tree has %d leaves", index, t.Len())
+	}
+	return t.levels[0][index].BigInt(new(big.Int)), nil
+}
+
+// Append adds the leaf hash after the last leaf and returns its index.
+func (t *Tree) Append(leafHash *big.Int) (uint64, error) {
+	leaf, err := toElement(leafHash)
+	if err != nil {
+		return 0, err
+	}
+	index := t.Len()
+	if c := t.Capacity(); c != 0 && index >= c {
+		return 0, fmt.Errorf("tree of depth %d is full", t.depth)
+	}
+
+	t.levels[0] = append(t.levels[0], leaf)
+	t.updatePath(index)
+	return index, nil
+}
+
+// Update replaces the leaf hash at the index.
+func (t *Tree) Update(index uint64, leafHash *big.Int) error {
+	leaf, err := toElement(leafHash)
+	if err != nil {
+		return err
+	}
+	if index >= t.Len() {
+		return fmt.Errorf("leaf %d does not exist, the tree has %d leaves", index, t.Len())
+	}
+
+	t.levels[0][index] = leaf
+	t.updatePath(index)
+	return nil
+}
+
+// updatePath recomputes the nodes from the leaf at the index up to the root.
+func (t *Tree) updatePath(index uint64) {
+	for level := 0; level < t.depth; level++ {
+		parent := index >> 1
+		left := t.node(level, parent<<1)
+		right := t.node(level, parent<<1|1)
+		h := hashPair(&left, &right)
+
+		if parent == uint64(len(t.levels[level+1])) {
+			t.levels[level+1] = append(t.levels[level+1], h)
+		} else {
+			t.levels[level+1][parent] = h
+		}
+		index = parent
+	}
+}
+
+func (t *Tree) node(level int, index uint64) fr.Element {
+	if index < uint64(len(t.levels[level])) {
+		return t.levels[level][index]
+	}
+	return t.zeros[level]
+}
+
+// Path returns the sibling hashes of the leaf at the index, from the leaf level
+// up.
+func (t *Tree) Path(index uint64) ([]*big.Int, error) {
+	if index >= t.Len() {
+		return nil, fmt.Errorf("leaf %d does not exist, the tree has %d leaves", index, t.Len())
+	}
+
+	siblings := make([]*big.Int, t.depth)
+	for level := 0; level < t.depth; level++ {
+		sibling := t.node(level, index^1)
+		siblings[level] = sibling.BigInt(new(big.Int))
+		index >>= 1
+	}
+	return siblings, nil
+}
+
+// Proof is a Merkle path of a leaf, the input of the merkle_membership
+// circuit. Index is the position of the leaf, its bits from the least
+// significant one are the path bits.
+type Proof struct {
+	LeafHash *big.Int
+	Index    uint64
+	Siblings []*big.Int // one per level, from the leaf level up
+	Root     *big.Int
+}
+
+// ComputeRoot hashes the leaf up to the root along its path, the same way the
+// tree and the circuits do.
+func ComputeRoot(leafHash *big.Int, index uint64, siblings []*big.Int) *big.Int {
+	current := leafHash
+	for i, sibling := range siblings {
+		left, right := current, sibling
+		if index>>i&1 == 1 {
+			left, right = sibling, current
+		}
+		current = HashElements(left, right)
+	}
+	return current
+}
+
+// Proof returns the path of the leaf at the index, the input of the
+// merkle_membership circuit when the tree depth matches the circuit depth.
+func (t *Tree) Proof(index uint64) (Proof, error) {
+	siblings, err := t.Path(index)
+	if err != nil {
+		return Proof{}, err
+	}
+	leaf, _ := t.Leaf(index)
+	return Proof{
+		LeafHash: leaf,
+		Index:    index,
+		Siblings: siblings,
+		Root:     t.Root(),
+	}, nil
+}
+
+func toElement(v *big.Int) (fr.Element, error) {
+	var e fr.Element
+	if v == nil {
+		return e, errors.New("leaf hash is nil")
+	}
+	if v.Sign() < 0 || v.Cmp(ecc.BLS12_381.ScalarField()) >= 0 {
+		return e, errors.New("leaf hash is not a field element")
+	}
+	e.SetBigInt(v)
+	return e, nil
+}
+
+// WriteTo implements io.WriterTo. A tree is written as its depth and leaves,
+// the inner nodes are recomputed when it is read back.
+func (t *Tree) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+
+	header := make([]byte, len(treeMagic)+1+8)
+	copy(header, treeMagic)
+	header[len(treeMagic)] = byte(t.depth)
+	binary.BigEndian.PutUint64(header[len(treeMagic)+1:], t.Len())
+	m, err := bw.Write(header)
+	n += int64(m)
+	if err != nil {
+		return n, err
+	}
+
+	for i := range t.levels[0] {
+		b := t.levels[0][i].Bytes()
+		m, err := bw.Write(b[:])
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
+
+// ReadFrom implements io.ReaderFrom, the tree is replaced by the one read.
+func (t *Tree) ReadFrom(r io.Reader) (int64, error) {
+	var n int64
+	header := make([]byte, len(treeMagic)+1+8)
+	m, err := io.ReadFull(r, header)
+	n += int64(m)
+	if err != nil {
+		return n, fmt.Errorf("failed to read tree header: %w", err)
+	}
+	if string(header[:len(treeMagic)]) != string(treeMagic) {
+		return n, errors.New("not a serialized Merkle tree")
+	}
+
+	read, err := New(int(header[len(treeMagic)]))
+	if err != nil {
+		return n, err
+	}
+	count := binary.BigEndian.Uint64(header[len(treeMagic)+1:])
+	if c := read.Capacity(); c != 0 && count > c {
+		return n, fmt.Errorf("tree of depth %d cannot hold %d leaves", read.depth, count)
+	}
+
+	br := bufio.NewReader(r)
+	buf := make([]byte, fr.Bytes)
+	for i := uint64(0); i < count; i++ {
+		m, err := io.ReadFull(br, buf)
+		n += int64(m)
+		if err != nil {
+			return n, fmt.Errorf("failed to read leaf %d: %w", i, err)
+		}
+		var leaf fr.Element
+		if err := leaf.SetBytesCanonical(buf); err != nil {
+			return n, fmt.Errorf("leaf %d: %w", i, err)
+		}
+		read.levels[0] = append(read.levels[0], leaf)
+		read.updatePath(i)
+	}
+
+	*t = *read
+	return n, nil
+}
+
+// WriteFile writes the tree to the file at path.
+func (t *Tree) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := t.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadFile reads a tree written by WriteFile.
+func ReadFile(path string) (*Tree, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t := &Tree{}
+	if _, err := t.ReadFrom(f); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
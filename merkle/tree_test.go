@@ -0,0 +1,126 @@
+package merkle
+
+import (
+	"bytes"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"neo_zk_starter/internal/util"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+func leaves(n int) []*big.Int {
+	hashes := make([]*big.Int, n)
+	for i := range hashes {
+		hashes[i] = Leaf{SenderKey: big.NewInt(int64(i)), Balance: big.NewInt(100), Nonce: big.NewInt(0)}.Hash()
+	}
+	return hashes
+}
+
+func TestHashMatchesUtil(t *testing.T) {
+	a, b := big.NewInt(1337), big.NewInt(9001)
+	expected := util.StringToBigInt(util.HashInputsToString([]interface{}{a, b}), 10)
+	if HashElements(a, b).Cmp(expected) != 0 {
+		t.Fatal("hash differs from util.HashInputsToString")
+	}
+}
+
+func TestTree(t *testing.T) {
+	const depth = 3
+	hashes := leaves(5)
+	tree, err := FromLeaves(depth, hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The root of a full binary tree computed level by level, missing leaves are zero.
+	level := make([]*big.Int, 1<<depth)
+	for i := range level {
+		level[i] = new(big.Int)
+		if i < len(hashes) {
+			level[i] = hashes[i]
+		}
+	}
+	for len(level) > 1 {
+		next := make([]*big.Int, len(level)/2)
+		for i := range next {
+			next[i] = HashElements(level[2*i], level[2*i+1])
+		}
+		level = next
+	}
+	if tree.Root().Cmp(level[0]) != 0 {
+		t.Fatal("unexpected root")
+	}
+
+	// Every path hashes up to the root.
+	for i := uint64(0); i < tree.Len(); i++ {
+		siblings, err := tree.Path(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ComputeRoot(hashes[i], i, siblings).Cmp(tree.Root()) != 0 {
+			t.Fatalf("path of leaf %d does not match the root", i)
+		}
+	}
+
+	// Updates change the root like rebuilding the tree does.
+	hashes[2] = big.NewInt(7)
+	if err := tree.Update(2, hashes[2]); err != nil {
+		t.Fatal(err)
+	}
+	rebuilt, _ := FromLeaves(depth, hashes)
+	if tree.Root().Cmp(rebuilt.Root()) != 0 {
+		t.Fatal("updated root differs from the rebuilt one")
+	}
+
+	if err := tree.Update(5, big.NewInt(1)); err == nil {
+		t.Fatal("update of a missing leaf must fail")
+	}
+	for tree.Len() < 1<<depth {
+		if _, err := tree.Append(big.NewInt(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tree.Append(big.NewInt(1)); err == nil {
+		t.Fatal("append to a full tree must fail")
+	}
+	if _, err := tree.Append(ecc.BLS12_381.ScalarField()); err == nil {
+		t.Fatal("leaf outside of the field must be rejected")
+	}
+}
+
+func TestSerialization(t *testing.T) {
+	tree, err := FromLeaves(32, leaves(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := tree.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read := &Tree{}
+	if _, err := read.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if read.Depth() != tree.Depth() || read.Len() != tree.Len() || read.Root().Cmp(tree.Root()) != 0 {
+		t.Fatal("read tree differs")
+	}
+	if _, err := read.ReadFrom(bytes.NewReader(buf.Bytes()[:buf.Len()-1])); err == nil {
+		t.Fatal("truncated tree must not be read")
+	}
+
+	path := filepath.Join(t.TempDir(), "tree")
+	if err := tree.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromFile.Root().Cmp(tree.Root()) != 0 {
+		t.Fatal("tree read from file differs")
+	}
+}
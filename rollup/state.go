@@ -0,0 +1,283 @@
+// Package rollup manages the account state of a rollup and produces the
+// witnesses of the rollup_transfer circuit. Accounts are merkle.Leaf leaves of
+// a MiMC Merkle tree, their key is the hash of an EdDSA public key on the
+// BLS12-381 twisted Edwards curve.
+package rollup
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"neo_zk_starter/merkle"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/mimc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards/eddsa"
+)
+
+// Account is an account of the rollup state.
+type Account struct {
+	PublicKey eddsa.PublicKey
+	Balance   uint64
+	Nonce     uint64 // number of transfers sent
+}
+
+// Leaf returns the tree leaf of the account.
+func (a Account) Leaf() merkle.Leaf {
+	return merkle.Leaf{
+		SenderKey: PublicKeyHash(&a.PublicKey),
+		Balance:   new(big.Int).SetUint64(a.Balance),
+		Nonce:     new(big.Int).SetUint64(a.Nonce),
+	}
+}
+
+// PublicKeyHash returns the account key of the public key, the MiMC hash of
+// its coordinates.
+func PublicKeyHash(pub *eddsa.PublicKey) *big.Int {
+	return merkle.HashElements(pub.A.X.BigInt(new(big.Int)), pub.A.Y.BigInt(new(big.Int)))
+}
+
+// TransferMessage returns the message the sender signs, the MiMC hash of the
+// sender key, the receiver key, the amount and the current sender nonce.
+func TransferMessage(senderKey, receiverKey *big.Int, amount, nonce uint64) *big.Int {
+	return merkle.HashElements(senderKey, receiverKey, new(big.Int).SetUint64(amount), new(big.Int).SetUint64(nonce))
+}
+
+// Transfer moves Amount from the account at index From to the account at
+// index To. Nonce is the nonce of the sender before the transfer.
+type Transfer struct {
+	From      uint64
+	To        uint64
+	Amount    uint64
+	Nonce     uint64
+	Signature []byte // EdDSA signature of TransferMessage with MiMC
+}
+
+// TransferWitness is a transfer with the account states and Merkle paths the
+// circuit needs. The receiver path is taken after the sender update. A NoOp
+// witness pads a batch, the circuit ignores its other fields.
+type TransferWitness struct {
+	NoOp             bool
+	SenderPublicKey  eddsa.PublicKey
+	Signature        []byte
+	ReceiverKey      *big.Int
+	Amount           uint64
+	SenderIndex      uint64
+	SenderBalance    uint64
+	SenderNonce      uint64
+	SenderSiblings   []*big.Int
+	ReceiverIndex    uint64
+	ReceiverBalance  uint64
+	ReceiverNonce    uint64
+	ReceiverSiblings []*big.Int
+}
+
+// Batch is the input of the rollup_transfer circuit, it moves the state from
+// OldRoot to NewRoot.
+type Batch struct {
+	OldRoot   *big.Int
+	NewRoot   *big.Int
+	Transfers []TransferWitness
+}
+
+// State is the account state of a rollup.
+type State struct {
+	tree     *merkle.Tree
+	accounts []Account
+}
+
+// NewState returns an empty state with a tree of the given depth.
+func NewState(depth int) (*State, error) {
+	tree, err := merkle.New(depth)
+	if err != nil {
+		return nil, err
+	}
+	return &State{tree: tree}, nil
+}
+
+// Depth returns the depth of the state tree.
+func (s *State) Depth() int {
+	return s.tree.Depth()
+}
+
+// Root returns the state root.
+func (s *State) Root() *big.Int {
+	return s.tree.Root()
+}
+
+// Len returns the number of accounts.
+func (s *State) Len() uint64 {
+	return uint64(len(s.accounts))
+}
+
+// AddAccount adds an account with the balance and returns its index.
+func (s *State) AddAccount(pub eddsa.PublicKey, balance uint64) (uint64, error) {
+	if !pub.A.IsOnCurve() {
+		return 0, errors.New("public key is not on the curve")
+	}
+	account := Account{PublicKey: pub, Balance: balance}
+	index, err := s.tree.Append(account.Leaf().Hash())
+	if err != nil {
+		return 0, err
+	}
+	s.accounts = append(s.accounts, account)
+	return index, nil
+}
+
+// Account returns the account at the index.
+func (s *State) Account(index uint64) (Account, error) {
+	if index >= s.Len() {
+		return Account{}, fmt.Errorf("account %d does not exist, the state has %d accounts", index, s.Len())
+	}
+	return s.accounts[index], nil
+}
+
+// SignTransfer fills the signature of the transfer, the key must be the one of
+// the sender account. The nonce is the one the sender has when the transfer is
+// applied, Account(From).Nonce unless earlier transfers of the same batch come
+// from the sender.
+func (s *State) SignTransfer(priv *eddsa.PrivateKey, t *Transfer) error {
+	sender, err := s.Account(t.From)
+	if err != nil {
+		return err
+	}
+	receiver, err := s.Account(t.To)
+	if err != nil {
+		return err
+	}
+	if !priv.PublicKey.Equal(&sender.PublicKey) {
+		return fmt.Errorf("key does not belong to account %d", t.From)
+	}
+
+	msg := messageBytes(TransferMessage(PublicKeyHash(&sender.PublicKey), PublicKeyHash(&receiver.PublicKey), t.Amount, t.Nonce))
+	t.Signature, err = priv.Sign(msg, mimc.NewMiMC())
+	return err
+}
+
+// Apply applies the transfers in order and returns the circuit input proving
+// the transition. The state is left unchanged when a transfer is invalid.
+func (s *State) Apply(transfers []Transfer) (*Batch, error) {
+	batch := &Batch{
+		OldRoot:   s.Root(),
+		Transfers: make([]TransferWitness, 0, len(transfers)),
+	}
+	saved := make(map[uint64]Account)
+	for i, t := range transfers {
+		w, err := s.apply(t, saved)
+		if err != nil {
+			s.revert(saved)
+			return nil, fmt.Errorf("transfer %d: %w", i, err)
+		}
+		batch.Transfers = append(batch.Transfers, w)
+	}
+	batch.NewRoot = s.Root()
+	return batch, nil
+}
+
+// apply checks and applies a single transfer, saved receives the accounts as
+// they were before their first change.
+func (s *State) apply(t Transfer, saved map[uint64]Account) (TransferWitness, error) {
+	sender, err := s.Account(t.From)
+	if err != nil {
+		return TransferWitness{}, err
+	}
+	receiver, err := s.Account(t.To)
+	if err != nil {
+		return TransferWitness{}, err
+	}
+	if t.Nonce != sender.Nonce {
+		return TransferWitness{}, fmt.Errorf("expected nonce %d, got %d", sender.Nonce, t.Nonce)
+	}
+	if sender.Nonce == math.MaxUint64 {
+		return TransferWitness{}, errors.New("sender nonce overflows")
+	}
+	if t.Amount > sender.Balance {
+		return TransferWitness{}, fmt.Errorf("balance %d is lower than the amount %d", sender.Balance, t.Amount)
+	}
+	if t.To != t.From && receiver.Balance > math.MaxUint64-t.Amount {
+		return TransferWitness{}, errors.New("receiver balance overflows")
+	}
+
+	receiverKey := PublicKeyHash(&receiver.PublicKey)
+	msg := messageBytes(TransferMessage(PublicKeyHash(&sender.PublicKey), receiverKey, t.Amount, t.Nonce))
+	ok, err := sender.PublicKey.Verify(t.Signature, msg, mimc.NewMiMC())
+	if err != nil {
+		return TransferWitness{}, fmt.Errorf("invalid signature: %w", err)
+	}
+	if !ok {
+		return TransferWitness{}, errors.New("invalid signature")
+	}
+
+	w := TransferWitness{
+		SenderPublicKey: sender.PublicKey,
+		Signature:       t.Signature,
+		ReceiverKey:     receiverKey,
+		Amount:          t.Amount,
+		SenderIndex:     t.From,
+		SenderBalance:   sender.Balance,
+		SenderNonce:     sender.Nonce,
+	}
+	w.SenderSiblings, _ = s.tree.Path(t.From)
+	sender.Balance -= t.Amount
+	sender.Nonce++
+	s.set(t.From, sender, saved)
+
+	// A transfer to the sender itself sees the updated sender
+	receiver = s.accounts[t.To]
+	w.ReceiverIndex = t.To
+	w.ReceiverBalance = receiver.Balance
+	w.ReceiverNonce = receiver.Nonce
+	w.ReceiverSiblings, _ = s.tree.Path(t.To)
+	receiver.Balance += t.Amount
+	s.set(t.To, receiver, saved)
+
+	return w, nil
+}
+
+// Pad appends no-op transfers to the batch until it holds size transfers, the
+// batch size of the circuit. They leave the state unchanged.
+func (s *State) Pad(batch *Batch, size int) error {
+	if len(batch.Transfers) > size {
+		return fmt.Errorf("batch holds %d transfers, more than %d", len(batch.Transfers), size)
+	}
+	for len(batch.Transfers) < size {
+		w := TransferWitness{
+			NoOp:             true,
+			ReceiverKey:      new(big.Int),
+			SenderSiblings:   make([]*big.Int, s.Depth()),
+			ReceiverSiblings: make([]*big.Int, s.Depth()),
+		}
+		for i := range w.SenderSiblings {
+			w.SenderSiblings[i], w.ReceiverSiblings[i] = new(big.Int), new(big.Int)
+		}
+		batch.Transfers = append(batch.Transfers, w)
+	}
+	return nil
+}
+
+func (s *State) set(index uint64, account Account, saved map[uint64]Account) {
+	if _, ok := saved[index]; !ok {
+		saved[index] = s.accounts[index]
+	}
+	s.accounts[index] = account
+	_ = s.tree.Update(index, account.Leaf().Hash())
+}
+
+func (s *State) revert(saved map[uint64]Account) {
+	for index, account := range saved {
+		s.accounts[index] = account
+		_ = s.tree.Update(index, account.Leaf().Hash())
+	}
+	clear(saved)
+}
+
+// messageBytes returns the message as the 32 bytes big endian field element
+// the signature hashes.
+func messageBytes(msg *big.Int) []byte {
+	var e fr.Element
+	e.SetBigInt(msg)
+	b := e.Bytes()
+	return b[:]
+}
@@ -0,0 +1,71 @@
+package rollup
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards/eddsa"
+)
+
+func newState(t *testing.T, depth int, balances ...uint64) (*State, []*eddsa.PrivateKey) {
+	state, err := NewState(depth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]*eddsa.PrivateKey, len(balances))
+	for i, balance := range balances {
+		keys[i], err = eddsa.GenerateKey(bytes.NewReader(bytes.Repeat([]byte{byte(i + 1)}, 32)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := state.AddAccount(keys[i].PublicKey, balance); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return state, keys
+}
+
+func TestApply(t *testing.T) {
+	state, keys := newState(t, 4, 100, 5)
+	oldRoot := state.Root()
+
+	sign := func(tr Transfer) Transfer {
+		if err := state.SignTransfer(keys[tr.From], &tr); err != nil {
+			t.Fatal(err)
+		}
+		return tr
+	}
+
+	// Invalid transfers leave the state unchanged
+	valid := sign(Transfer{From: 0, To: 1, Amount: 60})
+	invalid := map[string][]Transfer{
+		"overspend":       {valid, sign(Transfer{From: 0, To: 1, Amount: 60, Nonce: 1})},
+		"replayed nonce":  {valid, valid},
+		"wrong signer":    {func() Transfer { tr := valid; tr.Signature = sign(Transfer{From: 1, To: 1}).Signature; return tr }()},
+		"unknown account": {sign(Transfer{From: 0, To: 1}), {From: 0, To: 7}},
+	}
+	for name, transfers := range invalid {
+		if _, err := state.Apply(transfers); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+		if state.Root().Cmp(oldRoot) != 0 {
+			t.Fatalf("%s: the state changed", name)
+		}
+	}
+	if err := state.SignTransfer(keys[1], &Transfer{From: 0, To: 1}); err == nil {
+		t.Fatal("signing with another key must fail")
+	}
+
+	batch, err := state.Apply([]Transfer{valid, sign(Transfer{From: 1, To: 1, Amount: 65}), sign(Transfer{From: 0, To: 0, Amount: 40, Nonce: 1})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.OldRoot.Cmp(oldRoot) != 0 || batch.NewRoot.Cmp(state.Root()) != 0 {
+		t.Fatal("unexpected batch roots")
+	}
+	sender, _ := state.Account(0)
+	receiver, _ := state.Account(1)
+	if sender.Balance != 40 || sender.Nonce != 2 || receiver.Balance != 65 || receiver.Nonce != 1 {
+		t.Fatalf("unexpected accounts %+v %+v", sender, receiver)
+	}
+}
@@ -0,0 +1,209 @@
+// Package smt implements a sparse Merkle tree keyed by field elements that
+// hashes exactly like the smt_verify circuit, its proofs are inputs of the
+// circuit.
+package smt
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"neo_zk_starter/circuits/smt_verify"
+	"neo_zk_starter/merkle"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// FullDepth is the depth at which every key has a position of its own.
+const FullDepth = smt_verify.FullDepth
+
+// entry is a key and its value stored at a leaf position.
+type entry struct {
+	key   *big.Int
+	value *big.Int
+}
+
+// Tree is a sparse Merkle tree of fixed depth. The position of a key is given
+// by its lowest depth bits, the leaf is MiMC(key, value) and empty positions
+// are zero. Only the nodes above set keys are kept.
+//
+// Below FullDepth two keys can share a position, the tree then refuses the
+// second key.
+type Tree struct {
+	depth int
+	mask  *big.Int
+	// entries maps a position to the key stored there
+	entries map[string]entry
+	// nodes[i] maps a node index at level i to its hash, nodes[0] are leaves
+	nodes []map[string]*big.Int
+	// zeros[i] is the root of an empty subtree of height i
+	zeros []*big.Int
+}
+
+// New returns an empty tree of the given depth.
+func New(depth int) (*Tree, error) {
+	if depth < 1 || depth > FullDepth {
+		return nil, fmt.Errorf("depth must be between 1 and %d, got %d", FullDepth, depth)
+	}
+
+	t := &Tree{
+		depth:   depth,
+		mask:    new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(depth)), big.NewInt(1)),
+		entries: make(map[string]entry),
+		nodes:   make([]map[string]*big.Int, depth+1),
+		zeros:   make([]*big.Int, depth+1),
+	}
+	t.zeros[0] = new(big.Int)
+	for i := range t.nodes {
+		t.nodes[i] = make(map[string]*big.Int)
+		if i > 0 {
+			t.zeros[i] = merkle.HashElements(t.zeros[i-1], t.zeros[i-1])
+		}
+	}
+	return t, nil
+}
+
+// Depth returns the depth of the tree.
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// Len returns the number of keys in the tree.
+func (t *Tree) Len() int {
+	return len(t.entries)
+}
+
+// Root returns the root of the tree.
+func (t *Tree) Root() *big.Int {
+	return new(big.Int).Set(t.node(t.depth, new(big.Int)))
+}
+
+// Get returns the value of the key, ok is false when the key is not set.
+func (t *Tree) Get(key *big.Int) (value *big.Int, ok bool, err error) {
+	if err := checkElement("key", key); err != nil {
+		return nil, false, err
+	}
+	e, found := t.entries[t.position(key).String()]
+	if !found || e.key.Cmp(key) != 0 {
+		return nil, false, nil
+	}
+	return new(big.Int).Set(e.value), true, nil
+}
+
+// Set sets the value of the key. It fails when another key already holds the
+// position of the key.
+func (t *Tree) Set(key, value *big.Int) error {
+	if err := checkElement("key", key); err != nil {
+		return err
+	}
+	if err := checkElement("value", value); err != nil {
+		return err
+	}
+	pos := t.position(key)
+	if e, found := t.entries[pos.String()]; found && e.key.Cmp(key) != 0 {
+		return fmt.Errorf("key %s collides with key %s at depth %d", key, e.key, t.depth)
+	}
+
+	t.entries[pos.String()] = entry{key: new(big.Int).Set(key), value: new(big.Int).Set(value)}
+	t.updatePath(pos, merkle.HashElements(key, value))
+	return nil
+}
+
+// Delete removes the key, it is a no-op when the key is not set.
+func (t *Tree) Delete(key *big.Int) error {
+	if err := checkElement("key", key); err != nil {
+		return err
+	}
+	pos := t.position(key)
+	if e, found := t.entries[pos.String()]; !found || e.key.Cmp(key) != 0 {
+		return nil
+	}
+
+	delete(t.entries, pos.String())
+	t.updatePath(pos, nil)
+	return nil
+}
+
+// updatePath stores the leaf at the position, nil for an empty position, and
+// recomputes the nodes up to the root. Empty subtrees are not stored.
+func (t *Tree) updatePath(pos *big.Int, leaf *big.Int) {
+	index := new(big.Int).Set(pos)
+	t.setNode(0, index, leaf)
+	for level := 0; level < t.depth; level++ {
+		sibling := t.node(level, new(big.Int).Xor(index, big.NewInt(1)))
+		current := t.node(level, index)
+		left, right := current, sibling
+		if index.Bit(0) == 1 {
+			left, right = sibling, current
+		}
+
+		index.Rsh(index, 1)
+		h := merkle.HashElements(left, right)
+		if h.Cmp(t.zeros[level+1]) == 0 {
+			h = nil
+		}
+		t.setNode(level+1, index, h)
+	}
+}
+
+func (t *Tree) setNode(level int, index, h *big.Int) {
+	if h == nil {
+		delete(t.nodes[level], index.String())
+		return
+	}
+	t.nodes[level][index.String()] = h
+}
+
+func (t *Tree) node(level int, index *big.Int) *big.Int {
+	if h, ok := t.nodes[level][index.String()]; ok {
+		return h
+	}
+	return t.zeros[level]
+}
+
+// position returns the leaf position of the key, its lowest depth bits.
+func (t *Tree) position(key *big.Int) *big.Int {
+	return new(big.Int).And(key, t.mask)
+}
+
+// Proof returns the input of a smt_verify circuit of the tree depth. It is an
+// inclusion proof when the key is set and an exclusion proof otherwise.
+func (t *Tree) Proof(key *big.Int) (smt_verify.Input, error) {
+	if err := checkElement("key", key); err != nil {
+		return smt_verify.Input{}, err
+	}
+
+	pos := t.position(key)
+	index := new(big.Int).Set(pos)
+	siblings := make([]*big.Int, t.depth)
+	for level := range siblings {
+		siblings[level] = new(big.Int).Set(t.node(level, new(big.Int).Xor(index, big.NewInt(1))))
+		index.Rsh(index, 1)
+	}
+
+	input := smt_verify.Input{
+		Root:     t.Root(),
+		Key:      new(big.Int).Set(key),
+		Siblings: siblings,
+	}
+	if e, found := t.entries[pos.String()]; found {
+		if e.key.Cmp(key) == 0 {
+			input.Exists = true
+			input.Value = new(big.Int).Set(e.value)
+		} else {
+			input.OldKey = new(big.Int).Set(e.key)
+			input.OldValue = new(big.Int).Set(e.value)
+		}
+	}
+	return input, nil
+}
+
+func checkElement(name string, v *big.Int) error {
+	if v == nil {
+		return fmt.Errorf("%s is nil", name)
+	}
+	if v.Sign() < 0 || v.Cmp(ecc.BLS12_381.ScalarField()) >= 0 {
+		return errors.New(name + " is not a field element")
+	}
+	return nil
+}
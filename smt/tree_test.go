@@ -0,0 +1,114 @@
+package smt
+
+import (
+	"math/big"
+	"testing"
+
+	"neo_zk_starter/circuits/smt_verify"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/test"
+)
+
+func TestTree(t *testing.T) {
+	tree, err := New(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := tree.Root()
+
+	if err := tree.Set(big.NewInt(3), big.NewInt(30)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Set(big.NewInt(200), big.NewInt(2000)); err != nil {
+		t.Fatal(err)
+	}
+	withBoth := tree.Root()
+
+	// Keys sharing the lowest 8 bits collide
+	if err := tree.Set(big.NewInt(3+1<<8), big.NewInt(1)); err == nil {
+		t.Fatal("colliding key must be rejected")
+	}
+	if value, ok, err := tree.Get(big.NewInt(3)); err != nil || !ok || value.Int64() != 30 {
+		t.Fatalf("unexpected value %v, %v, %v", value, ok, err)
+	}
+	if _, ok, _ := tree.Get(big.NewInt(3 + 1<<8)); ok {
+		t.Fatal("colliding key must not be found")
+	}
+
+	// The root does not depend on the insertion order
+	other, _ := New(8)
+	_ = other.Set(big.NewInt(200), big.NewInt(2000))
+	_ = other.Set(big.NewInt(3), big.NewInt(30))
+	if other.Root().Cmp(withBoth) != 0 {
+		t.Fatal("root depends on the insertion order")
+	}
+
+	// Deleting every key gives the empty root back
+	if err := tree.Delete(big.NewInt(3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Delete(big.NewInt(200)); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Len() != 0 || tree.Root().Cmp(empty) != 0 {
+		t.Fatal("empty tree has a different root")
+	}
+
+	if err := tree.Set(ecc.BLS12_381.ScalarField(), big.NewInt(1)); err == nil {
+		t.Fatal("key outside of the field must be rejected")
+	}
+	if _, err := New(FullDepth + 1); err == nil {
+		t.Fatal("too deep tree must be rejected")
+	}
+}
+
+// TestCircuitProofs cross-checks the native tree with the smt_verify circuit.
+func TestCircuitProofs(t *testing.T) {
+	for _, depth := range []int{8, FullDepth} {
+		tree, err := New(depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		circuit, err := smt_verify.New(depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 2^254 + 1 shares the position of key 1 unless the tree is full depth
+		large := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 254), big.NewInt(1))
+		for i, key := range []*big.Int{big.NewInt(1), big.NewInt(42), large} {
+			err := tree.Set(key, big.NewInt(int64(100+i)))
+			if err != nil && (key != large || depth == FullDepth) {
+				t.Fatal(err)
+			}
+		}
+
+		cases := []struct {
+			key    *big.Int
+			exists bool
+		}{
+			{big.NewInt(1), true},
+			{big.NewInt(42), true},
+			{large, depth == FullDepth},
+			{big.NewInt(2), false},
+			{big.NewInt(1 + 1<<8), false}, // shares the position of key 1 at depth 8
+		}
+		for _, c := range cases {
+			input, err := tree.Proof(c.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if input.Exists != c.exists {
+				t.Fatalf("depth %d key %s: expected exists %v", depth, c.key, c.exists)
+			}
+			assignment, _, err := circuit.PrepareInput(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := test.IsSolved(circuit, assignment, ecc.BLS12_381.ScalarField()); err != nil {
+				t.Fatalf("depth %d key %s: %v", depth, c.key, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ContentAddressedStore keeps every artifact version under root/objects named
+// by the sha256 of its content, and the current version of each artifact as a
+// reference under root/refs/<namespace>/<file name>. Identical artifacts are
+// stored once and replaced versions stay available by hash.
+type ContentAddressedStore struct {
+	root string
+}
+
+// NewContentAddressedStore returns a content-addressed store rooted at the
+// given directory.
+func NewContentAddressedStore(root string) *ContentAddressedStore {
+	return &ContentAddressedStore{root: root}
+}
+
+func (s *ContentAddressedStore) refPath(ns Namespace, circuitName, name string) string {
+	return filepath.Join(s.root, "refs", string(ns), FileName(ns, circuitName, name))
+}
+
+func (s *ContentAddressedStore) objectPath(hash string) string {
+	return filepath.Join(s.root, "objects", hash[:2], hash[2:])
+}
+
+// Hash returns the content hash the artifact currently refers to.
+func (s *ContentAddressedStore) Hash(ns Namespace, circuitName, name string) (string, error) {
+	ref, err := os.ReadFile(s.refPath(ns, circuitName, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", notFound(ns, circuitName, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read reference: %w", err)
+	}
+	return strings.TrimSpace(string(ref)), nil
+}
+
+// Create implements ArtifactStore.
+func (s *ContentAddressedStore) Create(ns Namespace, circuitName, name string) (io.WriteCloser, error) {
+	return &casWriter{store: s, ref: s.refPath(ns, circuitName, name)}, nil
+}
+
+// Open implements ArtifactStore.
+func (s *ContentAddressedStore) Open(ns Namespace, circuitName, name string) (io.ReadCloser, error) {
+	hash, err := s.Hash(ns, circuitName, name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(s.objectPath(hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object %s: %w", hash, err)
+	}
+	return file, nil
+}
+
+// Exists implements ArtifactStore.
+func (s *ContentAddressedStore) Exists(ns Namespace, circuitName, name string) (bool, error) {
+	_, err := os.Stat(s.refPath(ns, circuitName, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// casWriter buffers the artifact, on Close it stores the object and then
+// points the reference at it.
+type casWriter struct {
+	bytes.Buffer
+	store *ContentAddressedStore
+	ref   string
+}
+
+func (w *casWriter) Close() error {
+	sum := sha256.Sum256(w.Bytes())
+	hash := hex.EncodeToString(sum[:])
+
+	object := w.store.objectPath(hash)
+	if _, err := os.Stat(object); errors.Is(err, os.ErrNotExist) {
+		if err := writeFileAtomic(object, w.Bytes()); err != nil {
+			return fmt.Errorf("failed to write object %s: %w", hash, err)
+		}
+	}
+	if err := writeFileAtomic(w.ref, []byte(hash+"\n")); err != nil {
+		return fmt.Errorf("failed to write reference: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
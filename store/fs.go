@@ -0,0 +1,57 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FileStore keeps artifacts in the data/ and contract/ directories under root.
+type FileStore struct {
+	root string
+}
+
+// NewFileStore returns a filesystem store rooted at the given directory.
+func NewFileStore(root string) *FileStore {
+	return &FileStore{root: root}
+}
+
+// Path implements Locator.
+func (s *FileStore) Path(ns Namespace, circuitName, name string) string {
+	return filepath.Join(s.root, string(ns), FileName(ns, circuitName, name))
+}
+
+// Create implements ArtifactStore.
+func (s *FileStore) Create(ns Namespace, circuitName, name string) (io.WriteCloser, error) {
+	if err := os.MkdirAll(filepath.Join(s.root, string(ns)), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s directory: %w", ns, err)
+	}
+	file, err := os.Create(s.Path(ns, circuitName, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	return file, nil
+}
+
+// Open implements ArtifactStore.
+func (s *FileStore) Open(ns Namespace, circuitName, name string) (io.ReadCloser, error) {
+	file, err := os.Open(s.Path(ns, circuitName, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, notFound(ns, circuitName, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return file, nil
+}
+
+// Exists implements ArtifactStore.
+func (s *FileStore) Exists(ns Namespace, circuitName, name string) (bool, error) {
+	_, err := os.Stat(s.Path(ns, circuitName, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
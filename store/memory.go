@@ -0,0 +1,63 @@
+package store
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// MemoryStore keeps artifacts in memory, it is meant for tests.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	artifacts map[string][]byte
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{artifacts: make(map[string][]byte)}
+}
+
+func memoryKey(ns Namespace, circuitName, name string) string {
+	return string(ns) + "/" + FileName(ns, circuitName, name)
+}
+
+// Create implements ArtifactStore.
+func (s *MemoryStore) Create(ns Namespace, circuitName, name string) (io.WriteCloser, error) {
+	return &memoryWriter{store: s, key: memoryKey(ns, circuitName, name)}, nil
+}
+
+// Open implements ArtifactStore.
+func (s *MemoryStore) Open(ns Namespace, circuitName, name string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.artifacts[memoryKey(ns, circuitName, name)]
+	if !ok {
+		return nil, notFound(ns, circuitName, name)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Exists implements ArtifactStore.
+func (s *MemoryStore) Exists(ns Namespace, circuitName, name string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.artifacts[memoryKey(ns, circuitName, name)]
+	return ok, nil
+}
+
+// memoryWriter buffers the artifact and stores it on Close.
+type memoryWriter struct {
+	bytes.Buffer
+	store *MemoryStore
+	key   string
+}
+
+func (w *memoryWriter) Close() error {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+
+	w.store.artifacts[w.key] = bytes.Clone(w.Bytes())
+	return nil
+}
@@ -0,0 +1,58 @@
+// Package store persists the artifacts produced for circuits: keys, compiled
+// constraint systems, fingerprints, ceremony state and generated contracts.
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Namespace separates circuit data from generated contract sources, they are
+// laid out in the data/ and contract/ directories of a filesystem store.
+type Namespace string
+
+const (
+	Data     Namespace = "data"
+	Contract Namespace = "contract"
+)
+
+// ErrNotFound is returned when an artifact does not exist in the store.
+var ErrNotFound = errors.New("artifact not found")
+
+// ArtifactStore reads and writes named artifacts of a circuit.
+type ArtifactStore interface {
+	// Create returns a writer for the artifact, its content replaces any
+	// previous version once the writer is closed.
+	Create(ns Namespace, circuitName, name string) (io.WriteCloser, error)
+	// Open returns a reader for the artifact, the error wraps ErrNotFound
+	// when it does not exist.
+	Open(ns Namespace, circuitName, name string) (io.ReadCloser, error)
+	// Exists reports whether the artifact is present.
+	Exists(ns Namespace, circuitName, name string) (bool, error)
+}
+
+// Locator is implemented by stores that keep artifacts as plain files. Tools
+// that need a path on disk, like the contract compiler, use it directly and
+// fall back to a temporary copy for other stores.
+type Locator interface {
+	Path(ns Namespace, circuitName, name string) string
+}
+
+// FileName returns the file name of the artifact in its namespace. Data
+// artifacts are named <circuit>_<name>, contract artifacts <circuit>-<name>.
+// Artifacts shared by all circuits use an empty circuit name.
+func FileName(ns Namespace, circuitName, name string) string {
+	switch {
+	case circuitName == "":
+		return name
+	case ns == Contract:
+		return fmt.Sprintf("%s-%s", circuitName, name)
+	default:
+		return fmt.Sprintf("%s_%s", circuitName, name)
+	}
+}
+
+func notFound(ns Namespace, circuitName, name string) error {
+	return fmt.Errorf("%w: %s/%s", ErrNotFound, ns, FileName(ns, circuitName, name))
+}
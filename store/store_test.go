@@ -0,0 +1,120 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func write(t *testing.T, s ArtifactStore, ns Namespace, circuitName, name, data string) {
+	w, err := s.Create(ns, circuitName, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func read(t *testing.T, s ArtifactStore, ns Namespace, circuitName, name string) string {
+	r, err := s.Open(ns, circuitName, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestArtifactStores(t *testing.T) {
+	stores := map[string]ArtifactStore{
+		"file":              NewFileStore(t.TempDir()),
+		"memory":            NewMemoryStore(),
+		"content-addressed": NewContentAddressedStore(t.TempDir()),
+	}
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.Open(Data, "circuit", "prover_key"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("expected ErrNotFound, got %v", err)
+			}
+			if ok, err := s.Exists(Data, "circuit", "prover_key"); err != nil || ok {
+				t.Fatalf("artifact must not exist: %v", err)
+			}
+
+			write(t, s, Data, "circuit", "prover_key", "key")
+			write(t, s, Contract, "circuit", "prover_key", "contract")
+			if ok, err := s.Exists(Data, "circuit", "prover_key"); err != nil || !ok {
+				t.Fatalf("artifact must exist: %v", err)
+			}
+			if got := read(t, s, Data, "circuit", "prover_key"); got != "key" {
+				t.Fatalf("unexpected data artifact %q", got)
+			}
+			if got := read(t, s, Contract, "circuit", "prover_key"); got != "contract" {
+				t.Fatalf("unexpected contract artifact %q", got)
+			}
+
+			// Replacing an artifact.
+			write(t, s, Data, "circuit", "prover_key", "new key")
+			if got := read(t, s, Data, "circuit", "prover_key"); got != "new key" {
+				t.Fatalf("unexpected replaced artifact %q", got)
+			}
+		})
+	}
+}
+
+func TestFileStoreLayout(t *testing.T) {
+	root := t.TempDir()
+	s := NewFileStore(root)
+	write(t, s, Data, "hash_commit", "prover_key", "key")
+	write(t, s, Contract, "hash_commit", "verifier.go", "src")
+	write(t, s, Contract, "", "go.mod", "mod")
+
+	for _, path := range []string{
+		filepath.Join(root, "data", "hash_commit_prover_key"),
+		filepath.Join(root, "contract", "hash_commit-verifier.go"),
+		filepath.Join(root, "contract", "go.mod"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestContentAddressedStoreDeduplicates(t *testing.T) {
+	root := t.TempDir()
+	s := NewContentAddressedStore(root)
+	write(t, s, Data, "a", "prover_key", "same")
+	write(t, s, Data, "b", "prover_key", "same")
+
+	ha, err := s.Hash(Data, "a", "prover_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hb, err := s.Hash(Data, "b", "prover_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ha != hb {
+		t.Fatalf("identical artifacts must share an object: %s != %s", ha, hb)
+	}
+
+	// The previous version stays available by hash.
+	write(t, s, Data, "a", "prover_key", "changed")
+	old, err := os.ReadFile(s.objectPath(ha))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(old, []byte("same")) {
+		t.Fatalf("unexpected old object %q", old)
+	}
+}